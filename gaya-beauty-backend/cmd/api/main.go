@@ -1,120 +1,210 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
 	"fmt"
+	"gaya-beauty-backend/internal/alerts"
+	"gaya-beauty-backend/internal/config"
 	"gaya-beauty-backend/internal/database"
 	"gaya-beauty-backend/internal/handlers"
+	"gaya-beauty-backend/internal/imaging"
+	"gaya-beauty-backend/internal/inventory"
+	"gaya-beauty-backend/internal/migrations"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/payment"
+	"gaya-beauty-backend/internal/search"
+	"gaya-beauty-backend/internal/storage"
+	"log"
 	"net/http"
 	"os"
 )
 
 func main() {
+	// 0. BACA CONFIG (env + file config.toml opsional, langsung divalidasi)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Config tidak valid:\n", err)
+	}
+
 	// 1. KONEK DATABASE
-	db := database.ConnectDB()
+	db := database.ConnectDB(cfg.Database)
 	defer db.Close()
 
-	// =================================================================
-	// ☢️ PINTU DARURAT: RESET DB (UPDATED SCHEMA) ☢️
-	// Akses: [LinkKoyeb]/reset-db-now
-	// =================================================================
-	http.HandleFunc("/reset-db-now", func(w http.ResponseWriter, r *http.Request) {
-		// A. Hapus Tabel Lama (Urutan Penting karena Foreign Key)
-		db.Exec("DROP TABLE IF EXISTS order_items")
-		db.Exec("DROP TABLE IF EXISTS orders")
-		db.Exec("DROP TABLE IF EXISTS products")
-		db.Exec("DROP TABLE IF EXISTS users")
-
-		// B. Tabel USERS
-		queryUser := `
-		CREATE TABLE users (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			full_name VARCHAR(100),
-			email VARCHAR(100) UNIQUE NOT NULL,
-			password VARCHAR(255) NOT NULL,
-			role VARCHAR(50) DEFAULT 'admin',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`
-		if _, err := db.Exec(queryUser); err != nil { fmt.Fprintf(w, "Gagal User: %v\n", err) }
-
-		// C. Tabel PRODUCTS
-		queryProduct := `
-		CREATE TABLE products (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			name VARCHAR(255),
-			price DECIMAL(10, 2),
-			stock INT,
-			category VARCHAR(100),
-			description TEXT,
-			image_url VARCHAR(255),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`
-		if _, err := db.Exec(queryProduct); err != nil { fmt.Fprintf(w, "Gagal Product: %v\n", err) }
-
-		// D. Tabel ORDERS (Struktur Baru)
-		queryOrder := `
-		CREATE TABLE orders (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			customer_id INT NOT NULL,
-			customer_name VARCHAR(255),
-			total_price DECIMAL(10, 2) NOT NULL,
-			status VARCHAR(50) DEFAULT 'Pending',
-			payment_method VARCHAR(50),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`
-		if _, err := db.Exec(queryOrder); err != nil { fmt.Fprintf(w, "Gagal Order: %v\n", err) }
-
-		// E. Tabel ORDER_ITEMS (Struktur Baru)
-		queryOrderItems := `
-		CREATE TABLE order_items (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			order_id INT NOT NULL,
-			product_id INT NOT NULL,
-			quantity INT NOT NULL,
-			price DECIMAL(10, 2) NOT NULL,
-			FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
-			FOREIGN KEY (product_id) REFERENCES products(id)
-		)`
-		if _, err := db.Exec(queryOrderItems); err != nil { fmt.Fprintf(w, "Gagal Order Items: %v\n", err) }
-
-		fmt.Fprintf(w, "\n DATABASE BERHASIL DI-RESET DENGAN STRUKTUR BARU!")
-	})
+	// 2. MIGRATION DATABASE
+	// Mode CLI: `go run ./cmd/api migrate up|down [n]|status` lalu keluar
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Run(db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Mode CLI: `go run ./cmd/api bootstrap-admin -email ... -name ...` (password dari env ADMIN_PASSWORD)
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		runBootstrapAdmin(db, cfg, os.Args[2:])
+		return
+	}
+
+	// Mode server: jalanin migration yang belum ada biar skema selalu up to date
+	applied, err := migrations.Up(db)
+	if err != nil {
+		log.Fatal("Gagal migrate database: ", err)
+	}
+	fmt.Printf("Migration beres (%d baru dijalankan)\n", applied)
+
+	// Seed owner pertama dari config (cuma jalan kalau tabel users masih kosong)
+	if cfg.Admin.Email != "" {
+		err := handlers.BootstrapOwner(db, cfg.Admin.Name, cfg.Admin.Email, cfg.Admin.Password)
+		if err == nil {
+			fmt.Println("Akun owner dibuat buat:", cfg.Admin.Email)
+		} else if err != handlers.ErrAlreadyBootstrapped {
+			log.Fatal("Gagal bikin akun owner: ", err)
+		}
+	}
+
+	// Job background: batalin order transfer yang gak dibayar-bayar biar stoknya balik
+	if cfg.Orders.PendingTimeout > 0 {
+		go orders.StartAutoCancel(context.Background(), db, cfg.Orders.PendingTimeout, cfg.Orders.AutoCancelInterval)
+	}
+
+	// Job background: sisa batch yang sudah kadaluarsa dikeluarin dari stok (tercatat di ledger)
+	go inventory.StartExpiryWriteOff(context.Background(), db, cfg.Inventory.ExpiryCheckInterval)
+
+	// Job background: cek stok menipis rutin + tiap habis checkout / edit produk,
+	// hasilnya masuk notifikasi admin (dan email/WhatsApp kalau diisi di [alerts])
+	lowStock := alerts.NewLowStockChecker(db, cfg.Alerts.LowStockThreshold, alerts.NotifiersFromConfig(cfg.Alerts)...)
+	go lowStock.Start(context.Background(), cfg.Alerts.CheckInterval)
+
+	// Index pencarian produk di memori, diisi dari DB sekali di awal
+	// (selanjutnya di-sync sama handler create/update/delete produk)
+	searchIndex := search.NewIndex()
+	if err := searchIndex.Load(db); err != nil {
+		log.Fatal("Gagal bangun index pencarian: ", err)
+	}
+	fmt.Printf("Index pencarian siap (%d produk)\n", searchIndex.Len())
+
+	// Storage foto produk (folder lokal / Cloudinary, lihat [storage] di config)
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Gagal siapin storage upload: ", err)
+	}
+	// Bukti transfer customer: folder privat, sengaja gak ikut disajikan di /uploads/
+	receipts, err := storage.NewPrivate(cfg.Storage.PrivateDir)
+	if err != nil {
+		log.Fatal("Gagal siapin storage bukti transfer: ", err)
+	}
+	if !imaging.WebPSupported {
+		log.Println("Build tanpa cgo: foto produk cuma dibikin JPEG (tanpa WebP)")
+	}
+
+	// Payment gateway buat metode bayar "Online" (nil = mati, lihat [payment] di config)
+	payments, err := payment.New(cfg.Payment)
+	if err != nil {
+		log.Fatal("Gagal siapin payment gateway: ", err)
+	}
+	if payments != nil {
+		fmt.Println("Pembayaran online aktif lewat", payments.Name())
+	}
 
 	// =================================================================
 	// DAFTAR RUTE (ROUTING)
 	// =================================================================
+	mux := http.NewServeMux()
 
 	// 1. PUBLIC ROUTES
-	http.HandleFunc("/login", handlers.HandleLogin(db))
-	http.HandleFunc("/register", handlers.HandleRegister(db))
-	http.HandleFunc("/products", handlers.HandleProducts(db))
-	http.HandleFunc("/checkout", handlers.HandleCheckout(db))
-	
+	mux.HandleFunc("/login", handlers.HandleLogin(db, cfg.Auth))
+	mux.HandleFunc("/invites/accept", handlers.HandleAcceptInvite(db))
+	mux.HandleFunc("/products", handlers.HandleProducts(db))
+	mux.HandleFunc("/products/{id}", handlers.HandleProductDetail(db))
+	mux.HandleFunc("/search", handlers.HandleSearch(db, searchIndex))
+	mux.HandleFunc("/categories", handlers.HandleCategories(db))
+	mux.HandleFunc("/order-statuses", handlers.HandleOrderStatuses())
+	mux.HandleFunc("POST /payments/notify", handlers.HandlePaymentWebhook(db, payments)) // Webhook gateway (dicek tanda tangannya)
+
 	// 2. CUSTOMER ROUTES
-	http.HandleFunc("/customer/register", handlers.HandleCustomerRegister(db))
-	http.HandleFunc("/customer/login", handlers.HandleCustomerLogin(db))
-	http.HandleFunc("/my-orders", handlers.HandleGetMyOrders(db))
-	http.HandleFunc("/complete-order", handlers.HandleCompleteOrder(db))
+	mux.HandleFunc("/customer/register", handlers.HandleCustomerRegister(db))
+	mux.HandleFunc("/customer/login", handlers.HandleCustomerLogin(db, cfg.Auth))
+	mux.HandleFunc("/cart", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandleCart(db)))
+	mux.HandleFunc("/checkout", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandleCheckout(db, lowStock, payments)))
+	mux.HandleFunc("/my-orders", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandleGetMyOrders(db)))
+	mux.HandleFunc("/complete-order", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandleCompleteOrder(db)))
+	mux.HandleFunc("/my-orders/cancel", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandleCustomerCancelOrder(db)))
+	mux.HandleFunc("POST /my-orders/pay", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandlePayOrder(db, payments)))
+	mux.HandleFunc("POST /my-orders/transfer-proof", handlers.CustomerAuthMiddleware(cfg.Auth, handlers.HandleUploadTransferProof(db, receipts, cfg.Storage.MaxUploadMB)))
 
 	// 3. ADMIN ROUTES (Protected)
+	// Akses tiap rute dicek lewat tabel permission (lihat handlers/permissions.go)
 	// Order Management
-	http.HandleFunc("/orders", handlers.AuthMiddleware(handlers.HandleGetOrders(db)))
-	http.HandleFunc("/orders/update", handlers.AuthMiddleware(handlers.HandleUpdateOrderStatus(db))) // Jalur Update Status
+	mux.HandleFunc("/orders", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersRead, handlers.HandleGetOrders(db)))
+	mux.HandleFunc("/orders/update", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleUpdateOrderStatus(db))) // Jalur Update Status
+	mux.HandleFunc("/orders/cancel", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleAdminCancelOrder(db)))
+	mux.HandleFunc("GET /payments/notifications", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersRead, handlers.HandlePaymentNotifications(db)))
+	mux.HandleFunc("GET /payments/transfer-proofs", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersRead, handlers.HandleTransferProofs(db))) // Antrian verifikasi transfer
+	mux.HandleFunc("POST /payments/transfer-proofs/approve", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleApproveTransferProof(db)))
+	mux.HandleFunc("POST /payments/transfer-proofs/reject", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleRejectTransferProof(db)))
+	mux.HandleFunc("GET /payments/transfer-proofs/{id}/receipt", handlers.AnyAuthMiddleware(db, cfg.Auth, handlers.HandleTransferProofReceipt(db, receipts)))           // Admin & customer pemilik
+	mux.HandleFunc("POST /payments/reconciliation/import", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleImportBankStatement(db))) // Upload CSV mutasi rekening
+	mux.HandleFunc("GET /payments/reconciliation", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersRead, handlers.HandleBankStatementLines(db)))
+	mux.HandleFunc("POST /payments/reconciliation/confirm", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleConfirmStatementMatches(db)))
+	mux.HandleFunc("POST /payments/reconciliation/ignore", handlers.RequirePermission(db, cfg.Auth, handlers.PermOrdersUpdate, handlers.HandleIgnoreStatementLines(db)))
+	mux.HandleFunc("GET /orders/{id}/history", handlers.AnyAuthMiddleware(db, cfg.Auth, handlers.HandleOrderHistory(db))) // Admin & customer
+
+	// Staff Management (undangan akun admin/staff/packer)
+	mux.HandleFunc("/invites", handlers.RequirePermission(db, cfg.Auth, handlers.PermUsersInvite, handlers.HandleCreateInvite(db)))
 
 	// Product Management
-	http.HandleFunc("/products/create", handlers.AuthMiddleware(handlers.HandleCreateProduct(db)))
-	http.HandleFunc("/products/update", handlers.AuthMiddleware(handlers.HandleUpdateProduct(db)))
-	http.HandleFunc("/products/delete", handlers.AuthMiddleware(handlers.HandleDeleteProduct(db)))
+	mux.HandleFunc("/products/create", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleCreateProduct(db, searchIndex, lowStock)))
+	mux.HandleFunc("/products/update", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleUpdateProduct(db, searchIndex, lowStock)))
+	mux.HandleFunc("/products/delete", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsDelete, handlers.HandleDeleteProduct(db, searchIndex)))
+	mux.HandleFunc("/products/restore", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsDelete, handlers.HandleRestoreProduct(db, searchIndex)))
+	mux.HandleFunc("GET /products/admin", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleAdminProducts(db))) // Termasuk draft & arsip
+	mux.HandleFunc("POST /products/{id}/images", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleUploadProductImages(db, store, cfg.Storage.MaxUploadMB)))
+	mux.HandleFunc("PUT /products/{id}/images/order", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleReorderProductImages(db)))
+	mux.HandleFunc("DELETE /products/{id}/images/{imageID}", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleDeleteProductImage(db, store)))
+
+	// Category Management
+	mux.HandleFunc("/categories/create", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleCreateCategory(db)))
+	mux.HandleFunc("/categories/update", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleUpdateCategory(db, searchIndex)))
+	mux.HandleFunc("/categories/delete", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsDelete, handlers.HandleDeleteCategory(db)))
+
+	// Inventory (ledger stok)
+	mux.HandleFunc("POST /inventory/adjust", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleAdjustInventory(db, lowStock)))
+	mux.HandleFunc("GET /inventory/movements", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleInventoryMovements(db)))
+	mux.HandleFunc("GET /inventory/drift", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleInventoryDrift(db)))
+	mux.HandleFunc("GET /inventory/batches", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleStockBatches(db)))
+	mux.HandleFunc("GET /inventory/batches/expiring", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleExpiringBatches(db)))
+	mux.HandleFunc("PUT /inventory/reorder-level", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleSetReorderLevel(db, lowStock)))
+
+	// Notifikasi admin (stok menipis, dst.)
+	mux.HandleFunc("GET /notifications", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleNotifications(db)))
+	mux.HandleFunc("POST /notifications/acknowledge", handlers.RequirePermission(db, cfg.Auth, handlers.PermProductsWrite, handlers.HandleAcknowledgeNotifications(db)))
 
 	// 4. STATIC FILES (Images)
-	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
+	mux.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir(cfg.Storage.LocalDir))))
 
 	// =================================================================
 	// START SERVER
 	// =================================================================
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
+	fmt.Printf(" Server GAYA BEAUTY jalan di Port: %s (mode %s)\n", cfg.Port, cfg.Env)
+	log.Fatal(http.ListenAndServe(":"+cfg.Port, handlers.CORSMiddleware(cfg.CORS, mux)))
+}
+
+// runBootstrapAdmin bikin akun owner pertama dari command line.
+func runBootstrapAdmin(db *sql.DB, cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("bootstrap-admin", flag.ExitOnError)
+	email := fs.String("email", cfg.Admin.Email, "email owner")
+	name := fs.String("name", cfg.Admin.Name, "nama lengkap owner")
+	fs.Parse(args)
+
+	if _, err := migrations.Up(db); err != nil {
+		log.Fatal("Gagal migrate database: ", err)
+	}
+
+	// Password sengaja cuma dari env/file config biar gak nyangkut di history shell
+	if err := handlers.BootstrapOwner(db, *name, *email, cfg.Admin.Password); err != nil {
+		log.Fatal("Gagal bikin akun owner: ", err)
 	}
-	fmt.Println(" Server GAYA BEAUTY jalan di Port:", port)
-	http.ListenAndServe(":"+port, nil)
-}
\ No newline at end of file
+	fmt.Println("Akun owner berhasil dibuat:", *email)
+}
@@ -3,10 +3,13 @@ module gaya-beauty-backend
 go 1.24.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/chai2010/webp v1.4.0
 	github.com/cloudinary/cloudinary-go/v2 v2.14.1
 	github.com/go-sql-driver/mysql v1.9.3
 	github.com/golang-jwt/jwt/v5 v5.3.1
 	golang.org/x/crypto v0.48.0
+	golang.org/x/image v0.36.0
 )
 
 require (
@@ -0,0 +1,298 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("produk/varian tidak ditemukan")
+
+// LowStockChecker bandingin stok tiap unit (produk tanpa varian / satu varian)
+// sama batas restock-nya, lalu bikin notifikasi kalau stoknya <= batas.
+// Notifikasi yang stoknya sudah aman lagi otomatis ditandai selesai.
+type LowStockChecker struct {
+	db           *sql.DB
+	defaultLevel int // Dipakai kalau produk & varian gak punya reorder_level sendiri
+	notifiers    []Notifier
+
+	mu    sync.Mutex // Cek gak boleh jalan barengan biar gak saling timpa
+	queue chan []int
+}
+
+func NewLowStockChecker(db *sql.DB, defaultLevel int, notifiers ...Notifier) *LowStockChecker {
+	return &LowStockChecker{
+		db:           db,
+		defaultLevel: defaultLevel,
+		notifiers:    notifiers,
+		queue:        make(chan []int, 100),
+	}
+}
+
+// Trigger minta stok produk-produk ini dicek di background (habis checkout,
+// edit produk, koreksi stok). Gak pernah bikin handler nunggu: kalau antrian
+// penuh dilewati aja, nanti tetap kecek sama jadwal rutin.
+func (c *LowStockChecker) Trigger(productIDs ...int) {
+	if c == nil || len(productIDs) == 0 {
+		return
+	}
+	select {
+	case c.queue <- productIDs:
+	default:
+		log.Println("Antrian cek stok menipis penuh, nunggu jadwal berikutnya")
+	}
+}
+
+// Start cek semua stok sekali, lalu tiap `interval` dan tiap ada Trigger,
+// sampai ctx selesai.
+func (c *LowStockChecker) Start(ctx context.Context, interval time.Duration) {
+	c.run(nil)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ids := <-c.queue:
+			c.run(ids)
+		case <-ticker.C:
+			c.run(nil)
+		}
+	}
+}
+
+func (c *LowStockChecker) run(productIDs []int) {
+	raised, err := c.Check(productIDs)
+	if err != nil {
+		log.Println("Cek stok menipis gagal:", err)
+		return
+	}
+	for _, n := range raised {
+		for _, nf := range c.notifiers {
+			if err := nf.Notify(n); err != nil {
+				log.Printf("Gagal kirim notifikasi %d lewat %s: %v", n.ID, nf.Name(), err)
+			}
+		}
+	}
+}
+
+type stockUnit struct {
+	ProductID int
+	VariantID int
+	Name      string
+	SKU       string
+	Stock     int
+	Level     int
+}
+
+type unitKey struct{ ProductID, VariantID int }
+
+type openAlert struct {
+	ID        int
+	Stock     int
+	Threshold int
+}
+
+func openKey(k unitKey) string {
+	return fmt.Sprintf("%s:%d:%d", KindLowStock, k.ProductID, k.VariantID)
+}
+
+// scope bikin "AND col IN (...)" buat productIDs (kosong = semua produk).
+func scope(col string, productIDs []int) (string, []interface{}) {
+	if len(productIDs) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	return " AND " + col + " IN (" + strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",") + ")", args
+}
+
+// Check jalanin pengecekan buat productIDs (kosong = semua produk) dan balikin
+// notifikasi yang BARU dibuat. Cuma produk active yang dicek; produk draft/arsip
+// gak dijual, jadi notifikasinya ikut ditutup.
+func (c *LowStockChecker) Check(productIDs []int) ([]Notification, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	units, err := c.loadUnits(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	open, err := c.loadOpen(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var raised []Notification
+	for _, u := range units {
+		if u.Stock > u.Level {
+			continue
+		}
+		key := unitKey{u.ProductID, u.VariantID}
+		if a, ok := open[key]; ok {
+			delete(open, key)
+			// Masih menipis: angkanya aja yang diperbarui, gak dikirim ulang
+			if a.Stock != u.Stock || a.Threshold != u.Level {
+				if _, err := c.db.Exec("UPDATE admin_notifications SET stock = ?, threshold = ?, message = ? WHERE id = ?",
+					u.Stock, u.Level, lowStockMessage(u), a.ID); err != nil {
+					return raised, err
+				}
+			}
+			continue
+		}
+
+		n, created, err := c.raise(u)
+		if err != nil {
+			return raised, err
+		}
+		if created {
+			raised = append(raised, n)
+		}
+	}
+
+	// Sisanya = notifikasi yang stoknya sudah aman / produknya gak dijual lagi
+	for _, a := range open {
+		if _, err := c.db.Exec("UPDATE admin_notifications SET resolved_at = NOW(), open_key = NULL WHERE id = ?", a.ID); err != nil {
+			return raised, err
+		}
+	}
+	return raised, nil
+}
+
+func (c *LowStockChecker) loadUnits(productIDs []int) ([]stockUnit, error) {
+	filter, ids := scope("p.id", productIDs)
+	args := []interface{}{c.defaultLevel}
+	args = append(args, ids...)
+	args = append(args, c.defaultLevel)
+	args = append(args, ids...)
+
+	rows, err := c.db.Query(`
+		SELECT p.id, 0, p.name, '', p.stock, COALESCE(p.reorder_level, ?)
+		FROM products p
+		WHERE p.status = 'active'
+			AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)`+filter+`
+		UNION ALL
+		SELECT p.id, v.id, p.name, v.sku, v.stock, COALESCE(v.reorder_level, p.reorder_level, ?)
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE p.status = 'active'`+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []stockUnit
+	for rows.Next() {
+		var u stockUnit
+		if err := rows.Scan(&u.ProductID, &u.VariantID, &u.Name, &u.SKU, &u.Stock, &u.Level); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (c *LowStockChecker) loadOpen(productIDs []int) (map[unitKey]openAlert, error) {
+	filter, args := scope("product_id", productIDs)
+	rows, err := c.db.Query(`SELECT id, product_id, variant_id, COALESCE(stock, 0), COALESCE(threshold, 0)
+		FROM admin_notifications WHERE kind = '`+string(KindLowStock)+`' AND open_key IS NOT NULL`+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	open := map[unitKey]openAlert{}
+	for rows.Next() {
+		var k unitKey
+		var a openAlert
+		if err := rows.Scan(&a.ID, &k.ProductID, &k.VariantID, &a.Stock, &a.Threshold); err != nil {
+			return nil, err
+		}
+		open[k] = a
+	}
+	return open, rows.Err()
+}
+
+// raise simpan notifikasi baru. created = false kalau checker lain (server
+// lain) sudah duluan bikin notifikasi buat unit yang sama.
+func (c *LowStockChecker) raise(u stockUnit) (Notification, bool, error) {
+	n := Notification{
+		Kind:      KindLowStock,
+		ProductID: u.ProductID,
+		VariantID: u.VariantID,
+		Title:     lowStockTitle(u),
+		Message:   lowStockMessage(u),
+		Stock:     &u.Stock,
+		Threshold: &u.Level,
+		CreatedAt: time.Now(),
+	}
+	res, err := c.db.Exec(`INSERT INTO admin_notifications (kind, product_id, variant_id, title, message, stock, threshold, open_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = id`,
+		n.Kind, n.ProductID, n.VariantID, n.Title, n.Message, u.Stock, u.Level, openKey(unitKey{u.ProductID, u.VariantID}))
+	if err != nil {
+		return n, false, err
+	}
+	if affected, _ := res.RowsAffected(); affected != 1 {
+		return n, false, nil
+	}
+	id, _ := res.LastInsertId()
+	n.ID = int(id)
+	return n, true, nil
+}
+
+func lowStockTitle(u stockUnit) string {
+	name := u.Name
+	if u.SKU != "" {
+		name += " (" + u.SKU + ")"
+	}
+	if u.Stock <= 0 {
+		return "Stok habis: " + name
+	}
+	return "Stok menipis: " + name
+}
+
+func lowStockMessage(u stockUnit) string {
+	return fmt.Sprintf("Sisa stok %d, batas restock %d. Segera restock biar gak kehabisan.", u.Stock, u.Level)
+}
+
+// SetReorderLevel ubah batas restock satu unit. level nil = balik ke default
+// (varian ikut produk, produk ikut config). Produk bervarian yang dikirim tanpa
+// variant_id = batas level produk, dipakai semua varian yang belum punya batas sendiri.
+func SetReorderLevel(db *sql.DB, productID, variantID int, level *int) error {
+	var res sql.Result
+	var err error
+	if variantID != 0 {
+		res, err = db.Exec("UPDATE product_variants SET reorder_level = ? WHERE id = ? AND product_id = ?", level, variantID, productID)
+	} else {
+		res, err = db.Exec("UPDATE products SET reorder_level = ? WHERE id = ?", level, productID)
+	}
+	if err != nil {
+		return err
+	}
+	// RowsAffected 0 juga kalau nilainya sama, jadi dicek ulang barisnya ada atau gak
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists bool
+		if variantID != 0 {
+			err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = ? AND product_id = ?)", variantID, productID).Scan(&exists)
+		} else {
+			err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = ?)", productID).Scan(&exists)
+		}
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
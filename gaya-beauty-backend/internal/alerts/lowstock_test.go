@@ -0,0 +1,140 @@
+package alerts
+
+import (
+	"database/sql"
+	"gaya-beauty-backend/internal/testdb"
+	"testing"
+)
+
+type alertRow struct {
+	ID       int
+	Stock    int
+	Open     bool // open_key masih diisi
+	Resolved bool
+}
+
+func lowStockAlerts(t *testing.T, db *sql.DB, productID, variantID int) []alertRow {
+	t.Helper()
+	rows, err := db.Query(`SELECT id, stock, open_key IS NOT NULL, resolved_at IS NOT NULL FROM admin_notifications
+		WHERE kind = ? AND product_id = ? AND variant_id = ? ORDER BY id`, KindLowStock, productID, variantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var list []alertRow
+	for rows.Next() {
+		var a alertRow
+		if err := rows.Scan(&a.ID, &a.Stock, &a.Open, &a.Resolved); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, a)
+	}
+	return list
+}
+
+func check(t *testing.T, c *LowStockChecker, productIDs ...int) []Notification {
+	t.Helper()
+	raised, err := c.Check(productIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raised
+}
+
+func TestLowStockRaiseDedupeResolve(t *testing.T) {
+	db := testdb.Open(t)
+	productID := testdb.Product(t, db, "Cushion", 180000, 10)
+	adminID := testdb.Exec(t, db, "INSERT INTO users (full_name, email, password, role) VALUES ('Admin', 'admin@test.local', 'x', 'admin')")
+	c := NewLowStockChecker(db, 5)
+	setStock := func(stock int) {
+		testdb.Exec(t, db, "UPDATE products SET stock = ? WHERE id = ?", stock, productID)
+	}
+
+	if raised := check(t, c); len(raised) != 0 {
+		t.Fatalf("stok 10 > batas 5 tapi ada notifikasi: %+v", raised)
+	}
+
+	// Pas di batas = sudah menipis
+	setStock(5)
+	raised := check(t, c, productID)
+	if len(raised) != 1 || raised[0].ProductID != productID || *raised[0].Stock != 5 || *raised[0].Threshold != 5 {
+		t.Fatalf("stok 5 = %+v, mau satu notifikasi stok 5 batas 5", raised)
+	}
+
+	// Masih menipis: gak bikin notifikasi baru, angkanya aja yang ikut turun
+	setStock(3)
+	if raised := check(t, c); len(raised) != 0 {
+		t.Errorf("notifikasi dobel selagi masih terbuka: %+v", raised)
+	}
+	alerts := lowStockAlerts(t, db, productID, 0)
+	if len(alerts) != 1 || alerts[0].Stock != 3 || !alerts[0].Open {
+		t.Fatalf("notifikasi = %+v, mau satu yang terbuka dengan stok 3", alerts)
+	}
+
+	// Sudah dibaca admin tapi stok masih menipis: tetap gak dobel
+	if n, err := Acknowledge(db, []int{alerts[0].ID}, adminID); err != nil || n != 1 {
+		t.Fatalf("acknowledge = %d, %v", n, err)
+	}
+	if raised := check(t, c, productID); len(raised) != 0 {
+		t.Errorf("notifikasi dobel setelah dibaca: %+v", raised)
+	}
+
+	// Restock di atas batas: notifikasi otomatis selesai
+	setStock(8)
+	check(t, c, productID)
+	alerts = lowStockAlerts(t, db, productID, 0)
+	if len(alerts) != 1 || alerts[0].Open || !alerts[0].Resolved {
+		t.Fatalf("setelah restock = %+v, mau selesai", alerts)
+	}
+
+	// Menipis lagi = kejadian baru, notifikasi baru
+	setStock(1)
+	if raised := check(t, c); len(raised) != 1 {
+		t.Errorf("stok turun lagi = %d notifikasi, mau 1", len(raised))
+	}
+	if alerts := lowStockAlerts(t, db, productID, 0); len(alerts) != 2 {
+		t.Errorf("jumlah notifikasi = %d, mau 2", len(alerts))
+	}
+}
+
+func TestLowStockVariantThreshold(t *testing.T) {
+	db := testdb.Open(t)
+	productID := testdb.Exec(t, db, "INSERT INTO products (name, price, stock, status, reorder_level) VALUES ('Foundation', 150000, 12, 'active', 3)")
+	ownLevel := testdb.Exec(t, db, `INSERT INTO product_variants (product_id, sku, options, stock, reorder_level)
+		VALUES (?, 'FDN-01', '{"shade":"01"}', 8, 10)`, productID)
+	productLevel := testdb.Exec(t, db, `INSERT INTO product_variants (product_id, sku, options, stock)
+		VALUES (?, 'FDN-02', '{"shade":"02"}', 4)`, productID)
+	c := NewLowStockChecker(db, 5)
+
+	// FDN-01: batas varian 10 menang dari batas produk 3 & default 5.
+	// FDN-02: gak punya batas sendiri, ikut produk (3), jadi stok 4 masih aman.
+	raised := check(t, c)
+	if len(raised) != 1 || raised[0].VariantID != ownLevel || *raised[0].Threshold != 10 {
+		t.Fatalf("notifikasi = %+v, mau satu buat varian %d dengan batas 10", raised, ownLevel)
+	}
+	if alerts := lowStockAlerts(t, db, productID, productLevel); len(alerts) != 0 {
+		t.Errorf("FDN-02 (stok 4, batas produk 3) dapat notifikasi: %+v", alerts)
+	}
+	// Produk bervarian gak dicek sebagai unit sendiri
+	if alerts := lowStockAlerts(t, db, productID, 0); len(alerts) != 0 {
+		t.Errorf("produk bervarian dapat notifikasi level produk: %+v", alerts)
+	}
+
+	// Batas varian dihapus: balik ikut produk (3), stok 8 aman, notifikasi selesai
+	if err := SetReorderLevel(db, productID, ownLevel, nil); err != nil {
+		t.Fatal(err)
+	}
+	check(t, c, productID)
+	if alerts := lowStockAlerts(t, db, productID, ownLevel); len(alerts) != 1 || !alerts[0].Resolved {
+		t.Errorf("setelah batas varian dihapus = %+v, mau selesai", alerts)
+	}
+
+	// Batas produk dihapus juga: FDN-02 ikut default config (5), stok 4 menipis
+	if err := SetReorderLevel(db, productID, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	raised = check(t, c, productID)
+	if len(raised) != 1 || raised[0].VariantID != productLevel || *raised[0].Threshold != 5 {
+		t.Errorf("setelah batas produk dihapus = %+v, mau FDN-02 dengan batas 5", raised)
+	}
+}
@@ -0,0 +1,144 @@
+package alerts
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Jenis notifikasi admin
+type Kind string
+
+const (
+	KindLowStock Kind = "low_stock"
+)
+
+// Notification = satu baris admin_notifications.
+// Selesai (resolved) diisi sistem waktu masalahnya hilang sendiri, misal stok sudah direstock.
+type Notification struct {
+	ID             int        `json:"id"`
+	Kind           Kind       `json:"kind"`
+	ProductID      int        `json:"product_id,omitempty"`
+	VariantID      int        `json:"variant_id,omitempty"`
+	Title          string     `json:"title"`
+	Message        string     `json:"message"`
+	Stock          *int       `json:"stock,omitempty"`
+	Threshold      *int       `json:"threshold,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy int        `json:"acknowledged_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Status buat filter daftar notifikasi
+type Status string
+
+const (
+	StatusOpen         Status = "open"         // Belum dibaca admin, masalahnya masih ada
+	StatusAcknowledged Status = "acknowledged" // Sudah dibaca, tapi masalahnya masih ada
+	StatusResolved     Status = "resolved"     // Sudah beres sendiri
+	StatusAll          Status = "all"
+)
+
+func ValidStatus(s Status) bool {
+	switch s {
+	case StatusOpen, StatusAcknowledged, StatusResolved, StatusAll:
+		return true
+	}
+	return false
+}
+
+// Filter daftar notifikasi (nilai kosong = gak difilter)
+type Filter struct {
+	Status   Status
+	Kind     Kind
+	BeforeID int // Halaman berikutnya: ID terakhir halaman sebelumnya
+	Limit    int
+}
+
+// List ambil notifikasi terbaru dulu.
+func List(db *sql.DB, f Filter) ([]Notification, error) {
+	conds := []string{"1=1"}
+	var args []interface{}
+	switch f.Status {
+	case StatusOpen:
+		conds = append(conds, "acknowledged_at IS NULL AND resolved_at IS NULL")
+	case StatusAcknowledged:
+		conds = append(conds, "acknowledged_at IS NOT NULL AND resolved_at IS NULL")
+	case StatusResolved:
+		conds = append(conds, "resolved_at IS NOT NULL")
+	}
+	if f.Kind != "" {
+		conds = append(conds, "kind = ?")
+		args = append(args, f.Kind)
+	}
+	if f.BeforeID != 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, f.BeforeID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Query(`SELECT id, kind, product_id, variant_id, title, message, stock, threshold,
+			acknowledged_at, acknowledged_by, resolved_at, created_at
+		FROM admin_notifications WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Notification{}
+	for rows.Next() {
+		var n Notification
+		var productID, stock, threshold, ackBy sql.NullInt64
+		var ackAt, resolvedAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Kind, &productID, &n.VariantID, &n.Title, &n.Message, &stock, &threshold,
+			&ackAt, &ackBy, &resolvedAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		n.ProductID = int(productID.Int64)
+		n.AcknowledgedBy = int(ackBy.Int64)
+		if stock.Valid {
+			v := int(stock.Int64)
+			n.Stock = &v
+		}
+		if threshold.Valid {
+			v := int(threshold.Int64)
+			n.Threshold = &v
+		}
+		if ackAt.Valid {
+			n.AcknowledgedAt = &ackAt.Time
+		}
+		if resolvedAt.Valid {
+			n.ResolvedAt = &resolvedAt.Time
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
+
+// CountOpen = jumlah notifikasi yang belum dibaca (buat badge di dashboard).
+func CountOpen(db *sql.DB) (int, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM admin_notifications WHERE acknowledged_at IS NULL AND resolved_at IS NULL").Scan(&n)
+	return n, err
+}
+
+// Acknowledge tandai notifikasi sudah dibaca userID. Yang sudah dibaca
+// sebelumnya gak ditimpa. Balikin jumlah yang berubah.
+func Acknowledge(db *sql.DB, ids []int, userID int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	res, err := db.Exec(`UPDATE admin_notifications SET acknowledged_at = NOW(), acknowledged_by = ?
+		WHERE acknowledged_at IS NULL AND id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
@@ -0,0 +1,85 @@
+package alerts
+
+import (
+	"fmt"
+	"gaya-beauty-backend/internal/config"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// Notifier = saluran keluar buat notifikasi baru (email, WhatsApp, dst.).
+// Notifikasinya sendiri tetap tersimpan di admin_notifications walaupun kirimnya gagal.
+type Notifier interface {
+	Name() string
+	Notify(n Notification) error
+}
+
+// NotifiersFromConfig nyusun saluran yang diisi di [alerts] config.
+// Gak ada yang diisi = notifikasi cuma kelihatan di dashboard admin.
+func NotifiersFromConfig(cfg config.AlertsConfig) []Notifier {
+	var list []Notifier
+	if cfg.Email.SMTPHost != "" && len(cfg.Email.To) > 0 {
+		list = append(list, NewEmail(cfg.Email))
+	}
+	if len(cfg.WhatsApp.To) > 0 {
+		list = append(list, WhatsAppLog{To: cfg.WhatsApp.To})
+	}
+	return list
+}
+
+// =========================================================
+// 1. EMAIL (SMTP)
+// =========================================================
+type Email struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func NewEmail(cfg config.EmailConfig) *Email {
+	e := &Email{
+		addr: net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		from: cfg.From,
+		to:   cfg.To,
+	}
+	// SMTP lokal (misal Mailpit buat dev) biasanya gak pakai login
+	if cfg.Username != "" {
+		e.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+	}
+	return e
+}
+
+func (e *Email) Name() string { return "email" }
+
+func (e *Email) Notify(n Notification) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[Gaya Beauty] "+n.Title))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(n.Message + "\r\n")
+	return smtp.SendMail(e.addr, e.auth, e.from, e.to, []byte(msg.String()))
+}
+
+// =========================================================
+// 2. WHATSAPP (SEMENTARA)
+// =========================================================
+// Belum ada provider WA, jadi pesannya cuma ditulis ke log server.
+// Nanti tinggal ganti sama implementasi Notifier yang manggil API provider.
+type WhatsAppLog struct {
+	To []string
+}
+
+func (WhatsAppLog) Name() string { return "whatsapp" }
+
+func (w WhatsAppLog) Notify(n Notification) error {
+	for _, to := range w.To {
+		log.Printf("[WhatsApp ke %s] %s - %s", to, n.Title, n.Message)
+	}
+	return nil
+}
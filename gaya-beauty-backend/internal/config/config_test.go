@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadForcesParseTime(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_DSN", "gaya:rahasia@tcp(db:3306)/gaya_beauty_db?charset=utf8mb4")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cfg.Database.DSN, "parseTime=true") || !strings.Contains(cfg.Database.DSN, "charset=utf8mb4") {
+		t.Errorf("DSN = %q, mau parseTime=true ditambahin tanpa buang opsi lain", cfg.Database.DSN)
+	}
+}
+
+func TestLoadKeepsDefaultDSN(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Harus tetap persis sama, cek "DSN masih default" di production bandingin string
+	if cfg.Database.DSN != DefaultDSN {
+		t.Errorf("DSN default berubah jadi %q", cfg.Database.DSN)
+	}
+}
+
+func TestValidateDSN(t *testing.T) {
+	tests := []struct {
+		dsn     string
+		wantErr string
+	}{
+		{dsn: DefaultDSN},
+		{dsn: "root@tcp(127.0.0.1:3306)/gaya?parseTime=true&loc=Local"},
+		{dsn: "root@tcp(127.0.0.1:3306)/gaya", wantErr: "parseTime=true"},
+		{dsn: "root@tcp(127.0.0.1:3306)/gaya?parseTime=false", wantErr: "parseTime=true"},
+		{dsn: "bukan dsn", wantErr: "dsn tidak valid"},
+		{dsn: "", wantErr: "dsn wajib diisi"},
+	}
+	for _, tt := range tests {
+		cfg := defaults()
+		cfg.Database.DSN = tt.dsn
+		err := cfg.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("Validate(%q) error: %v", tt.dsn, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("Validate(%q) = %v, mau error %q", tt.dsn, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePrivateDir(t *testing.T) {
+	tests := []struct {
+		local, private string
+		ok             bool
+	}{
+		{"./uploads", "./private-uploads", true},
+		{"./uploads", "./uploads/receipts", false},
+		{"./uploads", "uploads", false},
+		{"./uploads", "", false},
+	}
+	for _, tt := range tests {
+		cfg := defaults()
+		cfg.Storage.LocalDir, cfg.Storage.PrivateDir = tt.local, tt.private
+		if err := cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("local_dir %q private_dir %q: error %v, mau ok=%v", tt.local, tt.private, err, tt.ok)
+		}
+	}
+}
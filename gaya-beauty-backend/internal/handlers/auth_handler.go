@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"gaya-beauty-backend/internal/config"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -12,103 +15,126 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte("rahasia_gaya_beauty_2026")
+// Isi token JWT. UserID = id di tabel users (admin) atau customers (pembeli),
+// Role buat bedain keduanya.
+type Claims struct {
+	UserID int    `json:"uid"`
+	Role   string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// issueToken bikin token JWT, masa berlaku & kunci diambil dari config.
+func issueToken(cfg config.AuthConfig, userID int, subject, role string) (string, error) {
+	claims := &Claims{
+		UserID: userID,
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.TokenTTL)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.JWTSecret))
+}
+
+// parseToken ambil & verifikasi token dari header "Authorization: Bearer <token>".
+func parseToken(cfg config.AuthConfig, r *http.Request) (*Claims, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, errors.New("token tidak ditemukan")
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, errors.New("token tidak valid")
+	}
+	return claims, nil
+}
 
 type AuthRequest struct {
-	FullName string `json:"full_name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
-// 1. HANDLER REGISTER
-func HandleRegister(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// --- IZIN CORS ---
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == "OPTIONS" { return }
+// 1. BOOTSTRAP ADMIN PERTAMA
+// Gak ada lagi pendaftaran admin lewat endpoint publik. Akun pertama (role owner)
+// dibuat dari CLI `bootstrap-admin` atau dari env ADMIN_EMAIL/ADMIN_PASSWORD saat server start,
+// staff berikutnya lewat undangan (lihat invite_handler.go).
+var ErrAlreadyBootstrapped = errors.New("sudah ada akun admin, pakai fitur undangan")
 
-		var req AuthRequest
-		json.NewDecoder(r.Body).Decode(&req)
+// BootstrapOwner bikin akun owner kalau tabel users masih kosong.
+func BootstrapOwner(db *sql.DB, fullName, email, password string) error {
+	if email == "" || len(password) < 8 {
+		return errors.New("email wajib diisi dan password minimal 8 karakter")
+	}
 
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			http.Error(w, "Gagal proses password", http.StatusInternalServerError)
-			return
-		}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAlreadyBootstrapped
+	}
 
-		query := "INSERT INTO users (full_name, email, password, role) VALUES (?, ?, ?, 'admin')"
-		_, err = db.Exec(query, req.FullName, req.Email, string(hashedPassword))
-		
-		if err != nil {
-			fmt.Println(" Gagal daftar:", err)
-			http.Error(w, "Email sudah terdaftar!", http.StatusBadRequest)
-			return
-		}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
 
-		w.Write([]byte(`{"message": "Daftar berhasil, silakan login!"}`))
+	if fullName == "" {
+		fullName = "Owner"
 	}
+	_, err = db.Exec("INSERT INTO users (full_name, email, password, role) VALUES (?, ?, ?, ?)",
+		fullName, email, string(hashedPassword), string(RoleOwner))
+	return err
 }
 
 // 2. HANDLER LOGIN
-func HandleLogin(db *sql.DB) http.HandlerFunc {
+func HandleLogin(db *sql.DB, authCfg config.AuthConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// --- IZIN CORS (DIPERKUAT) ---
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		// Handle Preflight Request dari Browser
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		fmt.Println(" Ada request login masuk...")
-
 		var req AuthRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			fmt.Println(" Error Decode JSON:", err)
 			http.Error(w, "Data tidak valid", http.StatusBadRequest)
 			return
 		}
 
-		fmt.Println(" Email dicari:", req.Email)
-
+		var id int
 		var dbPassword, role string
 		// Ambil data dari DB
-		err = db.QueryRow("SELECT password, role FROM users WHERE email = ?", req.Email).Scan(&dbPassword, &role)
-		
-		if err != nil {
-			if err == sql.ErrNoRows {
-				fmt.Println(" Email TIDAK ADA di database!")
-			} else {
-				fmt.Println(" Error Database:", err)
-			}
+		err = db.QueryRow("SELECT id, password, role FROM users WHERE email = ?", req.Email).Scan(&id, &dbPassword, &role)
+		if err == sql.ErrNoRows {
 			http.Error(w, "Email atau Password salah!", http.StatusUnauthorized)
 			return
+		} else if err != nil {
+			log.Printf("Login admin gagal, error database: %v", err)
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
 		}
 
 		// Cek Password
 		err = bcrypt.CompareHashAndPassword([]byte(dbPassword), []byte(req.Password))
 		if err != nil {
-			fmt.Println(" Password SALAH Bos!")
 			http.Error(w, "Email atau Password salah!", http.StatusUnauthorized)
 			return
 		}
 
-		// Bikin Token JWT
-		expirationTime := time.Now().Add(24 * time.Hour)
-		claims := &jwt.RegisteredClaims{
-			Subject:   req.Email,
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+		// Jaga-jaga: akun di tabel users gak boleh pakai role customer
+		if Role(role) == RoleCustomer {
+			http.Error(w, "Email atau Password salah!", http.StatusUnauthorized)
+			return
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, _ := token.SignedString(jwtKey)
 
-		fmt.Println(" Login Berhasil buat:", req.Email)
+		// Bikin Token JWT (role di claim cuma penanda admin vs customer, aksesnya dicek dari DB)
+		tokenString, err := issueToken(authCfg, id, req.Email, role)
+		if err != nil {
+			http.Error(w, "Gagal bikin token", http.StatusInternalServerError)
+			return
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{
@@ -118,35 +144,53 @@ func HandleLogin(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-// 3. MIDDLEWARE
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// 3. MIDDLEWARE (semua role admin/staff, tanpa cek permission)
+// Role di token gak dipercaya: tiap request dibaca ulang dari users.role, jadi
+// role yang diturunin atau akun yang dihapus langsung berlaku tanpa nunggu token expired.
+func AuthMiddleware(db *sql.DB, authCfg config.AuthConfig, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		claims, err := parseToken(authCfg, r)
+		if err != nil {
+			http.Error(w, "Token tidak valid!", http.StatusUnauthorized)
 			return
 		}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Token tidak ditemukan!", http.StatusUnauthorized)
+		// Token customer ditandatangani pakai kunci yang sama, jadi wajib ditolak di rute admin.
+		// Token lama (sebelum ada claim role) juga ditolak, suruh login ulang.
+		role := Role(claims.Role)
+		if role == "" || role == RoleCustomer || claims.UserID == 0 {
+			http.Error(w, "Akses khusus admin!", http.StatusForbidden)
 			return
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
-
-		if err != nil || !token.Valid {
-			http.Error(w, "Token tidak valid!", http.StatusUnauthorized)
+		user, ok := loadAuthUser(w, db, claims.UserID)
+		if !ok {
 			return
 		}
-
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// loadAuthUser ambil email, role & permission terbaru akun admin/staff. Kalau gagal, response
+// error sudah ditulis dan hasilnya false.
+func loadAuthUser(w http.ResponseWriter, db *sql.DB, userID int) (AuthUser, bool) {
+	user := AuthUser{ID: userID}
+	err := db.QueryRow("SELECT email, role FROM users WHERE id = ?", userID).Scan(&user.Email, &user.Role)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Akun tidak ditemukan, silakan login ulang", http.StatusUnauthorized)
+		return user, false
+	} else if err != nil {
+		http.Error(w, "Server Error", http.StatusInternalServerError)
+		return user, false
+	}
+	if user.Role == RoleCustomer {
+		http.Error(w, "Akses khusus admin!", http.StatusForbidden)
+		return user, false
+	}
+	if user.Permissions, err = rolePermissions(db, user.Role); err != nil {
+		http.Error(w, "Server Error", http.StatusInternalServerError)
+		return user, false
+	}
+	return user, true
+}
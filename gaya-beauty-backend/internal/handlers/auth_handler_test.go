@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"gaya-beauty-backend/internal/config"
+	"gaya-beauty-backend/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequirePermissionReloadsRole(t *testing.T) {
+	db := testdb.Open(t)
+	authCfg := config.AuthConfig{JWTSecret: "rahasia-test", TokenTTL: time.Hour}
+
+	userID := testdb.Exec(t, db, "INSERT INTO users (full_name, email, password, role) VALUES ('Staff', 'staff@test.local', 'x', ?)", RoleStaff)
+	token, err := issueToken(authCfg, userID, "staff@test.local", string(RoleStaff))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen AuthUser
+	h := RequirePermission(db, authCfg, PermProductsWrite, func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = UserFromContext(r.Context())
+	})
+	call := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/products/create", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		return rec.Code
+	}
+
+	if code := call(); code != http.StatusOK || seen.Role != RoleStaff {
+		t.Fatalf("staff: status %d role %q, mau 200 staff", code, seen.Role)
+	}
+
+	// Diturunin jadi packer: token lama (claim staff) langsung gak bisa edit produk
+	testdb.Exec(t, db, "UPDATE users SET role = ? WHERE id = ?", RolePacker, userID)
+	if code := call(); code != http.StatusForbidden {
+		t.Errorf("setelah jadi packer: status %d, mau 403", code)
+	}
+
+	testdb.Exec(t, db, "DELETE FROM users WHERE id = ?", userID)
+	if code := call(); code != http.StatusUnauthorized {
+		t.Errorf("akun dihapus: status %d, mau 401", code)
+	}
+}
+
+// Akses dibaca dari tabel role_permissions tiap request: dicabut = langsung 403
+func TestRequirePermissionFromTable(t *testing.T) {
+	db := testdb.Open(t)
+	authCfg := config.AuthConfig{JWTSecret: "rahasia-test", TokenTTL: time.Hour}
+
+	userID := testdb.Exec(t, db, "INSERT INTO users (full_name, email, password, role) VALUES ('Packer', 'packer@test.local', 'x', ?)", RolePacker)
+	token, err := issueToken(authCfg, userID, "packer@test.local", string(RolePacker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(perm Permission) int {
+		h := RequirePermission(db, authCfg, perm, func(w http.ResponseWriter, r *http.Request) {})
+		r := httptest.NewRequest(http.MethodPost, "/orders/update", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		return rec.Code
+	}
+
+	// Isi awal migration: packer boleh update order, gak boleh hapus produk
+	if code := call(PermOrdersUpdate); code != http.StatusOK {
+		t.Fatalf("packer update order: status %d, mau 200", code)
+	}
+	if code := call(PermProductsDelete); code != http.StatusForbidden {
+		t.Errorf("packer hapus produk: status %d, mau 403", code)
+	}
+
+	testdb.Exec(t, db, "DELETE FROM role_permissions WHERE role = ? AND permission = ?", RolePacker, PermOrdersUpdate)
+	if code := call(PermOrdersUpdate); code != http.StatusForbidden {
+		t.Errorf("setelah dicabut: status %d, mau 403", code)
+	}
+	testdb.Exec(t, db, "INSERT INTO role_permissions (role, permission) VALUES (?, ?)", RolePacker, PermProductsDelete)
+	if code := call(PermProductsDelete); code != http.StatusOK {
+		t.Errorf("setelah dikasih akses hapus produk: status %d, mau 200", code)
+	}
+}
+
+func TestCanInvite(t *testing.T) {
+	owner := AuthUser{Role: RoleOwner, Permissions: []Permission{PermUsersInvite}}
+	admin := AuthUser{Role: RoleAdmin, Permissions: []Permission{PermUsersInvite}}
+	staff := AuthUser{Role: RoleStaff, Permissions: []Permission{PermOrdersRead, PermProductsWrite}}
+
+	cases := []struct {
+		inviter AuthUser
+		target  Role
+		want    bool
+	}{
+		{owner, RoleOwner, true},
+		{owner, RolePacker, true},
+		{admin, RoleOwner, false},
+		{admin, RoleStaff, true},
+		{staff, RolePacker, false},
+		{owner, RoleCustomer, false},
+		{owner, "superadmin", false},
+	}
+	for _, c := range cases {
+		if got := canInvite(c.inviter, c.target); got != c.want {
+			t.Errorf("%s ngundang %s = %v, mau %v", c.inviter.Role, c.target, got, c.want)
+		}
+	}
+}
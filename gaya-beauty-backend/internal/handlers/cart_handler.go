@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// === STRUKTUR DATA KERANJANG ===
+type CartItemRequest struct {
+	ProductID int `json:"product_id"`
+	VariantID int `json:"variant_id"` // Wajib kalau produknya punya varian
+	Quantity  int `json:"quantity"`
+}
+
+type CartItemResp struct {
+	ProductID int     `json:"product_id"`
+	VariantID int     `json:"variant_id"`
+	SKU       string  `json:"sku,omitempty"`
+	Variant   string  `json:"variant,omitempty"` // Contoh: "02 Ivory / 30ml"
+	Name      string  `json:"name"`
+	ImageURL  string  `json:"image_url"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	Stock     int     `json:"stock"`
+	Subtotal  float64 `json:"subtotal"`
+	// false kalau stok sekarang udah gak cukup buat qty di keranjang
+	Available bool `json:"available"`
+}
+
+type CartResponse struct {
+	Items      []CartItemResp `json:"items"`
+	TotalPrice float64        `json:"total_price"`
+}
+
+// =========================================================
+// KERANJANG CUSTOMER: GET / POST / PATCH / DELETE /cart
+// =========================================================
+// Keranjang disimpan di tabel carts, jadi aman walau browser di-reload
+// dan bisa dibuka dari device lain.
+func HandleCart(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			writeCart(w, db, customer.ID)
+
+		case "POST", "PATCH":
+			var req CartItemRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+				return
+			}
+			if req.ProductID <= 0 || req.VariantID < 0 || req.Quantity < 0 || (r.Method == "POST" && req.Quantity == 0) {
+				http.Error(w, "Produk atau jumlah tidak valid", http.StatusBadRequest)
+				return
+			}
+
+			// POST = tambah qty, PATCH = set qty (0 = hapus dari keranjang)
+			status, msg := upsertCartItem(db, customer.ID, req, r.Method == "POST")
+			if status != http.StatusOK {
+				http.Error(w, msg, status)
+				return
+			}
+			writeCart(w, db, customer.ID)
+
+		case "DELETE":
+			// ?product_id=X[&variant_id=Y] hapus satu item, tanpa parameter = kosongin keranjang
+			if idStr := r.URL.Query().Get("product_id"); idStr != "" {
+				productID, err := strconv.Atoi(idStr)
+				if err != nil {
+					http.Error(w, "ID Produk tidak valid", http.StatusBadRequest)
+					return
+				}
+				variantID := 0
+				if v := r.URL.Query().Get("variant_id"); v != "" {
+					if variantID, err = strconv.Atoi(v); err != nil {
+						http.Error(w, "ID Varian tidak valid", http.StatusBadRequest)
+						return
+					}
+				}
+				_, err = db.Exec("DELETE FROM carts WHERE customer_id = ? AND product_id = ? AND variant_id = ?",
+					customer.ID, productID, variantID)
+				if err != nil {
+					http.Error(w, "Gagal hapus item", http.StatusInternalServerError)
+					return
+				}
+			} else if _, err := db.Exec("DELETE FROM carts WHERE customer_id = ?", customer.ID); err != nil {
+				http.Error(w, "Gagal kosongin keranjang", http.StatusInternalServerError)
+				return
+			}
+			writeCart(w, db, customer.ID)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// upsertCartItem simpan item ke keranjang setelah dicek ke stok sekarang.
+// Balikin status HTTP + pesan error (StatusOK kalau berhasil).
+func upsertCartItem(db *sql.DB, customerID int, req CartItemRequest, increment bool) (int, string) {
+	tx, err := db.Begin()
+	if err != nil {
+		return http.StatusInternalServerError, "Server Error"
+	}
+	defer tx.Rollback()
+
+	var stock, variantCount int
+	err = tx.QueryRow("SELECT stock, (SELECT COUNT(*) FROM product_variants WHERE product_id = products.id) FROM products WHERE id = ? AND status = ?",
+		req.ProductID, ProductActive).Scan(&stock, &variantCount)
+	if err != nil {
+		return http.StatusNotFound, "Produk tidak ditemukan"
+	}
+
+	// Produk bervarian: stok yang dicek stok varian yang dipilih
+	if variantCount > 0 && req.VariantID == 0 {
+		return http.StatusBadRequest, "Pilih varian produk dulu"
+	}
+	if req.VariantID != 0 {
+		err := tx.QueryRow("SELECT stock FROM product_variants WHERE id = ? AND product_id = ?", req.VariantID, req.ProductID).Scan(&stock)
+		if err != nil {
+			return http.StatusNotFound, "Varian produk tidak ditemukan"
+		}
+	}
+
+	var current int
+	err = tx.QueryRow("SELECT quantity FROM carts WHERE customer_id = ? AND product_id = ? AND variant_id = ? FOR UPDATE",
+		customerID, req.ProductID, req.VariantID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return http.StatusInternalServerError, "Server Error"
+	}
+
+	quantity := req.Quantity
+	if increment {
+		quantity += current
+	}
+
+	if quantity == 0 {
+		_, err = tx.Exec("DELETE FROM carts WHERE customer_id = ? AND product_id = ? AND variant_id = ?",
+			customerID, req.ProductID, req.VariantID)
+	} else {
+		if quantity > stock {
+			return http.StatusConflict, "Stok tidak mencukupi (sisa " + strconv.Itoa(stock) + ")"
+		}
+		_, err = tx.Exec(`INSERT INTO carts (customer_id, product_id, variant_id, quantity) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)`, customerID, req.ProductID, req.VariantID, quantity)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "Gagal simpan keranjang"
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, "Gagal simpan keranjang"
+	}
+	return http.StatusOK, ""
+}
+
+func writeCart(w http.ResponseWriter, db *sql.DB, customerID int) {
+	rows, err := db.Query(`
+		SELECT c.product_id, c.variant_id, v.sku, v.options, p.name, p.image_url,
+			COALESCE(v.price, p.price), c.quantity,
+			CASE WHEN c.variant_id > 0 THEN COALESCE(v.stock, 0) ELSE p.stock END
+		FROM carts c
+		JOIN products p ON p.id = c.product_id
+		LEFT JOIN product_variants v ON v.id = c.variant_id
+		WHERE c.customer_id = ?
+		ORDER BY c.created_at, c.id`, customerID)
+	if err != nil {
+		http.Error(w, "Gagal ambil keranjang", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	cart := CartResponse{Items: []CartItemResp{}}
+	for rows.Next() {
+		var item CartItemResp
+		var img, sku, options sql.NullString
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &sku, &options, &item.Name, &img, &item.Price, &item.Quantity, &item.Stock); err != nil {
+			continue
+		}
+		item.ImageURL = img.String
+		item.SKU = sku.String
+		item.Variant = variantLabelJSON(options)
+		item.Subtotal = roundMoney(item.Price * float64(item.Quantity))
+		item.Available = item.Quantity <= item.Stock
+		cart.TotalPrice += item.Subtotal
+		cart.Items = append(cart.Items, item)
+	}
+	cart.TotalPrice = roundMoney(cart.TotalPrice)
+
+	json.NewEncoder(w).Encode(cart)
+}
+
+// loadStoredCart ambil isi keranjang customer buat checkout (baris dikunci
+// sampai transaksi checkout selesai).
+func loadStoredCart(tx *sql.Tx, customerID int) ([]CartItemData, error) {
+	rows, err := tx.Query("SELECT product_id, variant_id, quantity FROM carts WHERE customer_id = ? ORDER BY id FOR UPDATE", customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []CartItemData
+	for rows.Next() {
+		var item CartItemData
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gaya-beauty-backend/internal/search"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// --- STRUKTUR DATA KATEGORI ---
+// Kategori bisa bertingkat lewat parent_id (Makeup > Lips > Lipstick).
+type Category struct {
+	ID           int         `json:"id"`
+	ParentID     *int        `json:"parent_id"`
+	Slug         string      `json:"slug"`
+	Name         string      `json:"name"`
+	SortOrder    int         `json:"sort_order"`
+	ProductCount int         `json:"product_count"` // Produk yang langsung di kategori ini
+	TotalCount   int         `json:"total_count"`   // Termasuk semua sub-kategori
+	Children     []*Category `json:"children"`
+}
+
+var errCategoryNotFound = errors.New("kategori tidak ditemukan")
+
+// Body create/update kategori
+type CategoryRequest struct {
+	ID        int    `json:"id"`
+	ParentID  *int   `json:"parent_id"`
+	Slug      string `json:"slug"` // Opsional, default dari nama
+	Name      string `json:"name"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// slugify: "Skin Care & Body" -> "skin-care-body"
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// categoryKey: buat nyocokin nama kategori tanpa peduli huruf besar/spasi
+func categoryKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// loadCategories ambil semua kategori (flat) + jumlah produk aktif langsungnya.
+// Tabelnya kecil, jadi pohon & turunan dihitung di Go aja.
+func loadCategories(db *sql.DB) ([]*Category, error) {
+	rows, err := db.Query(`SELECT c.id, c.parent_id, c.slug, c.name, c.sort_order, COUNT(p.id)
+		FROM categories c LEFT JOIN products p ON p.category_id = c.id AND p.status = ?
+		GROUP BY c.id, c.parent_id, c.slug, c.name, c.sort_order
+		ORDER BY c.sort_order, c.name`, ProductActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Category
+	for rows.Next() {
+		c := &Category{Children: []*Category{}}
+		var parent sql.NullInt64
+		if err := rows.Scan(&c.ID, &parent, &c.Slug, &c.Name, &c.SortOrder, &c.ProductCount); err != nil {
+			return nil, err
+		}
+		if parent.Valid {
+			pid := int(parent.Int64)
+			c.ParentID = &pid
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// buildCategoryTree susun list flat jadi pohon & hitung total_count tiap node.
+func buildCategoryTree(list []*Category) []*Category {
+	byID := make(map[int]*Category, len(list))
+	for _, c := range list {
+		byID[c.ID] = c
+	}
+
+	roots := []*Category{}
+	for _, c := range list {
+		if c.ParentID != nil && byID[*c.ParentID] != nil {
+			parent := byID[*c.ParentID]
+			parent.Children = append(parent.Children, c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var total func(c *Category) int
+	total = func(c *Category) int {
+		c.TotalCount = c.ProductCount
+		sort.SliceStable(c.Children, func(i, j int) bool { return c.Children[i].SortOrder < c.Children[j].SortOrder })
+		for _, child := range c.Children {
+			c.TotalCount += total(child)
+		}
+		return c.TotalCount
+	}
+	for _, r := range roots {
+		total(r)
+	}
+	return roots
+}
+
+func categoryByID(list []*Category, id int) *Category {
+	for _, c := range list {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// findCategory cari kategori dari slug, nama (gak peduli huruf besar/spasi), atau ID.
+// Slug & nama dicek duluan biar kategori yang namanya angka ("2024") tetap ketemu.
+func findCategory(list []*Category, ref string) *Category {
+	ref = strings.TrimSpace(ref)
+	key := categoryKey(ref)
+	for _, c := range list {
+		if c.Slug == strings.ToLower(ref) || categoryKey(c.Name) == key {
+			return c
+		}
+	}
+	if id, err := strconv.Atoi(ref); err == nil {
+		return categoryByID(list, id)
+	}
+	return nil
+}
+
+// descendantIDs = id kategori itu sendiri + semua sub-kategorinya
+func descendantIDs(list []*Category, rootID int) []int {
+	children := make(map[int][]int)
+	for _, c := range list {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	ids := []int{rootID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// resolveProductCategory ubah category_id / nama kategori dari body produk jadi id.
+// Nama teks tetap diterima biar form admin lama (kirim "Skincare") masih jalan.
+func resolveProductCategory(db *sql.DB, p *Product) (sql.NullInt64, error) {
+	if p.CategoryID == nil && strings.TrimSpace(p.Category) == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	list, err := loadCategories(db)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	var c *Category
+	if p.CategoryID != nil {
+		c = categoryByID(list, *p.CategoryID)
+	} else {
+		c = findCategory(list, p.Category)
+	}
+	if c == nil {
+		return sql.NullInt64{}, errCategoryNotFound
+	}
+
+	p.CategoryID = &c.ID
+	p.Category = c.Name
+	return sql.NullInt64{Int64: int64(c.ID), Valid: true}, nil
+}
+
+// =========================================================
+// 1. POHON KATEGORI (PUBLIC) - GET /categories
+// =========================================================
+func HandleCategories(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		list, err := loadCategories(db)
+		if err != nil {
+			http.Error(w, "Gagal ambil data kategori", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(buildCategoryTree(list))
+	}
+}
+
+// validateCategory cek nama, slug, dan parent (gak boleh bikin lingkaran).
+func validateCategory(list []*Category, req *CategoryRequest) (int, string) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return http.StatusBadRequest, "Nama kategori wajib diisi"
+	}
+	if req.Slug == "" {
+		req.Slug = req.Name
+	}
+	req.Slug = slugify(req.Slug)
+	if req.Slug == "" {
+		return http.StatusBadRequest, "Slug kategori tidak valid"
+	}
+
+	for _, c := range list {
+		if c.ID != req.ID && c.Slug == req.Slug {
+			return http.StatusConflict, "Slug kategori sudah dipakai"
+		}
+		if c.ID != req.ID && categoryKey(c.Name) == categoryKey(req.Name) {
+			return http.StatusConflict, "Kategori dengan nama itu sudah ada"
+		}
+	}
+
+	if req.ParentID != nil {
+		if categoryByID(list, *req.ParentID) == nil {
+			return http.StatusBadRequest, "Parent kategori tidak ditemukan"
+		}
+		// Parent gak boleh dirinya sendiri atau turunannya
+		if req.ID != 0 {
+			for _, id := range descendantIDs(list, req.ID) {
+				if id == *req.ParentID {
+					return http.StatusBadRequest, "Parent kategori tidak boleh sub-kategorinya sendiri"
+				}
+			}
+		}
+	}
+	return 0, ""
+}
+
+// =========================================================
+// 2. TAMBAH KATEGORI (ADMIN ONLY)
+// =========================================================
+func HandleCreateCategory(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		var req CategoryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+		req.ID = 0
+
+		list, err := loadCategories(db)
+		if err != nil {
+			http.Error(w, "Gagal ambil data kategori", http.StatusInternalServerError)
+			return
+		}
+		if status, msg := validateCategory(list, &req); status != 0 {
+			http.Error(w, msg, status)
+			return
+		}
+
+		res, err := db.Exec("INSERT INTO categories (parent_id, slug, name, sort_order) VALUES (?, ?, ?, ?)",
+			req.ParentID, req.Slug, req.Name, req.SortOrder)
+		if err != nil {
+			http.Error(w, "Gagal simpan kategori", http.StatusInternalServerError)
+			return
+		}
+		id, _ := res.LastInsertId()
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Kategori berhasil ditambahkan!",
+			"id":      id,
+			"slug":    req.Slug,
+		})
+	}
+}
+
+// =========================================================
+// 3. UPDATE KATEGORI (ADMIN ONLY)
+// =========================================================
+// Ganti nama kategori ikut mengubah teks yang di-index buat /search,
+// jadi index pencarian dibangun ulang.
+func HandleUpdateCategory(db *sql.DB, idx *search.Index) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		var req CategoryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		list, err := loadCategories(db)
+		if err != nil {
+			http.Error(w, "Gagal ambil data kategori", http.StatusInternalServerError)
+			return
+		}
+		current := categoryByID(list, req.ID)
+		if req.ID <= 0 || current == nil {
+			http.Error(w, "Kategori tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		if status, msg := validateCategory(list, &req); status != 0 {
+			http.Error(w, msg, status)
+			return
+		}
+
+		_, err = db.Exec("UPDATE categories SET parent_id=?, slug=?, name=?, sort_order=? WHERE id=?",
+			req.ParentID, req.Slug, req.Name, req.SortOrder, req.ID)
+		if err != nil {
+			http.Error(w, "Gagal update kategori", http.StatusInternalServerError)
+			return
+		}
+
+		if current.Name != req.Name {
+			if err := idx.Load(db); err != nil {
+				http.Error(w, "Kategori tersimpan, tapi index pencarian gagal diperbarui", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Kategori berhasil diupdate!"})
+	}
+}
+
+// =========================================================
+// 4. HAPUS KATEGORI (ADMIN ONLY)
+// =========================================================
+// Kategori yang masih punya sub-kategori atau produk gak boleh dihapus,
+// pindahin dulu isinya biar gak ada produk yang tiba-tiba tanpa kategori.
+func HandleDeleteCategory(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil || id <= 0 {
+			http.Error(w, "ID Kategori tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		list, err := loadCategories(db)
+		if err != nil {
+			http.Error(w, "Gagal ambil data kategori", http.StatusInternalServerError)
+			return
+		}
+		c := categoryByID(list, id)
+		if c == nil {
+			http.Error(w, "Kategori tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		if len(descendantIDs(list, id)) > 1 {
+			http.Error(w, "Kategori masih punya sub-kategori", http.StatusConflict)
+			return
+		}
+		// Produk draft & arsip juga dihitung, biar kategorinya gak hilang waktu di-restore
+		var used int
+		if err := db.QueryRow("SELECT COUNT(*) FROM products WHERE category_id = ?", id).Scan(&used); err != nil {
+			http.Error(w, "Gagal cek produk kategori", http.StatusInternalServerError)
+			return
+		}
+		if used > 0 {
+			http.Error(w, "Kategori masih dipakai produk (termasuk draft/arsip)", http.StatusConflict)
+			return
+		}
+
+		if _, err := db.Exec("DELETE FROM categories WHERE id = ?", id); err != nil {
+			http.Error(w, "Gagal hapus kategori", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Kategori berhasil dihapus!"})
+	}
+}
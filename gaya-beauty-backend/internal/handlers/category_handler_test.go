@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+// Makeup(1) > Lips(2) > Lipstick(3), Makeup > Eyes(4), Skin Care(5), 2024(6)
+func sampleCategories() []*Category {
+	return []*Category{
+		{ID: 1, Slug: "makeup", Name: "Makeup", SortOrder: 1, ProductCount: 2, Children: []*Category{}},
+		{ID: 2, ParentID: intPtr(1), Slug: "lips", Name: "Lips", SortOrder: 2, ProductCount: 1, Children: []*Category{}},
+		{ID: 3, ParentID: intPtr(2), Slug: "lipstick", Name: "Lipstick", ProductCount: 4, Children: []*Category{}},
+		{ID: 4, ParentID: intPtr(1), Slug: "eyes", Name: "Eyes", SortOrder: 1, ProductCount: 3, Children: []*Category{}},
+		{ID: 5, Slug: "skincare", Name: "Skin Care", SortOrder: 2, Children: []*Category{}},
+		{ID: 6, Slug: "2024", Name: "2024", SortOrder: 3, ProductCount: 1, Children: []*Category{}},
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	list := sampleCategories()
+	// Parent yang sudah gak ada = jadi root
+	list = append(list, &Category{ID: 7, ParentID: intPtr(99), Slug: "yatim", Name: "Yatim", SortOrder: 4, Children: []*Category{}})
+	roots := buildCategoryTree(list)
+
+	var rootIDs []int
+	for _, r := range roots {
+		rootIDs = append(rootIDs, r.ID)
+	}
+	if !slices.Equal(rootIDs, []int{1, 5, 6, 7}) {
+		t.Fatalf("root = %v, mau [1 5 6 7]", rootIDs)
+	}
+
+	makeup := roots[0]
+	if len(makeup.Children) != 2 || makeup.Children[0].ID != 4 || makeup.Children[1].ID != 2 {
+		t.Fatalf("anak Makeup salah (harus urut sort_order: Eyes, Lips): %+v", makeup.Children)
+	}
+	lips := makeup.Children[1]
+	if len(lips.Children) != 1 || lips.Children[0].ID != 3 {
+		t.Fatalf("anak Lips = %+v, mau Lipstick", lips.Children)
+	}
+
+	// product_count = produk langsung, total_count = ikut semua turunan
+	for _, c := range []struct {
+		cat         *Category
+		direct, all int
+	}{
+		{makeup, 2, 10}, // 2 + Lips(1 + Lipstick 4) + Eyes 3
+		{lips, 1, 5},
+		{lips.Children[0], 4, 4},
+		{makeup.Children[0], 3, 3},
+		{roots[1], 0, 0},
+	} {
+		if c.cat.ProductCount != c.direct || c.cat.TotalCount != c.all {
+			t.Errorf("%s: product_count %d total_count %d, mau %d & %d",
+				c.cat.Name, c.cat.ProductCount, c.cat.TotalCount, c.direct, c.all)
+		}
+	}
+}
+
+func TestFindCategory(t *testing.T) {
+	list := sampleCategories()
+	cases := map[string]int{
+		"lips":       2,
+		"LIPSTICK":   3,
+		" skin care": 5, // nama, gak peduli spasi & huruf besar
+		"skincare":   5,
+		"4":          4, // ID
+		"2024":       6, // slug angka menang dari ID
+		"tas":        0,
+		"99":         0,
+	}
+	for ref, want := range cases {
+		got := findCategory(list, ref)
+		if want == 0 {
+			if got != nil {
+				t.Errorf("findCategory(%q) = %d, mau gak ketemu", ref, got.ID)
+			}
+			continue
+		}
+		if got == nil || got.ID != want {
+			t.Errorf("findCategory(%q) = %+v, mau %d", ref, got, want)
+		}
+	}
+
+	if ids := descendantIDs(list, 1); !slices.Equal(ids, []int{1, 2, 4, 3}) {
+		t.Errorf("descendantIDs(Makeup) = %v, mau [1 2 4 3]", ids)
+	}
+}
+
+func TestValidateCategory(t *testing.T) {
+	cases := []struct {
+		name   string
+		req    CategoryRequest
+		status int
+	}{
+		{"baru di bawah Lips", CategoryRequest{Name: "Lip Gloss", ParentID: intPtr(2)}, 0},
+		{"pindah ke cabang lain", CategoryRequest{ID: 3, Name: "Lipstick", ParentID: intPtr(4)}, 0},
+		{"nama kosong", CategoryRequest{Name: "  "}, http.StatusBadRequest},
+		{"slug cuma simbol", CategoryRequest{Name: "Baru", Slug: "&&&"}, http.StatusBadRequest},
+		{"slug sudah dipakai", CategoryRequest{Name: "Bibir", Slug: "Lips"}, http.StatusConflict},
+		{"nama sudah ada", CategoryRequest{Name: "skin-care"}, http.StatusConflict},
+		{"parent gak ada", CategoryRequest{Name: "Baru", ParentID: intPtr(99)}, http.StatusBadRequest},
+		// Lingkaran: parent = dirinya sendiri / anak / cucunya
+		{"parent diri sendiri", CategoryRequest{ID: 1, Name: "Makeup", ParentID: intPtr(1)}, http.StatusBadRequest},
+		{"parent anaknya", CategoryRequest{ID: 1, Name: "Makeup", ParentID: intPtr(2)}, http.StatusBadRequest},
+		{"parent cucunya", CategoryRequest{ID: 1, Name: "Makeup", ParentID: intPtr(3)}, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		status, msg := validateCategory(sampleCategories(), &c.req)
+		if status != c.status {
+			t.Errorf("%s: status %d (%s), mau %d", c.name, status, msg, c.status)
+		}
+	}
+
+	req := CategoryRequest{Name: "Body & Hair Care"}
+	if status, _ := validateCategory(sampleCategories(), &req); status != 0 || req.Slug != "body-hair-care" {
+		t.Errorf("slug default = %q (status %d), mau body-hair-care", req.Slug, status)
+	}
+}
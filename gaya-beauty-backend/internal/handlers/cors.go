@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"gaya-beauty-backend/internal/config"
+	"net/http"
+)
+
+// CORSMiddleware pasang header CORS buat semua rute sekaligus dan jawab
+// preflight OPTIONS. Origin yang diizinkan diatur dari config (CORS_ALLOWED_ORIGINS).
+func CORSMiddleware(cfg config.CORSConfig, next http.Handler) http.Handler {
+	allowAll := false
+	allowed := map[string]bool{}
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			allowAll = true
+		}
+		allowed[o] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if allowed[origin] {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		// If-None-Match / If-Modified-Since + ETag / Last-Modified: biar JS frontend bisa revalidasi cache detail produk
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match, If-Modified-Since")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified")
+
+		// Handle Preflight Request dari Browser
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
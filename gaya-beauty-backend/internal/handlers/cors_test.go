@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"gaya-beauty-backend/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSCacheValidators(t *testing.T) {
+	h := CORSMiddleware(config.CORSConfig{AllowedOrigins: []string{"http://localhost:5173"}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodOptions, "/products/detail?id=1", nil)
+	r.Header.Set("Origin", "http://localhost:5173")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:5173" {
+		t.Fatalf("Allow-Origin = %q", got)
+	}
+	allow := rec.Header().Get("Access-Control-Allow-Headers")
+	for _, name := range []string{"Authorization", "If-None-Match", "If-Modified-Since"} {
+		if !strings.Contains(allow, name) {
+			t.Errorf("Allow-Headers %q gak ada %s", allow, name)
+		}
+	}
+	expose := rec.Header().Get("Access-Control-Expose-Headers")
+	for _, name := range []string{"ETag", "Last-Modified"} {
+		if !strings.Contains(expose, name) {
+			t.Errorf("Expose-Headers %q gak ada %s", expose, name)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"gaya-beauty-backend/internal/config"
 	"net/http"
+	"strconv"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -23,19 +26,26 @@ type CustomerLoginRequest struct {
 	Password string `json:"password"`
 }
 
+// Customer yang sudah login (diambil dari token, bukan dari body/query)
+type AuthCustomer struct {
+	ID int
+}
+
+type contextKey string
+
+const customerContextKey contextKey = "customer"
+
+// CustomerFromContext ambil customer yang dipasang CustomerAuthMiddleware.
+func CustomerFromContext(ctx context.Context) (AuthCustomer, bool) {
+	c, ok := ctx.Value(customerContextKey).(AuthCustomer)
+	return c, ok
+}
+
 // === 1. REGISTER CUSTOMER ===
 func HandleCustomerRegister(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// A. Setup CORS (Wajib biar Frontend bisa akses)
+		// A. Setup Header (CORS sudah diurus CORSMiddleware)
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
 
 		if r.Method != "POST" {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -71,18 +81,10 @@ func HandleCustomerRegister(db *sql.DB) http.HandlerFunc {
 }
 
 // === 2. LOGIN CUSTOMER ===
-func HandleCustomerLogin(db *sql.DB) http.HandlerFunc {
+func HandleCustomerLogin(db *sql.DB, authCfg config.AuthConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// A. Setup CORS
+		// A. Setup Header
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
 
 		if r.Method != "POST" {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -114,10 +116,18 @@ func HandleCustomerLogin(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		// E. Login Sukses! Kirim Data Customer Balik ke Frontend
-		// Kita kirim ID dan Nama biar bisa disimpen di localStorage Frontend
+		// E. Login Sukses! Bikin token JWT khusus customer
+		tokenString, err := issueToken(authCfg, id, strconv.Itoa(id), string(RoleCustomer))
+		if err != nil {
+			http.Error(w, "Gagal bikin token", http.StatusInternalServerError)
+			return
+		}
+
+		// Data user tetap dikirim buat tampilan, tapi identitas di request berikutnya
+		// cuma diambil dari token
 		response := map[string]interface{}{
 			"message": "Login Berhasil",
+			"token":   tokenString,
 			"user": map[string]interface{}{
 				"id":        id,
 				"full_name": fullName,
@@ -127,4 +137,25 @@ func HandleCustomerLogin(db *sql.DB) http.HandlerFunc {
 		}
 		json.NewEncoder(w).Encode(response)
 	}
-}
\ No newline at end of file
+}
+
+// === 3. MIDDLEWARE CUSTOMER ===
+// Cek token customer lalu taruh datanya di context request.
+// Token admin ditolak di sini, begitu juga sebaliknya.
+func CustomerAuthMiddleware(authCfg config.AuthConfig, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := parseToken(authCfg, r)
+		if err != nil {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+		if Role(claims.Role) != RoleCustomer || claims.UserID == 0 {
+			http.Error(w, "Token bukan milik customer", http.StatusForbidden)
+			return
+		}
+
+		customer := AuthCustomer{ID: claims.UserID}
+		ctx := context.WithValue(r.Context(), customerContextKey, customer)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gaya-beauty-backend/internal/alerts"
+	"gaya-beauty-backend/internal/inventory"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Body POST /inventory/adjust. Isi salah satu: quantity (+/- selisih) atau
+// counted (hasil hitung fisik, sistem yang ngitung selisihnya).
+// Barang masuk pakai batch_code (+ expiry_date), koreksi batch tertentu pakai batch_id.
+type InventoryAdjustRequest struct {
+	ProductID  int              `json:"product_id"`
+	VariantID  int              `json:"variant_id"` // Wajib kalau produknya punya varian
+	Quantity   int              `json:"quantity"`
+	Counted    *int             `json:"counted"`
+	Reason     inventory.Reason `json:"reason"` // restock, adjustment, damaged, expired
+	Note       string           `json:"note"`
+	BatchID    int              `json:"batch_id"`
+	BatchCode  string           `json:"batch_code"`  // Wajib buat restock (nomor batch BPOM)
+	ExpiryDate string           `json:"expiry_date"` // "2027-05-31", kosong = gak kadaluarsa
+}
+
+const (
+	defaultMovementLimit = 50
+	maxMovementLimit     = 200
+)
+
+func writeInventoryError(w http.ResponseWriter, err error) {
+	switch err {
+	case inventory.ErrNotFound:
+		http.Error(w, "Produk/varian tidak ditemukan", http.StatusNotFound)
+	case inventory.ErrVariantRequired:
+		http.Error(w, "Produk ini punya varian, isi variant_id", http.StatusBadRequest)
+	case inventory.ErrNegativeStock:
+		http.Error(w, "Stok tidak boleh minus", http.StatusConflict)
+	case inventory.ErrBatchNotFound:
+		http.Error(w, "Batch tidak ditemukan di produk/varian ini", http.StatusNotFound)
+	case inventory.ErrBatchExpiryMismatch:
+		http.Error(w, "Kode batch sudah ada dengan tanggal kadaluarsa berbeda", http.StatusConflict)
+	default:
+		http.Error(w, "Gagal ubah stok", http.StatusInternalServerError)
+	}
+}
+
+// =========================================================
+// 1. KOREKSI STOK (ADMIN) - POST /inventory/adjust
+// =========================================================
+// Satu-satunya jalan ngubah stok di luar order: restock, rusak, kadaluarsa,
+// atau stock opname. Semua masuk ledger inventory_movements.
+func HandleAdjustInventory(db *sql.DB, lowStock *alerts.LowStockChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req InventoryAdjustRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+		req.Note = strings.TrimSpace(req.Note)
+		req.BatchCode = strings.TrimSpace(req.BatchCode)
+
+		if req.ProductID <= 0 {
+			http.Error(w, "product_id wajib diisi", http.StatusBadRequest)
+			return
+		}
+		if !inventory.ManualReason(req.Reason) {
+			http.Error(w, "reason harus restock, adjustment, damaged, atau expired", http.StatusBadRequest)
+			return
+		}
+		if (req.Counted == nil) == (req.Quantity == 0) {
+			http.Error(w, "Isi salah satu: quantity (selisih) atau counted (hasil hitung fisik)", http.StatusBadRequest)
+			return
+		}
+		if len(req.Note) > 255 {
+			http.Error(w, "Catatan maksimal 255 karakter", http.StatusBadRequest)
+			return
+		}
+
+		// Batch: kode cuma buat barang masuk, batch_id buat koreksi batch yang sudah ada
+		var expiry *time.Time
+		switch {
+		case req.BatchCode != "" && req.BatchID != 0:
+			http.Error(w, "Isi salah satu: batch_code (barang masuk) atau batch_id", http.StatusBadRequest)
+			return
+		case req.Reason == inventory.ReasonRestock && req.BatchCode == "":
+			http.Error(w, "Restock wajib isi batch_code", http.StatusBadRequest)
+			return
+		case req.BatchCode != "" && (req.Counted != nil || req.Quantity < 0):
+			http.Error(w, "batch_code cuma buat barang masuk (quantity positif), pakai batch_id buat koreksi batch", http.StatusBadRequest)
+			return
+		case len(req.BatchCode) > 64:
+			http.Error(w, "batch_code maksimal 64 karakter", http.StatusBadRequest)
+			return
+		case req.ExpiryDate != "" && req.BatchCode == "":
+			http.Error(w, "expiry_date cuma bisa diisi bareng batch_code", http.StatusBadRequest)
+			return
+		}
+		if req.ExpiryDate != "" {
+			t, err := time.Parse(inventory.DateLayout, req.ExpiryDate)
+			if err != nil {
+				http.Error(w, "expiry_date harus format YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			expiry = &t
+		}
+
+		// Arah perubahan harus masuk akal sama alasannya
+		if req.Counted == nil {
+			switch {
+			case req.Reason == inventory.ReasonRestock && req.Quantity < 0:
+				http.Error(w, "Restock harus quantity positif", http.StatusBadRequest)
+				return
+			case (req.Reason == inventory.ReasonDamaged || req.Reason == inventory.ReasonExpired) && req.Quantity > 0:
+				http.Error(w, "Barang rusak/kadaluarsa harus quantity negatif", http.StatusBadRequest)
+				return
+			}
+		} else if req.Reason != inventory.ReasonAdjustment {
+			http.Error(w, "counted cuma bisa dipakai dengan reason adjustment", http.StatusBadRequest)
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+		m := inventory.Movement{
+			ProductID: req.ProductID,
+			VariantID: req.VariantID,
+			BatchID:   req.BatchID,
+			Quantity:  req.Quantity,
+			Reason:    req.Reason,
+			UserID:    user.ID,
+			Note:      req.Note,
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		var movements []inventory.Movement
+		switch {
+		case req.BatchCode != "":
+			movements, err = inventory.Receive(tx, m, req.BatchCode, expiry)
+		case req.Counted != nil:
+			movements, err = inventory.SetCount(tx, m, *req.Counted)
+		default:
+			movements, err = inventory.Adjust(tx, m)
+		}
+		if err != nil {
+			writeInventoryError(w, err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal ubah stok", http.StatusInternalServerError)
+			return
+		}
+		lowStock.Trigger(req.ProductID)
+
+		if len(movements) == 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"message": "Stok sudah sesuai, gak ada perubahan", "movements": []inventory.Movement{}})
+			return
+		}
+		// Pengurangan tanpa batch_id bisa kena beberapa batch (FEFO), satu baris per batch
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Stok berhasil diubah", "movements": movements})
+	}
+}
+
+// =========================================================
+// 2. RIWAYAT STOK (ADMIN) - GET /inventory/movements
+// =========================================================
+// Filter: product_id, variant_id, batch_id, reason, order_id. Terbaru dulu;
+// halaman berikutnya kirim before_id = next_before_id.
+func HandleInventoryMovements(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		f := inventory.Filter{Reason: inventory.Reason(q.Get("reason")), Limit: defaultMovementLimit}
+		if !parseListParams(w, q, &f.Limit, maxMovementLimit,
+			intParam{"product_id", &f.ProductID},
+			intParam{"variant_id", &f.VariantID},
+			intParam{"batch_id", &f.BatchID},
+			intParam{"order_id", &f.OrderID},
+			intParam{"before_id", &f.BeforeID},
+		) {
+			return
+		}
+
+		items, err := inventory.List(db, f)
+		if err != nil {
+			http.Error(w, "Gagal ambil riwayat stok", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{"items": items, "next_before_id": nil}
+		if len(items) == f.Limit {
+			resp["next_before_id"] = items[len(items)-1].ID
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// =========================================================
+// 3. LAPORAN SELISIH STOK (ADMIN) - GET /inventory/drift
+// =========================================================
+// Stok tiap produk/varian dihitung ulang dari ledger (dan total sisa batch) lalu
+// dibandingin sama angka di database. Selisih = ada perubahan stok yang gak lewat ledger.
+// ?all=true nampilin semua unit, bukan cuma yang selisih.
+func HandleInventoryDrift(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+		checked, items, err := inventory.Reconcile(db, all)
+		if err != nil {
+			http.Error(w, "Gagal hitung ulang stok", http.StatusInternalServerError)
+			return
+		}
+
+		drifted := 0
+		for _, d := range items {
+			if d.Difference != 0 || d.BatchDiff != 0 {
+				drifted++
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"checked": checked,
+			"drifted": drifted,
+			"items":   items,
+		})
+	}
+}
+
+// =========================================================
+// 4. BATAS RESTOCK (ADMIN) - PUT /inventory/reorder-level
+// =========================================================
+// Body: {"product_id": 1, "variant_id": 0, "reorder_level": 10}.
+// Stok <= batas ini masuk notifikasi admin. reorder_level null = ikut default
+// (varian ikut batas produk, produk ikut low_stock_threshold di config).
+func HandleSetReorderLevel(db *sql.DB, lowStock *alerts.LowStockChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			ProductID    int  `json:"product_id"`
+			VariantID    int  `json:"variant_id"`
+			ReorderLevel *int `json:"reorder_level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+		if req.ProductID <= 0 || req.VariantID < 0 {
+			http.Error(w, "product_id wajib diisi", http.StatusBadRequest)
+			return
+		}
+		if req.ReorderLevel != nil && *req.ReorderLevel < 0 {
+			http.Error(w, "Batas restock tidak boleh minus", http.StatusBadRequest)
+			return
+		}
+
+		err := alerts.SetReorderLevel(db, req.ProductID, req.VariantID, req.ReorderLevel)
+		if err == alerts.ErrNotFound {
+			http.Error(w, "Produk/varian tidak ditemukan", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Gagal simpan batas restock", http.StatusInternalServerError)
+			return
+		}
+		// Batas baru bisa bikin stok yang ada sekarang langsung dianggap menipis (atau aman)
+		lowStock.Trigger(req.ProductID)
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Batas restock berhasil disimpan"})
+	}
+}
+
+// =========================================================
+// 5. DAFTAR BATCH PRODUK (ADMIN) - GET /inventory/batches
+// =========================================================
+// ?product_id= (wajib), ?variant_id=, ?all=true ikut nampilin batch yang sudah habis.
+// Urut FEFO: batch paling atas yang keluar duluan waktu checkout.
+func HandleStockBatches(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		productID, err := strconv.Atoi(q.Get("product_id"))
+		if err != nil || productID <= 0 {
+			http.Error(w, "product_id wajib diisi", http.StatusBadRequest)
+			return
+		}
+		variantID := 0
+		if v := q.Get("variant_id"); v != "" {
+			variantID, err = strconv.Atoi(v)
+			if err != nil || variantID <= 0 {
+				http.Error(w, "variant_id tidak valid", http.StatusBadRequest)
+				return
+			}
+		}
+		all, _ := strconv.ParseBool(q.Get("all"))
+
+		items, err := inventory.ListBatches(db, productID, variantID, all)
+		if err != nil {
+			http.Error(w, "Gagal ambil batch stok", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	}
+}
+
+const (
+	defaultExpiringDays = 30
+	maxExpiringDays     = 365
+)
+
+// =========================================================
+// 6. LAPORAN BATCH HAMPIR KADALUARSA (ADMIN) - GET /inventory/batches/expiring
+// =========================================================
+// ?days=30: batch yang masih ada stoknya dan kadaluarsa dalam N hari ke depan,
+// termasuk yang sudah lewat tapi belum sempat dikeluarin dari stok (expired = true).
+func HandleExpiringBatches(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		days := defaultExpiringDays
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > maxExpiringDays {
+				http.Error(w, "days harus 0 sampai 365", http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+
+		items, err := inventory.Expiring(db, days)
+		if err != nil {
+			http.Error(w, "Gagal ambil laporan kadaluarsa", http.StatusInternalServerError)
+			return
+		}
+		total := 0
+		for _, b := range items {
+			total += b.QuantityRemaining
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"days":           days,
+			"batches":        len(items),
+			"total_quantity": total,
+			"items":          items,
+		})
+	}
+}
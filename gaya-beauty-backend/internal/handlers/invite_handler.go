@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lama berlaku undangan kalau admin gak ngisi expires_in_hours
+const defaultInviteTTL = 72 * time.Hour
+
+type CreateInviteRequest struct {
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+	ExpiresInHours int    `json:"expires_in_hours"`
+}
+
+type AcceptInviteRequest struct {
+	Token    string `json:"token"`
+	FullName string `json:"full_name"`
+	Password string `json:"password"`
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// canInvite: owner boleh ngundang role apa aja (kecuali customer),
+// admin cuma boleh ngundang admin/staff/packer.
+func canInvite(inviter AuthUser, target Role) bool {
+	switch target {
+	case RoleOwner:
+		return inviter.Role == RoleOwner
+	case RoleAdmin, RoleStaff, RolePacker:
+		return inviter.HasPermission(PermUsersInvite)
+	default:
+		return false
+	}
+}
+
+// =========================================================
+// 1. BIKIN UNDANGAN (ADMIN)
+// =========================================================
+func HandleCreateInvite(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+
+		var req CreateInviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		req.Email = strings.TrimSpace(req.Email)
+		if req.Email == "" {
+			http.Error(w, "Email wajib diisi", http.StatusBadRequest)
+			return
+		}
+		if !canInvite(user, Role(req.Role)) {
+			http.Error(w, "Role undangan tidak valid atau di luar wewenang kamu", http.StatusForbidden)
+			return
+		}
+
+		ttl := defaultInviteTTL
+		if req.ExpiresInHours > 0 {
+			ttl = time.Duration(req.ExpiresInHours) * time.Hour
+		}
+		expiresAt := time.Now().Add(ttl)
+
+		// Token asli cuma dikirim sekali ke admin, yang disimpan di DB hash-nya
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			http.Error(w, "Gagal bikin token", http.StatusInternalServerError)
+			return
+		}
+		token := hex.EncodeToString(buf)
+
+		_, err := db.Exec(`INSERT INTO user_invites (token_hash, email, role, created_by, expires_at) VALUES (?, ?, ?, ?, ?)`,
+			hashInviteToken(token), req.Email, req.Role, user.ID, expiresAt)
+		if err != nil {
+			http.Error(w, "Gagal simpan undangan", http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    "Undangan berhasil dibuat, kirim token ke calon staff",
+			"token":      token,
+			"email":      req.Email,
+			"role":       req.Role,
+			"expires_at": expiresAt,
+		})
+	}
+}
+
+// =========================================================
+// 2. TERIMA UNDANGAN (PUBLIC, PAKAI TOKEN)
+// =========================================================
+func HandleAcceptInvite(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req AcceptInviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+		if req.Token == "" || req.FullName == "" || len(req.Password) < 8 {
+			http.Error(w, "Token, nama, dan password (min. 8 karakter) wajib diisi", http.StatusBadRequest)
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			http.Error(w, "Gagal proses password", http.StatusInternalServerError)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		// Kunci baris undangan biar token gak bisa dipakai dua kali barengan
+		var inviteID int
+		var email, role string
+		var expiresAt time.Time
+		var usedAt sql.NullTime
+		err = tx.QueryRow(`SELECT id, email, role, expires_at, used_at FROM user_invites WHERE token_hash = ? FOR UPDATE`,
+			hashInviteToken(req.Token)).Scan(&inviteID, &email, &role, &expiresAt, &usedAt)
+		if err != nil {
+			http.Error(w, "Undangan tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		if usedAt.Valid {
+			http.Error(w, "Undangan sudah dipakai", http.StatusGone)
+			return
+		}
+		if time.Now().After(expiresAt) {
+			http.Error(w, "Undangan sudah kedaluwarsa", http.StatusGone)
+			return
+		}
+
+		_, err = tx.Exec("INSERT INTO users (full_name, email, password, role) VALUES (?, ?, ?, ?)",
+			req.FullName, email, string(hashedPassword), role)
+		if err != nil {
+			http.Error(w, "Email sudah terdaftar!", http.StatusConflict)
+			return
+		}
+
+		if _, err := tx.Exec("UPDATE user_invites SET used_at = NOW() WHERE id = ?", inviteID); err != nil {
+			http.Error(w, "Gagal update undangan", http.StatusInternalServerError)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal simpan akun", http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Akun berhasil dibuat, silakan login!"})
+	}
+}
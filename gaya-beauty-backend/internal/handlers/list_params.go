@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// intParam = satu parameter query angka positif di endpoint daftar
+// (?order_id=, ?before_id=, dst.) beserta field filter tujuannya.
+type intParam struct {
+	name string
+	dst  *int
+}
+
+// parseListParams isi params + ?limit= dari query (yang gak dikirim dibiarin
+// nilai awalnya), lalu batasi limit ke maxLimit. Kalau ada yang bukan angka
+// positif, respon 400 sudah ditulis dan hasilnya false.
+func parseListParams(w http.ResponseWriter, q url.Values, limit *int, maxLimit int, params ...intParam) bool {
+	params = append(params, intParam{"limit", limit})
+	for _, p := range params {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, p.name+" tidak valid", http.StatusBadRequest)
+				return false
+			}
+			*p.dst = n
+		}
+	}
+	*limit = min(*limit, maxLimit)
+	return true
+}
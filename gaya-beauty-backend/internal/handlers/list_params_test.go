@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseListParams(t *testing.T) {
+	cases := []struct {
+		query          string
+		ok             bool
+		orderID, limit int
+		errParam       string
+	}{
+		{"", true, 0, 20, ""},
+		{"order_id=7&limit=5", true, 7, 5, ""},
+		{"limit=999", true, 0, 100, ""}, // dipotong ke batas maksimal
+		{"order_id=0", false, 0, 0, "order_id"},
+		{"order_id=abc", false, 0, 0, "order_id"},
+		{"limit=-1", false, 0, 0, "limit"},
+	}
+	for _, c := range cases {
+		q, _ := url.ParseQuery(c.query)
+		w := httptest.NewRecorder()
+		orderID, limit := 0, 20
+		ok := parseListParams(w, q, &limit, 100, intParam{"order_id", &orderID})
+		if ok != c.ok {
+			t.Errorf("%q: ok = %v, mau %v", c.query, ok, c.ok)
+			continue
+		}
+		if !ok {
+			if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Body.String(), c.errParam+" tidak valid") {
+				t.Errorf("%q: respon %d %q", c.query, w.Code, w.Body.String())
+			}
+			continue
+		}
+		if orderID != c.orderID || limit != c.limit {
+			t.Errorf("%q: order_id %d limit %d, mau %d & %d", c.query, orderID, limit, c.orderID, c.limit)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gaya-beauty-backend/internal/alerts"
+	"net/http"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+// =========================================================
+// 1. DAFTAR NOTIFIKASI (ADMIN) - GET /notifications
+// =========================================================
+// ?status=open (default) | acknowledged | resolved | all, ?kind=low_stock.
+// Terbaru dulu; halaman berikutnya kirim before_id = next_before_id.
+// open_count = jumlah yang belum dibaca, buat badge di dashboard.
+func HandleNotifications(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		f := alerts.Filter{Status: alerts.StatusOpen, Kind: alerts.Kind(q.Get("kind")), Limit: defaultNotificationLimit}
+		if s := q.Get("status"); s != "" {
+			f.Status = alerts.Status(s)
+			if !alerts.ValidStatus(f.Status) {
+				http.Error(w, "status harus open, acknowledged, resolved, atau all", http.StatusBadRequest)
+				return
+			}
+		}
+		if !parseListParams(w, q, &f.Limit, maxNotificationLimit, intParam{"before_id", &f.BeforeID}) {
+			return
+		}
+
+		items, err := alerts.List(db, f)
+		if err != nil {
+			http.Error(w, "Gagal ambil notifikasi", http.StatusInternalServerError)
+			return
+		}
+		openCount, err := alerts.CountOpen(db)
+		if err != nil {
+			http.Error(w, "Gagal ambil notifikasi", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{"items": items, "open_count": openCount, "next_before_id": nil}
+		if len(items) == f.Limit {
+			resp["next_before_id"] = items[len(items)-1].ID
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// =========================================================
+// 2. TANDAI SUDAH DIBACA (ADMIN) - POST /notifications/acknowledge
+// =========================================================
+// Body: {"ids": [1, 2, 3]}. Notifikasi stok yang sudah dibaca gak dikirim ulang,
+// dan baru ditutup sistem waktu stoknya sudah aman lagi.
+func HandleAcknowledgeNotifications(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			IDs []int `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+		if len(req.IDs) == 0 || len(req.IDs) > maxNotificationLimit {
+			http.Error(w, "ids wajib diisi (maksimal 200)", http.StatusBadRequest)
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+		n, err := alerts.Acknowledge(db, req.IDs, user.ID)
+		if err != nil {
+			http.Error(w, "Gagal update notifikasi", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Notifikasi ditandai sudah dibaca", "acknowledged": n})
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/payment"
+	"io"
+	"log"
+	"net/http"
+)
+
+// Batas body webhook (notifikasi gateway cuma beberapa KB)
+const maxWebhookBody = 64 << 10
+
+const (
+	defaultPaymentLogLimit = 50
+	maxPaymentLogLimit     = 200
+)
+
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch err {
+	case orders.ErrOrderNotFound:
+		http.Error(w, "Pesanan tidak ditemukan", http.StatusNotFound)
+	case payment.ErrNotOnline:
+		http.Error(w, "Pesanan ini bukan pembayaran online", http.StatusBadRequest)
+	case payment.ErrNotPending:
+		http.Error(w, "Pesanan sudah tidak menunggu pembayaran", http.StatusConflict)
+	default:
+		log.Println("Gagal bikin sesi pembayaran:", err)
+		http.Error(w, "Gagal menghubungi payment gateway, coba lagi", http.StatusBadGateway)
+	}
+}
+
+// =========================================================
+// 1. BAYAR ULANG (CUSTOMER) - POST /my-orders/pay
+// =========================================================
+// Body: {"order_id": 12}. Balikin sesi bayar order online yang masih Pending
+// (dibikin kalau waktu checkout tadi gagal nyambung ke gateway).
+func HandlePayOrder(db *sql.DB, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+		if provider == nil {
+			http.Error(w, "Pembayaran online belum tersedia", http.StatusServiceUnavailable)
+			return
+		}
+
+		var req struct {
+			OrderID int `json:"order_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OrderID <= 0 {
+			http.Error(w, "order_id wajib diisi", http.StatusBadRequest)
+			return
+		}
+
+		session, err := payment.StartSession(r.Context(), db, provider, req.OrderID, customer.ID)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"payment": session})
+	}
+}
+
+// =========================================================
+// 2. WEBHOOK PAYMENT GATEWAY - POST /payments/notify
+// =========================================================
+// Dipanggil server gateway (bukan browser). Tanda tangan dicek dulu, order yang
+// lunas dipindah ke Lunas sekali aja walau notifikasinya dikirim berkali-kali.
+// Balas non-2xx cuma kalau gateway perlu kirim ulang (error server) atau
+// notifikasinya memang gak sah.
+func HandlePaymentWebhook(db *sql.DB, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if provider == nil {
+			http.Error(w, "Pembayaran online belum tersedia", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "Body terlalu besar", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		n, parseErr := provider.ParseNotification(body, r.Header)
+		result, err := payment.Apply(db, provider.Name(), n, parseErr, body)
+		if err != nil {
+			log.Printf("Gagal proses notifikasi %s (%s): %v", provider.Name(), n.OrderRef, err)
+			http.Error(w, "Gagal proses notifikasi", http.StatusInternalServerError)
+			return
+		}
+
+		switch result {
+		case payment.ResultInvalidSignature:
+			http.Error(w, "Tanda tangan tidak valid", http.StatusUnauthorized)
+		case payment.ResultInvalidPayload:
+			http.Error(w, "Format notifikasi tidak dikenal", http.StatusBadRequest)
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"message": "OK", "result": result})
+		}
+	}
+}
+
+// =========================================================
+// 3. LOG NOTIFIKASI PEMBAYARAN (ADMIN) - GET /payments/notifications
+// =========================================================
+// Filter: order_id, result. Terbaru dulu; halaman berikutnya kirim before_id = next_before_id.
+func HandlePaymentNotifications(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		f := payment.Filter{Result: payment.Result(q.Get("result")), Limit: defaultPaymentLogLimit}
+		if !parseListParams(w, q, &f.Limit, maxPaymentLogLimit, intParam{"order_id", &f.OrderID}, intParam{"before_id", &f.BeforeID}) {
+			return
+		}
+
+		items, err := payment.List(db, f)
+		if err != nil {
+			http.Error(w, "Gagal ambil log pembayaran", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{"items": items, "next_before_id": nil}
+		if len(items) == f.Limit {
+			resp["next_before_id"] = items[len(items)-1].ID
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/payment"
+	"gaya-beauty-backend/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func sendWebhook(t *testing.T, h http.HandlerFunc, body []byte, signature string) (int, payment.Result) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/payments/notify", bytes.NewReader(body))
+	r.Header.Set(payment.FakeSignatureHeader, signature)
+	rec := httptest.NewRecorder()
+	h(rec, r)
+
+	var resp struct {
+		Result payment.Result `json:"result"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp.Result
+}
+
+func TestPaymentWebhookFake(t *testing.T) {
+	db := testdb.Open(t)
+	fake := payment.NewFake("rahasia-test")
+	h := HandlePaymentWebhook(db, fake)
+
+	customerID := testdb.Customer(t, db, "online@test.local")
+	newOrder := func(total float64) int {
+		return testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status)
+			VALUES (?, 'Test', ?, ?, ?)`, customerID, payment.MethodOnline, total, orders.StatusPending)
+	}
+	orderStatus := func(id int) orders.Status {
+		var s orders.Status
+		if err := db.QueryRow("SELECT status FROM orders WHERE id = ?", id).Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	settlement := func(orderID int, amount string) []byte {
+		return []byte(`{"order_id":"` + payment.OrderRef(orderID) + `","transaction_id":"t-` + amount +
+			`","transaction_status":"settlement","gross_amount":"` + amount + `"}`)
+	}
+
+	paid := newOrder(150000)
+	body := settlement(paid, "150000.00")
+
+	t.Run("settlement sah bikin Lunas", func(t *testing.T) {
+		code, result := sendWebhook(t, h, body, fake.Sign(body))
+		if code != http.StatusOK || result != payment.ResultPaid {
+			t.Fatalf("status %d result %q, mau 200 paid", code, result)
+		}
+		if s := orderStatus(paid); s != orders.StatusPaid {
+			t.Errorf("status order = %q, mau Lunas", s)
+		}
+	})
+
+	t.Run("kiriman ulang = duplicate", func(t *testing.T) {
+		code, result := sendWebhook(t, h, body, fake.Sign(body))
+		if code != http.StatusOK || result != payment.ResultDuplicate {
+			t.Fatalf("status %d result %q, mau 200 duplicate", code, result)
+		}
+		var transitions int
+		db.QueryRow("SELECT COUNT(*) FROM order_status_history WHERE order_id = ? AND new_status = ?",
+			paid, orders.StatusPaid).Scan(&transitions)
+		if transitions != 1 {
+			t.Errorf("riwayat Lunas tercatat %d kali, mau sekali", transitions)
+		}
+	})
+
+	t.Run("tanda tangan salah ditolak 401 dan dicatat", func(t *testing.T) {
+		pending := newOrder(99000)
+		forged := settlement(pending, "99000.00")
+		code, _ := sendWebhook(t, h, forged, payment.NewFake("kunci-lain").Sign(forged))
+		if code != http.StatusUnauthorized {
+			t.Fatalf("status %d, mau 401", code)
+		}
+		if s := orderStatus(pending); s != orders.StatusPending {
+			t.Errorf("status order = %q, mau tetap Pending", s)
+		}
+		var result string
+		var valid bool
+		err := db.QueryRow("SELECT result, signature_valid FROM payment_notifications ORDER BY id DESC LIMIT 1").
+			Scan(&result, &valid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != string(payment.ResultInvalidSignature) || valid {
+			t.Errorf("log notifikasi = %q (valid %v), mau invalid_signature", result, valid)
+		}
+	})
+
+	t.Run("nominal beda gak bikin Lunas", func(t *testing.T) {
+		pending := newOrder(200000)
+		short := settlement(pending, "100000.00")
+		code, result := sendWebhook(t, h, short, fake.Sign(short))
+		if code != http.StatusOK || result != payment.ResultAmountMismatch {
+			t.Fatalf("status %d result %q, mau 200 amount_mismatch", code, result)
+		}
+		if s := orderStatus(pending); s != orders.StatusPending {
+			t.Errorf("status order = %q, mau tetap Pending", s)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"gaya-beauty-backend/internal/config"
+	"net/http"
+)
+
+// === ROLE & PERMISSION ===
+// Role admin/staff disimpan di kolom users.role, permission tiap role di tabel
+// role_permissions. Dua-duanya dibaca ulang tiap request (AuthMiddleware), jadi
+// ganti role / cabut akses langsung berlaku. Claim role di token cuma buat
+// bedain admin vs customer. Customer gak pernah dapat permission admin.
+type Role string
+
+const (
+	RoleOwner    Role = "owner"
+	RoleAdmin    Role = "admin"
+	RoleStaff    Role = "staff"
+	RolePacker   Role = "packer"
+	RoleCustomer Role = "customer"
+)
+
+// Nilai kolom role_permissions.permission
+type Permission string
+
+const (
+	PermOrdersRead     Permission = "orders:read"
+	PermOrdersUpdate   Permission = "orders:update"
+	PermProductsWrite  Permission = "products:write"
+	PermProductsDelete Permission = "products:delete"
+	PermUsersInvite    Permission = "users:invite"
+)
+
+// Admin/staff yang sudah login (role & permission dari database, bukan dari token)
+type AuthUser struct {
+	ID          int
+	Email       string
+	Role        Role
+	Permissions []Permission
+}
+
+// HasPermission cek apakah user boleh melakukan aksi tertentu.
+// Role yang gak ada di role_permissions otomatis gak punya akses apa-apa.
+func (u AuthUser) HasPermission(perm Permission) bool {
+	for _, p := range u.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// rolePermissions ambil daftar permission satu role dari tabel role_permissions.
+func rolePermissions(db *sql.DB, role Role) ([]Permission, error) {
+	rows, err := db.Query("SELECT permission FROM role_permissions WHERE role = ?", role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []Permission
+	for rows.Next() {
+		var p Permission
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+const userContextKey contextKey = "user"
+
+// UserFromContext ambil user yang dipasang AuthMiddleware.
+func UserFromContext(ctx context.Context) (AuthUser, bool) {
+	u, ok := ctx.Value(userContextKey).(AuthUser)
+	return u, ok
+}
+
+// RequirePermission = AuthMiddleware + cek permission dari tabel role_permissions.
+func RequirePermission(db *sql.DB, authCfg config.AuthConfig, perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return AuthMiddleware(db, authCfg, func(w http.ResponseWriter, r *http.Request) {
+		user, _ := UserFromContext(r.Context())
+		if !user.HasPermission(perm) {
+			http.Error(w, "Role kamu tidak punya akses ke sini", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AnyAuthMiddleware terima token admin maupun customer, lalu pasang yang sesuai
+// ke context (UserFromContext / CustomerFromContext). Cek akses detailnya di handler.
+func AnyAuthMiddleware(db *sql.DB, authCfg config.AuthConfig, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := parseToken(authCfg, r)
+		if err != nil || claims.UserID == 0 || claims.Role == "" {
+			http.Error(w, "Token tidak valid!", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := r.Context()
+		if Role(claims.Role) == RoleCustomer {
+			ctx = context.WithValue(ctx, customerContextKey, AuthCustomer{ID: claims.UserID})
+		} else {
+			user, ok := loadAuthUser(w, db, claims.UserID)
+			if !ok {
+				return
+			}
+			ctx = context.WithValue(ctx, userContextKey, user)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
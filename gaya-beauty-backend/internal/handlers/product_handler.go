@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"gaya-beauty-backend/internal/alerts"
+	"gaya-beauty-backend/internal/inventory"
+	"gaya-beauty-backend/internal/search"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // --- STRUKTUR DATA PRODUK ---
@@ -14,29 +21,108 @@ type Product struct {
 	Name        string  `json:"name"`
 	Price       float64 `json:"price"`
 	Stock       int     `json:"stock"`
-	Category    string  `json:"category"`
+	CategoryID  *int    `json:"category_id"`
+	Category    string  `json:"category"` // Nama kategori (boleh diisi nama/slug waktu create/update)
 	Description string  `json:"description"`
-	ImageURL    string  `json:"image_url"`
+	ImageURL    string  `json:"-"` // products.image_url: cache cover (card JPEG) / URL foto produk lama
+	// Kosong = produk tanpa varian. Waktu create/update: gak dikirim = varian gak diubah
+	Variants []ProductVariant `json:"variants"`
+	// Semua foto urut; Srcset = semua ukuran foto pertama (cover)
+	Images []ProductImage `json:"images"`
+	Srcset ImageSet       `json:"srcset"`
+	// draft, active, archived. Katalog publik cuma nampilin active
+	Status    string     `json:"status"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Waktu diarsip
+}
+
+// Status produk. "Hapus" produk = arsip (soft delete), soalnya order_items
+// masih nunjuk ke produknya.
+const (
+	ProductDraft    = "draft"
+	ProductActive   = "active"
+	ProductArchived = "archived"
+)
+
+// Status yang boleh di-set langsung lewat create/update (arsip lewat /products/delete)
+func editableProductStatus(s string) bool {
+	return s == ProductDraft || s == ProductActive
+}
+
+// Satu halaman katalog
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int       `json:"total"`       // Jumlah semua produk yang cocok filter
+	NextCursor string    `json:"next_cursor"` // Kosong = halaman terakhir
 }
 
 // =========================================================
-// 1. AMBIL SEMUA PRODUK (PUBLIC)
+// 1. KATALOG PRODUK (PUBLIC)
 // =========================================================
+// Query: q, category, min_price, max_price, in_stock, sort, limit, cursor
+// (detail di product_query.go). Halaman berikutnya: kirim balik next_cursor.
+// Cuma produk active yang muncul.
 func HandleProducts(db *sql.DB) http.HandlerFunc {
+	return productListHandler(db, false)
+}
+
+// =========================================================
+// 1C. DAFTAR PRODUK ADMIN - GET /products/admin
+// =========================================================
+// Sama kayak katalog, plus ?status=draft|active|archived|all (default all),
+// buat lihat produk draft & yang sudah diarsip.
+func HandleAdminProducts(db *sql.DB) http.HandlerFunc {
+	return productListHandler(db, true)
+}
+
+func productListHandler(db *sql.DB, admin bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Setup CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 		w.Header().Set("Content-Type", "application/json")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		q, err := parseProductQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		q.Statuses = []string{ProductActive}
+		if admin {
+			switch status := r.URL.Query().Get("status"); status {
+			case "", "all":
+				q.Statuses = nil
+			case ProductDraft, ProductActive, ProductArchived:
+				q.Statuses = []string{status}
+			default:
+				http.Error(w, "status harus draft, active, archived, atau all", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var page ProductPage
+
+		// Filter kategori ikut semua sub-kategorinya (Makeup = Lips + Lipstick + ...)
+		if q.Category != "" {
+			list, err := loadCategories(db)
+			if err != nil {
+				http.Error(w, "Gagal ambil data produk", http.StatusInternalServerError)
+				return
+			}
+			c := findCategory(list, q.Category)
+			if c == nil {
+				page.Items = []Product{}
+				json.NewEncoder(w).Encode(page)
+				return
+			}
+			q.CategoryIDs = descendantIDs(list, c.ID)
+		}
+		countQuery, countArgs := q.countSQL()
+		if err := db.QueryRow(countQuery, countArgs...).Scan(&page.Total); err != nil {
+			http.Error(w, "Gagal ambil data produk", http.StatusInternalServerError)
 			return
 		}
 
 		// Query ke Database
-		rows, err := db.Query("SELECT id, name, price, stock, category, description, image_url FROM products ORDER BY id DESC")
+		listQuery, listArgs := q.listSQL()
+		rows, err := db.Query(listQuery, listArgs...)
 		if err != nil {
 			http.Error(w, "Gagal ambil data produk", http.StatusInternalServerError)
 			return
@@ -44,42 +130,174 @@ func HandleProducts(db *sql.DB) http.HandlerFunc {
 		defer rows.Close()
 
 		var products []Product
+		var sortValues []float64
 		for rows.Next() {
 			var p Product
+			var sortValue float64
 			// Pakai sql.NullString biar aman kalau ada data kosong
-			var desc, img sql.NullString
-			
+			var categoryID sql.NullInt64
+			var category, desc, img sql.NullString
+			var deletedAt sql.NullTime
+
 			// Scan data dari database ke variabel
-			if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Category, &desc, &img); err != nil {
+			if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &category, &desc, &img, &p.Status, &deletedAt, &sortValue); err != nil {
 				continue
 			}
 
-			if desc.Valid { p.Description = desc.String }
-			if img.Valid { p.ImageURL = img.String } else { p.ImageURL = "https://placehold.co/400?text=No+Image" }
-			
+			p.setCategory(categoryID, category)
+			if deletedAt.Valid {
+				p.DeletedAt = &deletedAt.Time
+			}
+			if desc.Valid {
+				p.Description = desc.String
+			}
+			if img.Valid {
+				p.ImageURL = img.String
+			} else {
+				p.ImageURL = "https://placehold.co/400?text=No+Image"
+			}
+
 			products = append(products, p)
+			sortValues = append(sortValues, sortValue)
+		}
+
+		// Kelebihan satu baris = masih ada halaman berikutnya
+		if len(products) > q.Limit {
+			products = products[:q.Limit]
+			last := products[q.Limit-1]
+			page.NextCursor = encodeCursor(productCursor{Sort: q.Sort, Value: sortValues[q.Limit-1], ID: last.ID})
 		}
 
 		// Kalau kosong, balikin array kosong [] biar frontend gak error
-		if products == nil { products = []Product{} }
-		json.NewEncoder(w).Encode(products)
+		if products == nil {
+			products = []Product{}
+		}
+		if err := attachProductExtras(db, products); err != nil {
+			http.Error(w, "Gagal ambil varian produk", http.StatusInternalServerError)
+			return
+		}
+		page.Items = products
+		json.NewEncoder(w).Encode(page)
 	}
 }
 
+// setCategory isi CategoryID & nama kategori dari hasil LEFT JOIN categories
+func (p *Product) setCategory(id sql.NullInt64, name sql.NullString) {
+	p.CategoryID = nil
+	if id.Valid {
+		cid := int(id.Int64)
+		p.CategoryID = &cid
+	}
+	p.Category = name.String
+}
+
+// Detail satu produk (Product + info waktu)
+type ProductDetail struct {
+	Product
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // =========================================================
-// 2. TAMBAH PRODUK BARU (ADMIN ONLY)
+// 1B. DETAIL SATU PRODUK (PUBLIC) - GET /products/{id}
 // =========================================================
-func HandleCreateProduct(db *sql.DB) http.HandlerFunc {
+// Pakai ETag + Last-Modified, jadi browser yang sudah punya versi terbaru
+// cukup dapat 304 tanpa body.
+func HandleProductDetail(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Content-Type", "application/json")
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			http.Error(w, "ID Produk tidak valid", http.StatusBadRequest)
+			return
+		}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		var p ProductDetail
+		var categoryID sql.NullInt64
+		var category, desc, img sql.NullString
+		// Draft & arsip dianggap gak ada (admin lihat lewat /products/admin)
+		err = db.QueryRow(`SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name, p.description, p.image_url, p.status, p.created_at, p.updated_at
+			FROM products p LEFT JOIN categories c ON c.id = p.category_id
+			WHERE p.id = ? AND p.status = ?`, id, ProductActive).
+			Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &category, &desc, &img, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Produk tidak ditemukan", http.StatusNotFound)
 			return
 		}
+		if err != nil {
+			http.Error(w, "Gagal ambil data produk", http.StatusInternalServerError)
+			return
+		}
+
+		p.setCategory(categoryID, category)
+		p.Description = desc.String
+		if img.Valid {
+			p.ImageURL = img.String
+		} else {
+			p.ImageURL = "https://placehold.co/400?text=No+Image"
+		}
+
+		single := []Product{p.Product}
+		if err := attachProductExtras(db, single); err != nil {
+			http.Error(w, "Gagal ambil varian produk", http.StatusInternalServerError)
+			return
+		}
+		p.Product = single[0]
+
+		body, err := json.Marshal(p)
+		if err != nil {
+			http.Error(w, "Gagal proses data produk", http.StatusInternalServerError)
+			return
+		}
+
+		// ETag = hash isi respon, jadi ikut berubah kalau stok/harga berubah
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		lastModified := p.UpdatedAt.UTC().Truncate(time.Second)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "no-cache") // Boleh disimpan, tapi wajib revalidasi
+
+		if notModified(r, etag, lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// notModified: If-None-Match didahulukan, If-Modified-Since cuma dicek kalau gak ada ETag.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(t)
+	}
+	return false
+}
+
+// =========================================================
+// 2. TAMBAH PRODUK BARU (ADMIN ONLY)
+// =========================================================
+func HandleCreateProduct(db *sql.DB, idx *search.Index, lowStock *alerts.LowStockChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
 
 		// Baca JSON dari Body (Simple & Clean)
 		var p Product
@@ -93,51 +311,199 @@ func HandleCreateProduct(db *sql.DB) http.HandlerFunc {
 			http.Error(w, "Nama dan Harga wajib diisi!", http.StatusBadRequest)
 			return
 		}
+		if p.Status == "" {
+			p.Status = ProductActive
+		}
+		if !editableProductStatus(p.Status) {
+			http.Error(w, "Status produk harus draft atau active", http.StatusBadRequest)
+			return
+		}
+
+		categoryID, err := resolveProductCategory(db, &p)
+		if err == errCategoryNotFound {
+			http.Error(w, "Kategori tidak ditemukan", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Gagal cek kategori", http.StatusInternalServerError)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
 
 		// Simpan ke Database
-		query := `INSERT INTO products (name, price, stock, category, description, image_url, created_at) 
+		// Foto nyusul lewat POST /products/{id}/images
+		query := `INSERT INTO products (name, price, stock, category_id, description, status, created_at) 
 				  VALUES (?, ?, ?, ?, ?, ?, NOW())`
-		
-		_, err := db.Exec(query, p.Name, p.Price, p.Stock, p.Category, p.Description, p.ImageURL)
-		
+
+		res, err := tx.Exec(query, p.Name, p.Price, p.Stock, categoryID, p.Description, p.Status)
+
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Gagal simpan ke database: %v", err), http.StatusInternalServerError)
 			return
 		}
+		newID, _ := res.LastInsertId()
+		p.ID = int(newID)
+
+		// Stok awal masuk ledger
+		user, _ := UserFromContext(r.Context())
+		err = inventory.Record(tx, inventory.Movement{
+			ProductID: p.ID, Quantity: p.Stock, Reason: inventory.ReasonInitial, UserID: user.ID, Note: "Produk baru",
+		})
+		if err != nil {
+			http.Error(w, "Gagal catat stok awal", http.StatusInternalServerError)
+			return
+		}
+
+		// Varian (kalau ada) disimpan di transaksi yang sama
+		if len(p.Variants) > 0 {
+			if err := saveVariants(tx, p.ID, p.Variants, user.ID); err != nil {
+				writeVariantError(w, err)
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal simpan ke database", http.StatusInternalServerError)
+			return
+		}
 
-		json.NewEncoder(w).Encode(map[string]string{"message": "Produk berhasil ditambahkan!"})
+		// Masukin ke index pencarian biar langsung bisa dicari di /search (draft belum)
+		syncSearchIndex(idx, p)
+		lowStock.Trigger(p.ID)
+
+		// ID dibalikin biar frontend bisa lanjut upload foto ke /products/{id}/images
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Produk berhasil ditambahkan!", "id": p.ID})
 	}
 }
 
 // =========================================================
 // 3. UPDATE PRODUK (ADMIN ONLY)
 // =========================================================
-func HandleUpdateProduct(db *sql.DB) http.HandlerFunc {
+func HandleUpdateProduct(db *sql.DB, idx *search.Index, lowStock *alerts.LowStockChecker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, PUT, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Content-Type", "application/json")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+		// Stok gak ditimpa begitu aja: kalau mau diubah, kirim juga expected_stock
+		// (stok yang kebaca waktu form dibuka). Kalau sudah beda (keburu ada
+		// checkout/restock), ditolak 409 biar admin muat ulang dulu.
+		var req struct {
+			Product
+			Stock         *int `json:"stock"` // nil = stok gak diubah
+			ExpectedStock *int `json:"expected_stock"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON error", http.StatusBadRequest)
+			return
+		}
+		p := req.Product
+		// Status kosong = gak diubah
+		if p.Status != "" && !editableProductStatus(p.Status) {
+			http.Error(w, "Status produk harus draft atau active (arsip lewat /products/delete)", http.StatusBadRequest)
 			return
 		}
 
-		var p Product
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-			http.Error(w, "Data JSON error", http.StatusBadRequest)
+		categoryID, err := resolveProductCategory(db, &p)
+		if err == errCategoryNotFound {
+			http.Error(w, "Kategori tidak ditemukan", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Gagal cek kategori", http.StatusInternalServerError)
 			return
 		}
 
-		query := `UPDATE products SET name=?, price=?, stock=?, category=?, description=?, image_url=? WHERE id=?`
-		_, err := db.Exec(query, p.Name, p.Price, p.Stock, p.Category, p.Description, p.ImageURL, p.ID)
-		
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		// Baris produk dikunci dulu biar stok yang dicek gak berubah sampai commit
+		var oldStock int
+		var hasVariants bool
+		err = tx.QueryRow(`SELECT stock, EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+			FROM products WHERE id = ? FOR UPDATE`, p.ID).Scan(&oldStock, &hasVariants)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Produk tidak ditemukan", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Gagal update produk", http.StatusInternalServerError)
+			return
+		}
+
+		// image_url gak disentuh: cover diatur dari endpoint foto produk.
+		// Ganti status ke draft/active sekalian ngeluarin produk dari arsip
+		// (MySQL ngisi SET urut kiri ke kanan, jadi deleted_at lihat status yang baru).
+		// Stok gak ikut di sini, perubahannya lewat ledger di bawah.
+		query := `UPDATE products SET name=?, price=?, category_id=?, description=?,
+			status = COALESCE(NULLIF(?, ''), status),
+			deleted_at = IF(status = 'archived', deleted_at, NULL)
+			WHERE id=?`
+		_, err = tx.Exec(query, p.Name, p.Price, categoryID, p.Description, p.Status, p.ID)
 		if err != nil {
 			http.Error(w, "Gagal update produk", http.StatusInternalServerError)
 			return
 		}
 
+		// Produk tanpa varian: stok baru = hasil hitung, selisihnya dicatat sebagai koreksi
+		// (produk bervarian stoknya dicatat per varian di saveVariants)
+		user, _ := UserFromContext(r.Context())
+		if !hasVariants && req.Stock != nil {
+			changed := *req.Stock != oldStock
+			if req.ExpectedStock != nil {
+				changed = *req.Stock != *req.ExpectedStock
+			}
+			if changed {
+				if req.ExpectedStock == nil {
+					http.Error(w, "Stok diubah, expected_stock wajib diisi", http.StatusBadRequest)
+					return
+				}
+				if *req.ExpectedStock != oldStock {
+					writeJSONError(w, http.StatusConflict, map[string]interface{}{
+						"error": "Stok sudah berubah sejak form dibuka, silakan muat ulang",
+						"stock": oldStock,
+					})
+					return
+				}
+				_, err = inventory.SetCount(tx, inventory.Movement{
+					ProductID: p.ID, Reason: inventory.ReasonAdjustment, UserID: user.ID, Note: "Edit produk",
+				}, *req.Stock)
+				if err == inventory.ErrNegativeStock {
+					http.Error(w, "Stok tidak boleh minus", http.StatusBadRequest)
+					return
+				} else if err != nil {
+					http.Error(w, "Gagal catat perubahan stok", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		// Variants gak dikirim = varian lama tetap, tapi stok produk tetap ikut total varian
+		if p.Variants != nil {
+			err = saveVariants(tx, p.ID, p.Variants, user.ID)
+		} else {
+			err = syncProductStock(tx, p.ID)
+		}
+		if err != nil {
+			writeVariantError(w, err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal update produk", http.StatusInternalServerError)
+			return
+		}
+		if err := db.QueryRow("SELECT status FROM products WHERE id = ?", p.ID).Scan(&p.Status); err == nil {
+			syncSearchIndex(idx, p)
+		}
+		// Stok / status baru dicek di background: bisa bikin atau nutup notifikasi stok menipis
+		lowStock.Trigger(p.ID)
+
 		json.NewEncoder(w).Encode(map[string]string{"message": "Produk berhasil diupdate!"})
 	}
 }
@@ -145,18 +511,12 @@ func HandleUpdateProduct(db *sql.DB) http.HandlerFunc {
 // =========================================================
 // 4. HAPUS PRODUK (ADMIN ONLY)
 // =========================================================
-func HandleDeleteProduct(db *sql.DB) http.HandlerFunc {
+// Bukan DELETE beneran: produk diarsip (status archived + deleted_at) biar
+// riwayat order tetap utuh. Bisa dibalikin lewat /products/restore.
+func HandleDeleteProduct(db *sql.DB, idx *search.Index) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Content-Type", "application/json")
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		// Ambil ID dari Query Param (?id=1) atau Body JSON
 		idStr := r.URL.Query().Get("id")
 		var id int
@@ -166,7 +526,9 @@ func HandleDeleteProduct(db *sql.DB) http.HandlerFunc {
 			id, err = strconv.Atoi(idStr)
 		} else {
 			// Coba ambil dari body kalau query kosong
-			var req struct { ID int `json:"id"` }
+			var req struct {
+				ID int `json:"id"`
+			}
 			json.NewDecoder(r.Body).Decode(&req)
 			id = req.ID
 		}
@@ -176,12 +538,97 @@ func HandleDeleteProduct(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		_, err = db.Exec("DELETE FROM products WHERE id = ?", id)
+		tx, err := db.Begin()
 		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		var status string
+		err = tx.QueryRow("SELECT status FROM products WHERE id = ? FOR UPDATE", id).Scan(&status)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Produk tidak ditemukan", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Gagal hapus produk", http.StatusInternalServerError)
+			return
+		}
+		if status == ProductArchived {
+			http.Error(w, "Produk sudah diarsip", http.StatusConflict)
+			return
+		}
+
+		if _, err := tx.Exec("UPDATE products SET status = ?, deleted_at = NOW() WHERE id = ?", ProductArchived, id); err != nil {
 			http.Error(w, "Gagal hapus produk", http.StatusInternalServerError)
 			return
 		}
+		// Produk arsip gak bisa dibeli, jadi sekalian dikeluarin dari keranjang customer
+		if _, err := tx.Exec("DELETE FROM carts WHERE product_id = ?", id); err != nil {
+			http.Error(w, "Gagal hapus produk", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal hapus produk", http.StatusInternalServerError)
+			return
+		}
+		idx.Remove(id)
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Produk berhasil dihapus (diarsip)!"})
+	}
+}
+
+// =========================================================
+// 4B. RESTORE PRODUK ARSIP (ADMIN ONLY) - POST /products/restore
+// =========================================================
+// Body: {"id": 12, "status": "draft"}. Status boleh kosong (default active).
+func HandleRestoreProduct(db *sql.DB, idx *search.Index) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-		json.NewEncoder(w).Encode(map[string]string{"message": "Produk berhasil dihapus!"})
+		var req struct {
+			ID     int    `json:"id"`
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+			http.Error(w, "ID Produk tidak valid", http.StatusBadRequest)
+			return
+		}
+		if req.Status == "" {
+			req.Status = ProductActive
+		}
+		if !editableProductStatus(req.Status) {
+			http.Error(w, "Status produk harus draft atau active", http.StatusBadRequest)
+			return
+		}
+
+		res, err := db.Exec("UPDATE products SET status = ?, deleted_at = NULL WHERE id = ? AND status = ?",
+			req.Status, req.ID, ProductArchived)
+		if err != nil {
+			http.Error(w, "Gagal restore produk", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			http.Error(w, "Produk arsip tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		// Balikin ke index pencarian (datanya diambil ulang, request cuma bawa ID)
+		var p Product
+		var category, desc sql.NullString
+		err = db.QueryRow(`SELECT p.id, p.name, c.name, p.description, p.status
+			FROM products p LEFT JOIN categories c ON c.id = p.category_id WHERE p.id = ?`, req.ID).
+			Scan(&p.ID, &p.Name, &category, &desc, &p.Status)
+		if err == nil {
+			p.Category = category.String
+			p.Description = desc.String
+			syncSearchIndex(idx, p)
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Produk berhasil di-restore!"})
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gaya-beauty-backend/internal/search"
+	"gaya-beauty-backend/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpdateProductStock(t *testing.T) {
+	db := testdb.Open(t)
+	h := HandleUpdateProduct(db, search.NewIndex(), nil)
+	productID := testdb.Product(t, db, "Serum", 85000, 10)
+
+	update := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		body["id"] = productID
+		body["name"] = "Serum"
+		body["price"] = 85000
+		b, _ := json.Marshal(body)
+		r := httptest.NewRequest(http.MethodPut, "/products/update", bytes.NewReader(b))
+		r = r.WithContext(context.WithValue(r.Context(), userContextKey, AuthUser{ID: 1, Role: RoleAdmin}))
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		return rec
+	}
+	stock := func() int {
+		var s int
+		if err := db.QueryRow("SELECT stock FROM products WHERE id = ?", productID).Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// Form dibuka waktu stok 10, lalu ada yang checkout 3
+	testdb.Exec(t, db, "UPDATE products SET stock = 7 WHERE id = ?", productID)
+	testdb.Exec(t, db, "UPDATE stock_batches SET quantity_remaining = 7 WHERE product_id = ?", productID)
+
+	if rec := update(map[string]interface{}{"stock": 10, "expected_stock": 10}); rec.Code != http.StatusOK {
+		t.Fatalf("stok gak diubah: status %d (%s), mau 200", rec.Code, rec.Body)
+	}
+	if s := stock(); s != 7 {
+		t.Fatalf("edit tanpa ubah stok numpuk stok jadi %d, mau tetap 7", s)
+	}
+
+	if rec := update(map[string]interface{}{"stock": 20}); rec.Code != http.StatusBadRequest {
+		t.Errorf("ubah stok tanpa expected_stock: status %d, mau 400", rec.Code)
+	}
+
+	rec := update(map[string]interface{}{"stock": 20, "expected_stock": 10})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected_stock basi: status %d, mau 409", rec.Code)
+	}
+	var conflict struct {
+		Stock int `json:"stock"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &conflict)
+	if conflict.Stock != 7 || stock() != 7 {
+		t.Errorf("409 balikin stok %d, stok sekarang %d, mau 7", conflict.Stock, stock())
+	}
+
+	if rec := update(map[string]interface{}{"stock": 20, "expected_stock": 7}); rec.Code != http.StatusOK {
+		t.Fatalf("expected_stock cocok: status %d (%s), mau 200", rec.Code, rec.Body)
+	}
+	if s := stock(); s != 20 {
+		t.Errorf("stok = %d, mau 20", s)
+	}
+	var qty, after int
+	err := db.QueryRow(`SELECT SUM(quantity), MAX(stock_after) FROM inventory_movements
+		WHERE product_id = ? AND reason = 'adjustment'`, productID).Scan(&qty, &after)
+	if err != nil || qty != 13 || after != 20 {
+		t.Errorf("ledger koreksi = %d (stock_after %d, err %v), mau +13 jadi 20", qty, after, err)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	const etag = `"abc123"`
+	modified := time.Date(2026, 3, 10, 8, 30, 0, 0, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	same := modified.Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	cases := []struct {
+		name        string
+		noneMatch   string
+		modSince    string
+		notModified bool
+	}{
+		{"tanpa validator", "", "", false},
+		{"etag sama", etag, "", true},
+		{"etag lemah", `W/"abc123"`, "", true},
+		{"salah satu dari daftar", `"lama", "abc123"`, "", true},
+		{"bintang", "*", "", true},
+		{"etag beda", `"lama"`, "", false},
+		{"tanpa tanda kutip", "abc123", "", false},
+		{"belum berubah sejak", "", same, true},
+		{"dicek setelah berubah", "", after, true},
+		{"berubah setelah dicek", "", before, false},
+		{"tanggal ngaco", "", "kemarin sore", false},
+		// If-None-Match didahulukan: kalau ada, If-Modified-Since diabaikan
+		{"etag beda walau tanggal cocok", `"lama"`, after, false},
+		{"etag sama walau tanggal lama", etag, before, true},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/products/detail?id=1", nil)
+		if c.noneMatch != "" {
+			r.Header.Set("If-None-Match", c.noneMatch)
+		}
+		if c.modSince != "" {
+			r.Header.Set("If-Modified-Since", c.modSince)
+		}
+		if got := notModified(r, etag, modified); got != c.notModified {
+			t.Errorf("%s: notModified = %v, mau %v", c.name, got, c.notModified)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gaya-beauty-backend/internal/imaging"
+	"gaya-beauty-backend/internal/storage"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// --- FOTO PRODUK ---
+type ProductImage struct {
+	ID        int      `json:"id"`
+	Srcset    ImageSet `json:"srcset"`
+	SortOrder int      `json:"sort_order"`
+}
+
+// Satu ukuran foto: Src = JPEG (aman di semua browser), WebP = versi lebih
+// kecil buat <source type="image/webp"> (kosong kalau gak ada).
+type ImageSource struct {
+	Src    string `json:"src"`
+	WebP   string `json:"webp,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// ImageSet = semua ukuran satu foto, key-nya nama ukuran (thumb, card, zoom)
+type ImageSet map[string]ImageSource
+
+// Produk lama yang fotonya cuma satu URL (image_url): URL itu dipakai di semua ukuran
+func singleURLImageSet(url string) ImageSet {
+	set := ImageSet{}
+	for _, s := range imaging.Sizes {
+		set[s.Name] = ImageSource{Src: url}
+	}
+	return set
+}
+
+// Maksimal file per sekali upload
+const maxImagesPerUpload = 10
+
+// Tipe file yang diterima (cek cepat dari isi file; validasi penuhnya di imaging.Process)
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// loadImages ambil foto beberapa produk sekaligus (key = product_id).
+func loadImages(q queryer, productIDs []int) (map[int][]ProductImage, error) {
+	result := make(map[int][]ProductImage)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	rows, err := q.Query(`SELECT i.product_id, i.id, i.sort_order, r.size, r.format, r.url, r.width, r.height
+		FROM product_images i LEFT JOIN product_image_renditions r ON r.image_id = i.id
+		WHERE i.product_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",")+`)
+		ORDER BY i.product_id, i.sort_order, i.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var img ProductImage
+		var size, format, url sql.NullString
+		var width, height sql.NullInt64
+		if err := rows.Scan(&productID, &img.ID, &img.SortOrder, &size, &format, &url, &width, &height); err != nil {
+			return nil, err
+		}
+
+		// Satu baris per file, digabung per foto (baris foto yang sama pasti berurutan)
+		list := result[productID]
+		if len(list) == 0 || list[len(list)-1].ID != img.ID {
+			img.Srcset = ImageSet{}
+			list = append(list, img)
+		}
+		if size.Valid {
+			set := list[len(list)-1].Srcset
+			src := set[size.String]
+			if format.String == "webp" {
+				src.WebP = url.String
+			} else {
+				src.Src = url.String
+			}
+			src.Width, src.Height = int(width.Int64), int(height.Int64)
+			set[size.String] = src
+		}
+		result[productID] = list
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Foto lama yang cuma ada versi WebP-nya: Src tetap harus keisi
+	for _, list := range result {
+		for _, img := range list {
+			for name, src := range img.Srcset {
+				if src.Src == "" {
+					src.Src = src.WebP
+					img.Srcset[name] = src
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// attachProductExtras isi Variants, Images & Srcset (cover) tiap produk.
+func attachProductExtras(q queryer, products []Product) error {
+	if err := attachVariants(q, products); err != nil {
+		return err
+	}
+
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	images, err := loadImages(q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].Images = images[products[i].ID]
+		if products[i].Images == nil {
+			products[i].Images = []ProductImage{}
+		}
+
+		// Cover = foto urutan pertama; produk lama tanpa foto pakai image_url
+		if len(products[i].Images) > 0 {
+			products[i].Srcset = products[i].Images[0].Srcset
+		} else {
+			products[i].Srcset = singleURLImageSet(products[i].ImageURL)
+		}
+	}
+	return nil
+}
+
+// syncCoverImage samain products.image_url sama foto urutan pertama (ukuran card, JPEG).
+// Produk lama yang belum punya foto di product_images dibiarkan pakai image_url-nya.
+func syncCoverImage(db *sql.DB, productID int) error {
+	_, err := db.Exec(`UPDATE products p
+		JOIN (SELECT r.url FROM product_images i JOIN product_image_renditions r ON r.image_id = i.id
+			WHERE i.product_id = ? AND r.size = 'card'
+			ORDER BY i.sort_order, i.id, r.format = 'webp'
+			LIMIT 1) cover
+		SET p.image_url = cover.url
+		WHERE p.id = ?`, productID, productID)
+	return err
+}
+
+func randomKey() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func productIDFromPath(w http.ResponseWriter, r *http.Request, db *sql.DB) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "ID Produk tidak valid", http.StatusBadRequest)
+		return 0, false
+	}
+	var exists int
+	if err := db.QueryRow("SELECT 1 FROM products WHERE id = ?", id).Scan(&exists); err != nil {
+		http.Error(w, "Produk tidak ditemukan", http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func writeProductImages(w http.ResponseWriter, db *sql.DB, productID int, status int) {
+	images, err := loadImages(db, []int{productID})
+	if err != nil {
+		http.Error(w, "Gagal ambil foto produk", http.StatusInternalServerError)
+		return
+	}
+	list := images[productID]
+	if list == nil {
+		list = []ProductImage{}
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"images": list})
+}
+
+// =========================================================
+// 5. UPLOAD FOTO PRODUK (ADMIN ONLY) - POST /products/{id}/images
+// =========================================================
+// Body multipart, field "images" (boleh banyak file). Foto baru ditaruh
+// di urutan paling belakang.
+func HandleUploadProductImages(db *sql.DB, store storage.Storage, maxUploadMB int) http.HandlerFunc {
+	maxFileSize := int64(maxUploadMB) << 20
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		productID, ok := productIDFromPath(w, r, db)
+		if !ok {
+			return
+		}
+
+		// Batas total body biar gak ada yang upload berGB-GB
+		r.Body = http.MaxBytesReader(w, r.Body, maxFileSize*maxImagesPerUpload+(1<<20))
+		if err := r.ParseMultipartForm(8 << 20); err != nil {
+			http.Error(w, "Upload terlalu besar atau bukan multipart/form-data", http.StatusRequestEntityTooLarge)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		files := append(r.MultipartForm.File["images"], r.MultipartForm.File["image"]...)
+		if len(files) == 0 {
+			http.Error(w, "Pilih minimal satu foto (field: images)", http.StatusBadRequest)
+			return
+		}
+		if len(files) > maxImagesPerUpload {
+			http.Error(w, fmt.Sprintf("Maksimal %d foto sekali upload", maxImagesPerUpload), http.StatusBadRequest)
+			return
+		}
+
+		// Cek & olah semua file dulu sebelum ada yang di-upload
+		type upload struct {
+			name       string
+			renditions []imaging.Rendition
+		}
+		var uploads []upload
+		for _, fh := range files {
+			if fh.Size > maxFileSize {
+				http.Error(w, fmt.Sprintf("%s lebih dari %d MB", fh.Filename, maxUploadMB), http.StatusRequestEntityTooLarge)
+				return
+			}
+			f, err := fh.Open()
+			if err != nil {
+				http.Error(w, "Gagal baca file upload", http.StatusBadRequest)
+				return
+			}
+			data, err := io.ReadAll(io.LimitReader(f, maxFileSize+1))
+			f.Close()
+			if err != nil || int64(len(data)) > maxFileSize {
+				http.Error(w, "Gagal baca file upload", http.StatusBadRequest)
+				return
+			}
+
+			if !allowedImageTypes[http.DetectContentType(data)] {
+				http.Error(w, fh.Filename+" bukan gambar JPG/PNG/WebP", http.StatusUnsupportedMediaType)
+				return
+			}
+
+			// Resize ke semua ukuran + buang EXIF. File rusak/palsu ketahuan di sini.
+			renditions, err := imaging.Process(data)
+			if err == imaging.ErrTooLarge {
+				http.Error(w, fh.Filename+": resolusi gambar terlalu besar", http.StatusRequestEntityTooLarge)
+				return
+			} else if err == imaging.ErrNotImage {
+				http.Error(w, fh.Filename+" bukan gambar JPG/PNG/WebP yang valid", http.StatusUnsupportedMediaType)
+				return
+			} else if err != nil {
+				log.Println("Gagal olah foto produk:", err)
+				http.Error(w, "Gagal olah "+fh.Filename, http.StatusInternalServerError)
+				return
+			}
+			uploads = append(uploads, upload{name: fh.Filename, renditions: renditions})
+		}
+
+		var nextOrder int
+		if err := db.QueryRow("SELECT COALESCE(MAX(sort_order) + 1, 0) FROM product_images WHERE product_id = ?", productID).Scan(&nextOrder); err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		// Upload ke storage, lalu catat di DB. Kalau gagal di tengah, file yang
+		// sudah ke-upload dihapus lagi biar gak jadi sampah.
+		var savedKeys []string
+		cleanup := func() {
+			for _, key := range savedKeys {
+				if err := store.Delete(r.Context(), key); err != nil {
+					log.Println("Gagal hapus file upload yang batal:", key, err)
+				}
+			}
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		for i, u := range uploads {
+			name, err := randomKey()
+			if err != nil {
+				cleanup()
+				http.Error(w, "Server Error", http.StatusInternalServerError)
+				return
+			}
+			res, err := tx.Exec("INSERT INTO product_images (product_id, sort_order) VALUES (?, ?)", productID, nextOrder+i)
+			if err != nil {
+				cleanup()
+				http.Error(w, "Gagal simpan foto produk", http.StatusInternalServerError)
+				return
+			}
+			imageID, _ := res.LastInsertId()
+
+			for _, rd := range u.renditions {
+				// Nama file beda per format (bukan cuma ekstensi): Cloudinary buang ekstensi dari public ID
+				key := fmt.Sprintf("products/%d/%s/%s-%s%s", productID, name, rd.Size, rd.Format, rd.Ext)
+				url, err := store.Save(r.Context(), key, bytes.NewReader(rd.Data), rd.ContentType)
+				if err != nil {
+					cleanup()
+					log.Println("Gagal upload foto produk:", err)
+					http.Error(w, "Gagal upload "+u.name, http.StatusBadGateway)
+					return
+				}
+				savedKeys = append(savedKeys, key)
+
+				_, err = tx.Exec(`INSERT INTO product_image_renditions (image_id, size, format, storage_key, url, width, height, size_bytes)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, imageID, rd.Size, rd.Format, key, url, rd.Width, rd.Height, len(rd.Data))
+				if err != nil {
+					cleanup()
+					http.Error(w, "Gagal simpan foto produk", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			cleanup()
+			http.Error(w, "Gagal simpan foto produk", http.StatusInternalServerError)
+			return
+		}
+		if err := syncCoverImage(db, productID); err != nil {
+			log.Println("Gagal update cover produk:", err)
+		}
+
+		writeProductImages(w, db, productID, http.StatusCreated)
+	}
+}
+
+// =========================================================
+// 6. HAPUS FOTO PRODUK (ADMIN ONLY) - DELETE /products/{id}/images/{imageID}
+// =========================================================
+func HandleDeleteProductImage(db *sql.DB, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		productID, ok := productIDFromPath(w, r, db)
+		if !ok {
+			return
+		}
+		imageID, err := strconv.Atoi(r.PathValue("imageID"))
+		if err != nil {
+			http.Error(w, "ID Foto tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		var exists int
+		err = db.QueryRow("SELECT 1 FROM product_images WHERE id = ? AND product_id = ?", imageID, productID).Scan(&exists)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Foto tidak ditemukan", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		rows, err := db.Query("SELECT storage_key, url FROM product_image_renditions WHERE image_id = ?", imageID)
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		var keys []string
+		var urls []interface{}
+		seen := map[string]bool{}
+		for rows.Next() {
+			var key, url string
+			if err := rows.Scan(&key, &url); err != nil {
+				continue
+			}
+			// Foto lama: satu file dipakai semua ukuran, jadi key-nya bisa sama
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+			urls = append(urls, url)
+		}
+		rows.Close()
+
+		if _, err := db.Exec("DELETE FROM product_images WHERE id = ?", imageID); err != nil {
+			http.Error(w, "Gagal hapus foto", http.StatusInternalServerError)
+			return
+		}
+
+		// Foto yang dihapus jadi cover? Ganti ke foto berikutnya (atau kosong kalau habis)
+		if len(urls) > 0 {
+			db.Exec("UPDATE products SET image_url = NULL WHERE id = ? AND image_url IN (?"+strings.Repeat(",?", len(urls)-1)+")",
+				append([]interface{}{productID}, urls...)...)
+		}
+		if err := syncCoverImage(db, productID); err != nil {
+			log.Println("Gagal update cover produk:", err)
+		}
+
+		// File di storage dihapus belakangan: kalau gagal cuma jadi sampah, data tetap konsisten
+		for _, key := range keys {
+			if err := store.Delete(r.Context(), key); err != nil {
+				log.Println("Gagal hapus file foto:", key, err)
+			}
+		}
+
+		writeProductImages(w, db, productID, http.StatusOK)
+	}
+}
+
+// =========================================================
+// 7. ATUR URUTAN FOTO (ADMIN ONLY) - PUT /products/{id}/images/order
+// =========================================================
+// Body: {"image_ids": [3, 1, 2]} -> semua foto produk, urutan baru.
+func HandleReorderProductImages(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		productID, ok := productIDFromPath(w, r, db)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			ImageIDs []int `json:"image_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data JSON tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		images, err := loadImages(db, []int{productID})
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		current := map[int]bool{}
+		for _, img := range images[productID] {
+			current[img.ID] = true
+		}
+
+		// Harus persis semua foto produk ini, masing-masing sekali
+		seen := map[int]bool{}
+		for _, id := range req.ImageIDs {
+			if !current[id] || seen[id] {
+				http.Error(w, "Daftar foto tidak cocok dengan foto produk", http.StatusBadRequest)
+				return
+			}
+			seen[id] = true
+		}
+		if len(seen) != len(current) {
+			http.Error(w, "Daftar foto tidak cocok dengan foto produk", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+		for i, id := range req.ImageIDs {
+			if _, err := tx.Exec("UPDATE product_images SET sort_order = ? WHERE id = ?", i, id); err != nil {
+				http.Error(w, "Gagal simpan urutan foto", http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal simpan urutan foto", http.StatusInternalServerError)
+			return
+		}
+		if err := syncCoverImage(db, productID); err != nil {
+			log.Println("Gagal update cover produk:", err)
+		}
+
+		writeProductImages(w, db, productID, http.StatusOK)
+	}
+}
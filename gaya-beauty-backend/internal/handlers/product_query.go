@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gaya-beauty-backend/internal/orders"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Batas jumlah produk per halaman katalog
+const (
+	defaultProductLimit = 20
+	maxProductLimit     = 100
+)
+
+// Parameter query GET /products
+type ProductQuery struct {
+	Search      string   // ?q= cari di nama & deskripsi
+	Category    string   // ?category= id, slug, atau nama kategori
+	CategoryIDs []int    // Diisi handler: kategori itu + semua sub-kategorinya
+	Statuses    []string // Diisi handler: publik cuma active, admin bisa pilih (kosong = semua)
+	MinPrice    float64  // ?min_price=
+	MaxPrice    float64  // ?max_price= (0 = tanpa batas)
+	InStock     bool     // ?in_stock=true
+	Sort        string   // ?sort=newest|price_asc|price_desc|best_selling
+	Limit       int      // ?limit=
+	Cursor      *productCursor
+}
+
+// Posisi terakhir halaman sebelumnya (dikirim ke client dalam bentuk base64)
+type productCursor struct {
+	Sort  string  `json:"s"`
+	Value float64 `json:"v"`
+	ID    int     `json:"id"`
+}
+
+var errBadCursor = errors.New("cursor tidak valid")
+
+func encodeCursor(c productCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*productCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	var c productCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID <= 0 {
+		return nil, errBadCursor
+	}
+	return &c, nil
+}
+
+func parseProductQuery(v url.Values) (ProductQuery, error) {
+	q := ProductQuery{
+		Search:   strings.TrimSpace(v.Get("q")),
+		Category: strings.TrimSpace(v.Get("category")),
+		Sort:     v.Get("sort"),
+		Limit:    defaultProductLimit,
+	}
+
+	if q.Sort == "" {
+		q.Sort = "newest"
+	}
+	switch q.Sort {
+	case "newest", "price_asc", "price_desc", "best_selling":
+	default:
+		return q, errors.New("sort harus newest, price_asc, price_desc, atau best_selling")
+	}
+
+	var err error
+	if s := v.Get("min_price"); s != "" {
+		if q.MinPrice, err = strconv.ParseFloat(s, 64); err != nil || q.MinPrice < 0 {
+			return q, errors.New("min_price tidak valid")
+		}
+	}
+	if s := v.Get("max_price"); s != "" {
+		if q.MaxPrice, err = strconv.ParseFloat(s, 64); err != nil || q.MaxPrice < 0 {
+			return q, errors.New("max_price tidak valid")
+		}
+	}
+	if s := v.Get("in_stock"); s != "" {
+		if q.InStock, err = strconv.ParseBool(s); err != nil {
+			return q, errors.New("in_stock harus true/false")
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 1 {
+			return q, errors.New("limit tidak valid")
+		}
+		if q.Limit > maxProductLimit {
+			q.Limit = maxProductLimit
+		}
+	}
+	if s := v.Get("cursor"); s != "" {
+		if q.Cursor, err = decodeCursor(s); err != nil {
+			return q, err
+		}
+		// Cursor dari urutan lain gak bisa dipakai
+		if q.Cursor.Sort != q.Sort {
+			return q, errBadCursor
+		}
+	}
+	return q, nil
+}
+
+// Kolom "nilai urut" per jenis sort (dipakai di ORDER BY & cursor)
+func (q ProductQuery) sortExpr() string {
+	switch q.Sort {
+	case "price_asc", "price_desc":
+		return "p.price"
+	case "best_selling":
+		return "COALESCE(s.sold, 0)"
+	default:
+		return "p.id"
+	}
+}
+
+// Subquery jumlah terjual (order batal/refund gak dihitung)
+func soldJoin() (string, []interface{}) {
+	return `LEFT JOIN (
+			SELECT oi.product_id, SUM(oi.quantity) AS sold
+			FROM order_items oi JOIN orders o ON o.id = oi.order_id
+			WHERE o.status NOT IN (?, ?)
+			GROUP BY oi.product_id
+		) s ON s.product_id = p.id`, []interface{}{orders.StatusCancelled, orders.StatusRefunded}
+}
+
+// whereClause bikin filter (tanpa cursor) + argumennya.
+func (q ProductQuery) whereClause() (string, []interface{}) {
+	conds := []string{"1=1"}
+	var args []interface{}
+
+	if q.Search != "" {
+		like := "%" + q.Search + "%"
+		conds = append(conds, "(p.name LIKE ? OR p.description LIKE ?)")
+		args = append(args, like, like)
+	}
+	if len(q.Statuses) > 0 {
+		conds = append(conds, "p.status IN ("+strings.TrimSuffix(strings.Repeat("?,", len(q.Statuses)), ",")+")")
+		for _, s := range q.Statuses {
+			args = append(args, s)
+		}
+	}
+	if len(q.CategoryIDs) > 0 {
+		conds = append(conds, "p.category_id IN ("+strings.TrimSuffix(strings.Repeat("?,", len(q.CategoryIDs)), ",")+")")
+		for _, id := range q.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if q.MinPrice > 0 {
+		conds = append(conds, "p.price >= ?")
+		args = append(args, q.MinPrice)
+	}
+	if q.MaxPrice > 0 {
+		conds = append(conds, "p.price <= ?")
+		args = append(args, q.MaxPrice)
+	}
+	if q.InStock {
+		conds = append(conds, "p.stock > 0")
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// listSQL bikin query halaman produk. Ambil limit+1 baris buat tahu masih ada halaman berikutnya.
+func (q ProductQuery) listSQL() (string, []interface{}) {
+	join, args := soldJoin()
+	where, whereArgs := q.whereClause()
+	args = append(args, whereArgs...)
+
+	expr := q.sortExpr()
+	asc := q.Sort == "price_asc"
+
+	if c := q.Cursor; c != nil {
+		if q.Sort == "newest" {
+			where += " AND p.id < ?"
+			args = append(args, c.ID)
+		} else if asc {
+			where += " AND (" + expr + " > ? OR (" + expr + " = ? AND p.id > ?))"
+			args = append(args, c.Value, c.Value, c.ID)
+		} else {
+			where += " AND (" + expr + " < ? OR (" + expr + " = ? AND p.id < ?))"
+			args = append(args, c.Value, c.Value, c.ID)
+		}
+	}
+
+	order := expr + " DESC, p.id DESC"
+	if q.Sort == "newest" {
+		order = "p.id DESC"
+	} else if asc {
+		order = expr + " ASC, p.id ASC"
+	}
+
+	query := `SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name, p.description, p.image_url, p.status, p.deleted_at, ` + expr + `
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id ` + join + `
+		WHERE ` + where + `
+		ORDER BY ` + order + `
+		LIMIT ?`
+	args = append(args, q.Limit+1)
+	return query, args
+}
+
+func (q ProductQuery) countSQL() (string, []interface{}) {
+	where, args := q.whereClause()
+	return "SELECT COUNT(*) FROM products p WHERE " + where, args
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseProductQuery(t *testing.T) {
+	priceCursor := encodeCursor(productCursor{Sort: "price_asc", Value: 50000, ID: 7})
+
+	cases := []struct {
+		query   string
+		wantErr bool
+		check   func(ProductQuery) bool
+	}{
+		{"", false, func(q ProductQuery) bool {
+			return q.Sort == "newest" && q.Limit == defaultProductLimit && q.Cursor == nil
+		}},
+		{"q=+lipstik+&category=+makeup+", false, func(q ProductQuery) bool {
+			return q.Search == "lipstik" && q.Category == "makeup"
+		}},
+		{"sort=price_desc&min_price=10000&max_price=250000.5&in_stock=true", false, func(q ProductQuery) bool {
+			return q.Sort == "price_desc" && q.MinPrice == 10000 && q.MaxPrice == 250000.5 && q.InStock
+		}},
+		{"limit=1", false, func(q ProductQuery) bool { return q.Limit == 1 }},
+		{"limit=5000", false, func(q ProductQuery) bool { return q.Limit == maxProductLimit }},
+		{"sort=price_asc&cursor=" + priceCursor, false, func(q ProductQuery) bool {
+			return q.Cursor != nil && *q.Cursor == productCursor{Sort: "price_asc", Value: 50000, ID: 7}
+		}},
+
+		{"sort=termurah", true, nil},
+		{"min_price=-1", true, nil},
+		{"min_price=murah", true, nil},
+		{"max_price=-5", true, nil},
+		{"in_stock=ada", true, nil},
+		{"limit=0", true, nil},
+		{"limit=-3", true, nil},
+		{"limit=sepuluh", true, nil},
+		{"cursor=%21%21", true, nil},
+		// Cursor dari urutan harga gak bisa dipakai buat urutan terbaru
+		{"sort=newest&cursor=" + priceCursor, true, nil},
+		{"cursor=" + priceCursor, true, nil},
+	}
+
+	for _, c := range cases {
+		v, err := url.ParseQuery(c.query)
+		if err != nil {
+			t.Fatalf("%q: %v", c.query, err)
+		}
+		q, err := parseProductQuery(v)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q harusnya ditolak, dapat %+v", c.query, q)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q ditolak: %v", c.query, err)
+			continue
+		}
+		if !c.check(q) {
+			t.Errorf("%q = %+v", c.query, q)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, c := range []productCursor{
+		{Sort: "newest", ID: 42},
+		{Sort: "price_desc", Value: 129999.99, ID: 3},
+		{Sort: "best_selling", Value: 0, ID: 1},
+	} {
+		got, err := decodeCursor(encodeCursor(c))
+		if err != nil || *got != c {
+			t.Errorf("round trip %+v = %+v, %v", c, got, err)
+		}
+	}
+
+	for _, bad := range []string{
+		"bukan-base64!",
+		encodeCursor(productCursor{Sort: "newest"}),         // ID wajib > 0
+		encodeCursor(productCursor{Sort: "newest", ID: -4}), // ID minus
+		"eyJzIjoibmV3ZXN0Ig",                                // JSON kepotong
+		base64.RawURLEncoding.EncodeToString([]byte("bukan json")),
+	} {
+		if _, err := decodeCursor(bad); err != errBadCursor {
+			t.Errorf("decodeCursor(%q) = %v, mau errBadCursor", bad, err)
+		}
+	}
+}
+
+func TestListSQL(t *testing.T) {
+	q := ProductQuery{Sort: "price_asc", Limit: 20, MinPrice: 1000, Statuses: []string{ProductActive}}
+	query, args := q.listSQL()
+	if !strings.Contains(query, "ORDER BY p.price ASC, p.id ASC") {
+		t.Errorf("urutan price_asc salah:\n%s", query)
+	}
+	if args[len(args)-1] != 21 {
+		t.Errorf("LIMIT = %v, mau limit+1 = 21", args[len(args)-1])
+	}
+	if strings.Count(query, "?") != len(args) {
+		t.Errorf("jumlah placeholder %d != jumlah argumen %d", strings.Count(query, "?"), len(args))
+	}
+
+	// Cursor halaman berikutnya: nilai urut lebih besar, atau sama dengan ID lebih besar
+	q.Cursor = &productCursor{Sort: "price_asc", Value: 50000, ID: 9}
+	query, args = q.listSQL()
+	if !strings.Contains(query, "AND (p.price > ? OR (p.price = ? AND p.id > ?))") {
+		t.Errorf("kondisi cursor price_asc salah:\n%s", query)
+	}
+	if !slices.Equal(args[len(args)-4:], []interface{}{50000.0, 50000.0, 9, 21}) {
+		t.Errorf("argumen cursor = %v", args[len(args)-4:])
+	}
+
+	q = ProductQuery{Sort: "best_selling", Limit: 10, Cursor: &productCursor{Sort: "best_selling", Value: 12, ID: 4}}
+	query, _ = q.listSQL()
+	if !strings.Contains(query, "ORDER BY COALESCE(s.sold, 0) DESC, p.id DESC") ||
+		!strings.Contains(query, "COALESCE(s.sold, 0) < ? OR (COALESCE(s.sold, 0) = ? AND p.id < ?)") {
+		t.Errorf("query best_selling salah:\n%s", query)
+	}
+
+	q = ProductQuery{Sort: "newest", Limit: 10, Cursor: &productCursor{Sort: "newest", ID: 30}}
+	query, _ = q.listSQL()
+	if !strings.Contains(query, "AND p.id < ?") || !strings.Contains(query, "ORDER BY p.id DESC") {
+		t.Errorf("query newest salah:\n%s", query)
+	}
+}
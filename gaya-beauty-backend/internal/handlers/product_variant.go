@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gaya-beauty-backend/internal/inventory"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// --- VARIAN PRODUK (shade, ukuran, dst.) ---
+// Produk yang punya varian: stok & harga yang dipakai checkout ada di varian,
+// products.stock cuma jumlah stok semua variannya (biar filter in_stock tetap jalan).
+type ProductVariant struct {
+	ID            int               `json:"id"`
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"options"`        // {"shade": "02 Ivory", "size": "30ml"}
+	Price         float64           `json:"price"`          // Harga efektif (read-only)
+	PriceOverride *float64          `json:"price_override"` // null = ikut harga produk
+	Stock         int               `json:"stock"`
+	SwatchURL     string            `json:"swatch_url"`
+	SortOrder     int               `json:"sort_order"`
+}
+
+// Label buat ditampilin di keranjang / order: "02 Ivory / 30ml"
+func variantLabel(options map[string]string) string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, options[k])
+	}
+	return strings.Join(values, " / ")
+}
+
+func variantLabelJSON(raw sql.NullString) string {
+	if !raw.Valid {
+		return ""
+	}
+	var options map[string]string
+	if err := json.Unmarshal([]byte(raw.String), &options); err != nil {
+		return ""
+	}
+	return variantLabel(options)
+}
+
+// Dipakai bareng *sql.DB maupun *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadVariants ambil varian beberapa produk sekaligus (key = product_id).
+func loadVariants(q queryer, productIDs []int) (map[int][]ProductVariant, error) {
+	result := make(map[int][]ProductVariant)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	rows, err := q.Query(`SELECT v.product_id, v.id, v.sku, v.options, COALESCE(v.price, p.price), v.price, v.stock, v.swatch_url, v.sort_order
+		FROM product_variants v JOIN products p ON p.id = v.product_id
+		WHERE v.product_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",")+`)
+		ORDER BY v.product_id, v.sort_order, v.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var v ProductVariant
+		var options string
+		var override sql.NullFloat64
+		var swatch sql.NullString
+		if err := rows.Scan(&productID, &v.ID, &v.SKU, &options, &v.Price, &override, &v.Stock, &swatch, &v.SortOrder); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(options), &v.Options); err != nil {
+			v.Options = map[string]string{}
+		}
+		if override.Valid {
+			v.PriceOverride = &override.Float64
+		}
+		v.SwatchURL = swatch.String
+		result[productID] = append(result[productID], v)
+	}
+	return result, rows.Err()
+}
+
+// attachVariants isi field Variants tiap produk (array kosong kalau gak punya varian).
+func attachVariants(q queryer, products []Product) error {
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	variants, err := loadVariants(q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].Variants = variants[products[i].ID]
+		if products[i].Variants == nil {
+			products[i].Variants = []ProductVariant{}
+		}
+	}
+	return nil
+}
+
+// Error validasi varian (pesannya aman ditampilin ke admin)
+type variantError string
+
+func (e variantError) Error() string { return string(e) }
+
+func writeVariantError(w http.ResponseWriter, err error) {
+	if ve, ok := err.(variantError); ok {
+		http.Error(w, string(ve), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "Gagal simpan varian produk", http.StatusInternalServerError)
+}
+
+func validateVariants(variants []ProductVariant) error {
+	seen := map[string]bool{}
+	for i := range variants {
+		v := &variants[i]
+		v.SKU = strings.TrimSpace(v.SKU)
+		if v.SKU == "" {
+			return variantError("SKU varian wajib diisi")
+		}
+		if seen[strings.ToLower(v.SKU)] {
+			return variantError("SKU varian dobel: " + v.SKU)
+		}
+		seen[strings.ToLower(v.SKU)] = true
+
+		if len(v.Options) == 0 {
+			return variantError("Varian " + v.SKU + " wajib punya minimal satu opsi (misal shade/size)")
+		}
+		if v.Stock < 0 {
+			return variantError("Stok varian " + v.SKU + " tidak boleh minus")
+		}
+		if v.PriceOverride != nil && *v.PriceOverride <= 0 {
+			return variantError("Harga varian " + v.SKU + " harus lebih dari 0")
+		}
+	}
+	return nil
+}
+
+// saveVariants ganti daftar varian produk sama isi body: yang ada ID-nya diupdate,
+// yang baru di-insert, yang gak dikirim lagi dihapus. Habis itu products.stock disamain.
+// Semua perubahan stok varian dicatat di ledger atas nama userID.
+func saveVariants(tx *sql.Tx, productID int, variants []ProductVariant, userID int) error {
+	if err := validateVariants(variants); err != nil {
+		return err
+	}
+
+	existing, err := loadVariants(tx, []int{productID})
+	if err != nil {
+		return err
+	}
+	owned := map[int]bool{}
+	oldStock := map[int]int{}
+	for _, v := range existing[productID] {
+		owned[v.ID] = true
+		oldStock[v.ID] = v.Stock
+	}
+	hadVariants := len(owned) > 0
+
+	movement := func(variantID, qty int, reason inventory.Reason, note string) error {
+		return inventory.Record(tx, inventory.Movement{
+			ProductID: productID, VariantID: variantID, Quantity: qty,
+			Reason: reason, UserID: userID, Note: note,
+		})
+	}
+
+	// Produk yang baru pertama kali punya varian: stok level produk ditutup ke 0,
+	// mulai sekarang stoknya dicatat per varian
+	if !hadVariants && len(variants) > 0 {
+		var stock int
+		if err := tx.QueryRow("SELECT stock FROM products WHERE id = ?", productID).Scan(&stock); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE products SET stock = 0 WHERE id = ?", productID); err != nil {
+			return err
+		}
+		if err := movement(0, -stock, inventory.ReasonAdjustment, "Stok dipindah ke varian"); err != nil {
+			return err
+		}
+	}
+
+	// SKU unik se-toko, bukan cuma se-produk
+	for _, v := range variants {
+		var otherID int
+		err := tx.QueryRow("SELECT id FROM product_variants WHERE sku = ? AND product_id <> ?", v.SKU, productID).Scan(&otherID)
+		if err == nil {
+			return variantError("SKU " + v.SKU + " sudah dipakai produk lain")
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	// Hapus dulu yang gak dikirim lagi (biar SKU-nya bisa dipakai ulang di baris baru)
+	keep := map[int]bool{}
+	for _, v := range variants {
+		if v.ID != 0 {
+			if !owned[v.ID] {
+				return variantError("Varian tidak ditemukan di produk ini")
+			}
+			keep[v.ID] = true
+		}
+	}
+	for id := range owned {
+		if keep[id] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM carts WHERE variant_id = ?", id); err != nil {
+			return err
+		}
+		// Stoknya dinolkan + dicatat dulu sebelum barisnya hilang
+		if _, err := tx.Exec("UPDATE product_variants SET stock = 0 WHERE id = ?", id); err != nil {
+			return err
+		}
+		if err := movement(id, -oldStock[id], inventory.ReasonAdjustment, "Varian dihapus"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM product_variants WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range variants {
+		options, _ := json.Marshal(v.Options)
+		sortOrder := v.SortOrder
+		if sortOrder == 0 {
+			sortOrder = i
+		}
+		var swatch interface{}
+		if v.SwatchURL != "" {
+			swatch = v.SwatchURL
+		}
+
+		if v.ID != 0 {
+			_, err = tx.Exec(`UPDATE product_variants SET sku=?, options=?, price=?, stock=?, swatch_url=?, sort_order=?
+				WHERE id=? AND product_id=?`, v.SKU, string(options), v.PriceOverride, v.Stock, swatch, sortOrder, v.ID, productID)
+			if err == nil {
+				err = movement(v.ID, v.Stock-oldStock[v.ID], inventory.ReasonAdjustment, "Edit varian")
+			}
+		} else {
+			var res sql.Result
+			res, err = tx.Exec(`INSERT INTO product_variants (product_id, sku, options, price, stock, swatch_url, sort_order)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`, productID, v.SKU, string(options), v.PriceOverride, v.Stock, swatch, sortOrder)
+			if err == nil {
+				newID, _ := res.LastInsertId()
+				err = movement(int(newID), v.Stock, inventory.ReasonInitial, "Varian baru")
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Semua varian dihapus: stok terakhir di products.stock jadi stok level produk lagi
+	if hadVariants && len(variants) == 0 {
+		var stock int
+		if err := tx.QueryRow("SELECT stock FROM products WHERE id = ?", productID).Scan(&stock); err != nil {
+			return err
+		}
+		if err := movement(0, stock, inventory.ReasonAdjustment, "Stok varian digabung ke produk"); err != nil {
+			return err
+		}
+	}
+
+	// updated_at disentuh biar ETag/Last-Modified detail produk ikut berubah
+	if _, err := tx.Exec("UPDATE products SET updated_at = NOW() WHERE id = ?", productID); err != nil {
+		return err
+	}
+	return syncProductStock(tx, productID)
+}
+
+// syncProductStock samain products.stock = total stok varian (kalau produknya punya varian).
+func syncProductStock(tx *sql.Tx, productID int) error {
+	_, err := tx.Exec(`UPDATE products SET stock = (SELECT SUM(stock) FROM product_variants WHERE product_id = ?)
+		WHERE id = ? AND EXISTS (SELECT 1 FROM product_variants WHERE product_id = ?)`,
+		productID, productID, productID)
+	return err
+}
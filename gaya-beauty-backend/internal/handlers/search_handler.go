@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gaya-beauty-backend/internal/search"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Satu hasil pencarian = data produk + skor relevansi
+type SearchHit struct {
+	Product
+	Score float64 `json:"score"`
+}
+
+type SearchResponse struct {
+	Query string      `json:"query"`
+	Items []SearchHit `json:"items"`
+	Total int         `json:"total"` // Semua produk yang cocok, bukan cuma yang di halaman ini
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// searchDocument ubah Product jadi dokumen index pencarian
+func searchDocument(p Product) search.Document {
+	return search.Document{ID: p.ID, Name: p.Name, Category: p.Category, Description: p.Description}
+}
+
+// syncSearchIndex masukin produk ke index kalau active, selain itu dikeluarin
+func syncSearchIndex(idx *search.Index, p Product) {
+	if p.Status == ProductActive {
+		idx.Upsert(searchDocument(p))
+	} else {
+		idx.Remove(p.ID)
+	}
+}
+
+// =========================================================
+// PENCARIAN PRODUK (PUBLIC)
+// =========================================================
+// GET /search?q=...&limit=...
+// Beda sama filter q di /products (LIKE biasa), ini pakai index full-text di memori:
+// kata dasar bahasa Indonesia ("pelembap" = "melembapkan"), tahan typo, diurut relevansi.
+func HandleSearch(db *sql.DB, idx *search.Index) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			http.Error(w, "Parameter q wajib diisi", http.StatusBadRequest)
+			return
+		}
+
+		limit := defaultSearchLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "limit harus angka positif", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxSearchLimit)
+		}
+
+		// Ambil semua yang cocok dulu (index toh sudah ngurutin semuanya) biar total-nya beneran
+		results := idx.Search(query, 0)
+		total := len(results)
+		if len(results) > limit {
+			results = results[:limit]
+		}
+		resp := SearchResponse{Query: query, Items: []SearchHit{}}
+		if len(results) == 0 {
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+
+		// Ambil data produk terbaru dari DB (index cuma nyimpen ID & kata)
+		ids := make([]any, len(results))
+		placeholders := make([]string, len(results))
+		for i, res := range results {
+			ids[i] = res.ID
+			placeholders[i] = "?"
+		}
+		rows, err := db.Query(`SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name, p.description, p.image_url, p.status
+			FROM products p LEFT JOIN categories c ON c.id = p.category_id
+			WHERE p.id IN (`+strings.Join(placeholders, ",")+`) AND p.status = ?`, append(ids, ProductActive)...)
+		if err != nil {
+			http.Error(w, "Gagal ambil data produk", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		products := make(map[int]Product)
+		for rows.Next() {
+			var p Product
+			var categoryID sql.NullInt64
+			var category, desc, img sql.NullString
+			if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &category, &desc, &img, &p.Status); err != nil {
+				continue
+			}
+			p.setCategory(categoryID, category)
+			p.Description = desc.String
+			if img.Valid {
+				p.ImageURL = img.String
+			} else {
+				p.ImageURL = "https://placehold.co/400?text=No+Image"
+			}
+			products[p.ID] = p
+		}
+
+		// Urutan tetap ikut skor relevansi dari index
+		var found []Product
+		var scores []float64
+		for _, res := range results {
+			if p, ok := products[res.ID]; ok {
+				found = append(found, p)
+				scores = append(scores, res.Score)
+			}
+		}
+		if err := attachProductExtras(db, found); err != nil {
+			http.Error(w, "Gagal ambil varian produk", http.StatusInternalServerError)
+			return
+		}
+		for i, p := range found {
+			resp.Items = append(resp.Items, SearchHit{Product: p, Score: scores[i]})
+		}
+		// Hit yang produknya sudah gak ada di DB (index belum sinkron) gak ikut dihitung
+		resp.Total = total - (len(results) - len(found))
+		json.NewEncoder(w).Encode(resp)
+	}
+}
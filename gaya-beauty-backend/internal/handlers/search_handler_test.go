@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gaya-beauty-backend/internal/search"
+	"gaya-beauty-backend/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// total = semua produk yang cocok, walau yang dikirim cuma sebanyak limit
+func TestSearchTotalCountsAllMatches(t *testing.T) {
+	db := testdb.Open(t)
+	idx := search.NewIndex()
+	for _, name := range []string{"Serum Pelembap Wajah", "Krim Pelembap Malam", "Pelembap Bibir"} {
+		id := testdb.Product(t, db, name, 50000, 5)
+		idx.Upsert(search.Document{ID: id, Name: name})
+	}
+
+	rec := httptest.NewRecorder()
+	HandleSearch(db, idx)(rec, httptest.NewRequest(http.MethodGet, "/search?q=pelembap&limit=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Items) != 2 {
+		t.Errorf("jumlah item = %d, mau 2 (limit)", len(resp.Items))
+	}
+	if resp.Total != 3 {
+		t.Errorf("total = %d, mau 3 (semua yang cocok)", resp.Total)
+	}
+}
@@ -3,23 +3,33 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"gaya-beauty-backend/internal/alerts"
+	"gaya-beauty-backend/internal/inventory"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/payment"
+	"gaya-beauty-backend/internal/transfer"
 	"log"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // === STRUKTUR DATA (Disesuaikan Frontend) ===
+// Identitas customer gak diambil dari sini, tapi dari token (CustomerAuthMiddleware).
+// Barangnya selalu dari keranjang yang tersimpan di server (tabel carts), bukan dari body.
 type CheckoutRequest struct {
-	CustomerID    int            `json:"customer_id"`
-	CustomerName  string         `json:"customer_name"` // BARU
-	PaymentMethod string         `json:"payment_method"` // BARU
-	TotalPrice    float64        `json:"total_price"`
-	CartItems     []CartItemData `json:"cart_items"`
+	PaymentMethod string  `json:"payment_method"` // BARU
+	TotalPrice    float64 `json:"total_price"`
 }
 
+// Satu baris keranjang tersimpan yang mau di-checkout
 type CartItemData struct {
-	ProductID int     `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID int
+	VariantID int // 0 = produk tanpa varian
+	Quantity  int
 }
 
 type OrderResponse struct {
@@ -34,22 +44,296 @@ type OrderResponse struct {
 
 type OrderItemResp struct {
 	ProductName string `json:"product_name"`
+	Variant     string `json:"variant,omitempty"`
 	Quantity    int    `json:"quantity"`
 }
 
+// Selisih maksimal antara total yang dilihat customer dan total di database
+// sebelum checkout ditolak (toleransi pembulatan desimal).
+const priceTolerance = 0.01
+
+// Baris order hasil hitungan server (harga asli dari tabel products)
+type pricedItem struct {
+	ProductID   int
+	ProductName string // Disalin ke order_items biar riwayat order gak tergantung produknya
+	VariantID   int
+	Quantity    int
+	Price       float64
+	LineTotal   float64
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Detail item yang stoknya kurang (buat respon 409)
+type StockShortage struct {
+	ProductID int `json:"product_id"`
+	VariantID int `json:"variant_id,omitempty"`
+	Requested int `json:"requested"`
+	Available int `json:"available"`
+}
+
+// Data produk yang udah dikunci (SELECT ... FOR UPDATE) selama transaksi checkout
+type lockedProduct struct {
+	Name        string
+	Price       float64
+	Stock       int
+	Status      string
+	HasVariants bool
+}
+
+// Varian yang udah dikunci (harga sudah termasuk fallback ke harga produk)
+type lockedVariant struct {
+	ProductID int
+	Price     float64
+	Stock     int
+}
+
+// Semua baris yang dikunci checkout: produk + varian yang dibeli
+type lockedStock struct {
+	Products map[int]lockedProduct
+	Variants map[int]lockedVariant
+}
+
+// Satu "unit stok": produk tanpa varian (VariantID 0) atau satu varian
+type stockKey struct {
+	ProductID int
+	VariantID int
+}
+
+// price & stock unit yang dibeli item ini
+func (l lockedStock) unit(item CartItemData) (float64, int) {
+	if item.VariantID != 0 {
+		v := l.Variants[item.VariantID]
+		return v.Price, v.Stock
+	}
+	p := l.Products[item.ProductID]
+	return p.Price, p.Stock
+}
+
+var (
+	errProductNotFound = errors.New("produk tidak ditemukan")
+	errProductInactive = errors.New("produk sudah tidak dijual")
+	errVariantNotFound = errors.New("varian produk tidak ditemukan")
+	errVariantRequired = errors.New("pilih varian produk dulu")
+)
+
+// lockProducts kunci baris produk yang dibeli sampai transaksi selesai.
+// Urutan ID selalu naik biar dua checkout barengan gak saling deadlock.
+func lockProducts(tx *sql.Tx, items []CartItemData) (map[int]lockedProduct, error) {
+	var ids []int
+	seen := map[int]bool{}
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			ids = append(ids, item.ProductID)
+		}
+	}
+	sort.Ints(ids)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := tx.Query(`SELECT id, name, price, stock, status, EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+		FROM products WHERE id IN (`+placeholders+") ORDER BY id FOR UPDATE", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locked := map[int]lockedProduct{}
+	for rows.Next() {
+		var id int
+		var p lockedProduct
+		if err := rows.Scan(&id, &p.Name, &p.Price, &p.Stock, &p.Status, &p.HasVariants); err != nil {
+			return nil, err
+		}
+		locked[id] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(locked) != len(ids) {
+		return nil, errProductNotFound
+	}
+	// Draft / arsip gak bisa dibeli walau masih nyangkut di keranjang
+	for _, p := range locked {
+		if p.Status != ProductActive {
+			return nil, errProductInactive
+		}
+	}
+	return locked, nil
+}
+
+// lockVariants kunci baris varian yang dibeli (dipanggil setelah lockProducts,
+// urutannya sama kayak restoreStock: produk dulu baru varian).
+func lockVariants(tx *sql.Tx, products map[int]lockedProduct, items []CartItemData) (map[int]lockedVariant, error) {
+	var ids []interface{}
+	seen := map[int]bool{}
+	for _, item := range items {
+		if item.VariantID == 0 {
+			if products[item.ProductID].HasVariants {
+				return nil, errVariantRequired
+			}
+			continue
+		}
+		if !seen[item.VariantID] {
+			seen[item.VariantID] = true
+			ids = append(ids, item.VariantID)
+		}
+	}
+
+	locked := map[int]lockedVariant{}
+	if len(ids) == 0 {
+		return locked, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := tx.Query(`SELECT v.id, v.product_id, COALESCE(v.price, p.price), v.stock
+		FROM product_variants v JOIN products p ON p.id = v.product_id
+		WHERE v.id IN (`+placeholders+`) ORDER BY v.id FOR UPDATE`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var v lockedVariant
+		if err := rows.Scan(&id, &v.ProductID, &v.Price, &v.Stock); err != nil {
+			return nil, err
+		}
+		locked[id] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Varian harus ada & memang punya produk yang dikirim
+	for _, item := range items {
+		if item.VariantID == 0 {
+			continue
+		}
+		if v, ok := locked[item.VariantID]; !ok || v.ProductID != item.ProductID {
+			return nil, errVariantNotFound
+		}
+	}
+	return locked, nil
+}
+
+// capSellable batasi stok yang bisa dibeli ke batch yang belum kadaluarsa
+// (stok di products/varian masih ngitung batch kadaluarsa yang belum sempat dikeluarin).
+func capSellable(tx *sql.Tx, locked lockedStock) error {
+	ids := make([]int, 0, len(locked.Products))
+	for id := range locked.Products {
+		ids = append(ids, id)
+	}
+	sellable, err := inventory.Sellable(tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for id, p := range locked.Products {
+		if !p.HasVariants {
+			p.Stock = min(p.Stock, sellable[inventory.Unit{ProductID: id}])
+			locked.Products[id] = p
+		}
+	}
+	for id, v := range locked.Variants {
+		v.Stock = min(v.Stock, sellable[inventory.Unit{ProductID: v.ProductID, VariantID: id}])
+		locked.Variants[id] = v
+	}
+	return nil
+}
+
+// priceCartItems hitung subtotal & total pakai harga resmi dari database.
+func priceCartItems(locked lockedStock, items []CartItemData) ([]pricedItem, float64) {
+	var priced []pricedItem
+	var total float64
+
+	for _, item := range items {
+		price, _ := locked.unit(item)
+		lineTotal := roundMoney(price * float64(item.Quantity))
+		priced = append(priced, pricedItem{
+			ProductID:   item.ProductID,
+			ProductName: locked.Products[item.ProductID].Name,
+			VariantID:   item.VariantID,
+			Quantity:    item.Quantity,
+			Price:       price,
+			LineTotal:   lineTotal,
+		})
+		total += lineTotal
+	}
+
+	return priced, roundMoney(total)
+}
+
+// checkStock bandingin total qty per produk/varian sama stok yang udah dikunci.
+// Unit yang sama bisa muncul di beberapa baris keranjang, jadi dijumlah dulu.
+func checkStock(locked lockedStock, items []CartItemData) []StockShortage {
+	requested := map[stockKey]int{}
+	first := map[stockKey]CartItemData{}
+	var order []stockKey
+	for _, item := range items {
+		key := stockKey{item.ProductID, item.VariantID}
+		if _, ok := requested[key]; !ok {
+			order = append(order, key)
+			first[key] = item
+		}
+		requested[key] += item.Quantity
+	}
+
+	var shortages []StockShortage
+	for _, key := range order {
+		if _, available := locked.unit(first[key]); requested[key] > available {
+			shortages = append(shortages, StockShortage{
+				ProductID: key.ProductID,
+				VariantID: key.VariantID,
+				Requested: requested[key],
+				Available: available,
+			})
+		}
+	}
+	return shortages
+}
+
+func writeJSONError(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
 // =========================================================
 // 1. HANDLE CHECKOUT (CUSTOMER BELI)
 // =========================================================
-func HandleCheckout(db *sql.DB) http.HandlerFunc {
+func HandleCheckout(db *sql.DB, lowStock *alerts.LowStockChecker, payments payment.Provider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		
+
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+
 		// Decode Data
 		var req CheckoutRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Data tidak lengkap", http.StatusBadRequest)
 			return
 		}
+		if payment.IsOnline(req.PaymentMethod) {
+			if payments == nil {
+				http.Error(w, "Pembayaran online belum tersedia, pilih metode lain", http.StatusBadRequest)
+				return
+			}
+			req.PaymentMethod = payment.MethodOnline
+		}
 
 		// Mulai Transaksi Database
 		tx, err := db.Begin()
@@ -58,12 +342,94 @@ func HandleCheckout(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		// Barang yang dibeli = isi keranjang tersimpan (udah lewat pengecekan /cart)
+		cartItems, err := loadStoredCart(tx, customer.ID)
+		if err != nil {
+			tx.Rollback()
+			http.Error(w, "Gagal ambil keranjang", http.StatusInternalServerError)
+			return
+		}
+
+		if len(cartItems) == 0 {
+			tx.Rollback()
+			http.Error(w, "Keranjang kosong", http.StatusBadRequest)
+			return
+		}
+		for _, item := range cartItems {
+			if item.ProductID <= 0 || item.VariantID < 0 || item.Quantity <= 0 {
+				tx.Rollback()
+				http.Error(w, "Item keranjang tidak valid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Nama customer diambil dari database, bukan dari body
+		var customerName string
+		if err := tx.QueryRow("SELECT full_name FROM customers WHERE id = ?", customer.ID).Scan(&customerName); err != nil {
+			tx.Rollback()
+			http.Error(w, "Akun customer tidak ditemukan", http.StatusUnauthorized)
+			return
+		}
+
+		// KUNCI BARIS PRODUK (biar checkout barengan gak oversell)
+		var locked lockedStock
+		locked.Products, err = lockProducts(tx, cartItems)
+		if err == nil {
+			locked.Variants, err = lockVariants(tx, locked.Products, cartItems)
+		}
+		if err == nil {
+			err = capSellable(tx, locked)
+		}
+		if err != nil {
+			tx.Rollback()
+			switch err {
+			case errProductNotFound:
+				http.Error(w, "Produk tidak ditemukan", http.StatusBadRequest)
+			case errProductInactive:
+				http.Error(w, "Ada produk di keranjang yang sudah tidak dijual", http.StatusConflict)
+			case errVariantNotFound:
+				http.Error(w, "Varian produk tidak ditemukan", http.StatusBadRequest)
+			case errVariantRequired:
+				http.Error(w, "Pilih varian produk dulu", http.StatusBadRequest)
+			default:
+				log.Println("Gagal kunci produk:", err)
+				http.Error(w, "Server Error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// HITUNG ULANG HARGA DI SERVER (JANGAN PERCAYA FRONTEND)
+		items, totalPrice := priceCartItems(locked, cartItems)
+
+		// Total yang dilihat customer dicek biar gak kaget harganya berubah (0 = gak dikirim)
+		if req.TotalPrice != 0 && math.Abs(req.TotalPrice-totalPrice) > priceTolerance {
+			tx.Rollback()
+			log.Printf("Checkout ditolak, harga tidak cocok (customer %d): total client %.2f vs server %.2f",
+				customer.ID, req.TotalPrice, totalPrice)
+			writeJSONError(w, http.StatusConflict, map[string]interface{}{
+				"error":        "Harga produk sudah berubah, silakan muat ulang keranjang",
+				"client_total": req.TotalPrice,
+				"server_total": totalPrice,
+			})
+			return
+		}
+
+		// CEK STOK (semua item harus cukup, kalau gak batal semua)
+		if shortages := checkStock(locked, cartItems); len(shortages) > 0 {
+			tx.Rollback()
+			writeJSONError(w, http.StatusConflict, map[string]interface{}{
+				"error": "Stok tidak mencukupi",
+				"items": shortages,
+			})
+			return
+		}
+
 		// INSERT KE ORDERS (LENGKAP)
 		res, err := tx.Exec(`
 			INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status, created_at) 
-			VALUES (?, ?, ?, ?, 'Pending', NOW())`,
-			req.CustomerID, req.CustomerName, req.PaymentMethod, req.TotalPrice)
-		
+			VALUES (?, ?, ?, ?, ?, NOW())`,
+			customer.ID, customerName, req.PaymentMethod, totalPrice, orders.StatusPending)
+
 		if err != nil {
 			tx.Rollback()
 			log.Println("Gagal Insert Order:", err)
@@ -73,32 +439,133 @@ func HandleCheckout(db *sql.DB) http.HandlerFunc {
 
 		orderID, _ := res.LastInsertId()
 
+		// Catat status awal di riwayat
+		actor := orders.Actor{Type: orders.ActorCustomer, ID: customer.ID}
+		if err := orders.RecordCreated(tx, int(orderID), orders.StatusPending, actor); err != nil {
+			tx.Rollback()
+			http.Error(w, "Gagal membuat pesanan", http.StatusInternalServerError)
+			return
+		}
+
+		// Transfer manual: nominal = total + kode unik, biar transfer yang masuk gampang dicocokin
+		var transferCode *transfer.Code
+		if transfer.IsBankTransfer(req.PaymentMethod) {
+			code, err := transfer.AssignUniqueCode(tx, int(orderID), totalPrice)
+			if err != nil {
+				tx.Rollback()
+				if err == transfer.ErrNoUniqueCode {
+					http.Error(w, "Lagi banyak pesanan transfer, coba lagi sebentar", http.StatusServiceUnavailable)
+					return
+				}
+				log.Println("Gagal bikin kode unik transfer:", err)
+				http.Error(w, "Gagal membuat pesanan", http.StatusInternalServerError)
+				return
+			}
+			transferCode = &code
+		}
+
 		// LOOPING ITEMS
-		for _, item := range req.CartItems {
-			// Insert Item
-			_, err := tx.Exec(`INSERT INTO order_items (order_id, product_id, quantity, price) VALUES (?, ?, ?, ?)`,
-				orderID, item.ProductID, item.Quantity, item.Price)
-			
+		for _, item := range items {
+			// Insert Item (harga dari database, bukan dari frontend)
+			var variantID interface{}
+			if item.VariantID != 0 {
+				variantID = item.VariantID
+			}
+			_, err := tx.Exec(`INSERT INTO order_items (order_id, product_id, variant_id, product_name, quantity, price) VALUES (?, ?, ?, ?, ?, ?)`,
+				orderID, item.ProductID, variantID, item.ProductName, item.Quantity, item.Price)
+
 			if err != nil {
 				tx.Rollback()
 				http.Error(w, "Gagal insert item", http.StatusInternalServerError)
 				return
 			}
 
-			// Potong Stok
-			_, err = tx.Exec(`UPDATE products SET stock = stock - ? WHERE id = ?`, item.Quantity, item.ProductID)
+			// Potong Stok (bersyarat, stok gak boleh minus)
+			res, err := tx.Exec(`UPDATE products SET stock = stock - ? WHERE id = ? AND stock >= ?`,
+				item.Quantity, item.ProductID, item.Quantity)
 			if err != nil {
 				tx.Rollback()
-				http.Error(w, "Stok habis", http.StatusInternalServerError)
+				http.Error(w, "Gagal potong stok", http.StatusInternalServerError)
+				return
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				tx.Rollback()
+				http.Error(w, "Stok habis", http.StatusConflict)
+				return
+			}
+
+			// Produk bervarian: stok varian juga dipotong (products.stock = total varian)
+			if item.VariantID != 0 {
+				res, err := tx.Exec(`UPDATE product_variants SET stock = stock - ? WHERE id = ? AND stock >= ?`,
+					item.Quantity, item.VariantID, item.Quantity)
+				if err != nil {
+					tx.Rollback()
+					http.Error(w, "Gagal potong stok", http.StatusInternalServerError)
+					return
+				}
+				if n, _ := res.RowsAffected(); n == 0 {
+					tx.Rollback()
+					http.Error(w, "Stok varian habis", http.StatusConflict)
+					return
+				}
+			}
+
+			// Catat di ledger stok (produk bervarian dicatat per varian).
+			// Batch yang dipakai diambil FEFO, yang kadaluarsa gak ikut.
+			err = inventory.Record(tx, inventory.Movement{
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Quantity:  -item.Quantity,
+				Reason:    inventory.ReasonSale,
+				OrderID:   int(orderID),
+			})
+			if err != nil {
+				tx.Rollback()
+				http.Error(w, "Gagal catat stok", http.StatusInternalServerError)
 				return
 			}
 		}
 
-		tx.Commit()
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "Checkout Berhasil!", 
-			"order_id": orderID,
-		})
+		// Keranjang tersimpan dikosongin setelah jadi pesanan
+		if _, err := tx.Exec("DELETE FROM carts WHERE customer_id = ?", customer.ID); err != nil {
+			tx.Rollback()
+			http.Error(w, "Gagal kosongin keranjang", http.StatusInternalServerError)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal menyimpan pesanan", http.StatusInternalServerError)
+			return
+		}
+
+		// Stok yang baru kepotong dicek di background (gak bikin customer nunggu)
+		productIDs := make([]int, 0, len(locked.Products))
+		for id := range locked.Products {
+			productIDs = append(productIDs, id)
+		}
+		lowStock.Trigger(productIDs...)
+
+		resp := map[string]interface{}{
+			"message":     "Checkout Berhasil!",
+			"order_id":    orderID,
+			"total_price": totalPrice,
+		}
+		if transferCode != nil {
+			resp["transfer"] = transferCode
+		}
+
+		// Bayar online: sesi bayar dibikin setelah order tersimpan (di luar transaksi).
+		// Kalau gateway lagi error, order tetap jadi dan bisa dibayar ulang lewat /my-orders/pay.
+		if req.PaymentMethod == payment.MethodOnline {
+			session, err := payment.StartSession(r.Context(), db, payments, int(orderID), customer.ID)
+			if err != nil {
+				log.Printf("Gagal bikin sesi bayar order %d: %v", orderID, err)
+				resp["payment_error"] = "Gagal menghubungi payment gateway, coba bayar lagi dari halaman pesanan"
+			} else {
+				resp["payment"] = session
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
 	}
 }
 
@@ -123,17 +590,20 @@ func HandleGetOrders(db *sql.DB) http.HandlerFunc {
 			var o OrderResponse
 			rows.Scan(&o.ID, &o.CustomerName, &o.PaymentMethod, &o.TotalPrice, &o.Status, &o.CreatedAt)
 
-			// AMBIL DETAIL ITEM BUAT TIAP ORDER (JOIN KE PRODUCTS BIAR DAPET NAMA)
+			// AMBIL DETAIL ITEM BUAT TIAP ORDER (nama dari salinan waktu checkout, order lama fallback ke products)
 			itemRows, _ := db.Query(`
-				SELECT p.name, oi.quantity 
+				SELECT COALESCE(oi.product_name, p.name), v.options, oi.quantity 
 				FROM order_items oi 
-				JOIN products p ON oi.product_id = p.id 
+				LEFT JOIN products p ON oi.product_id = p.id 
+				LEFT JOIN product_variants v ON v.id = oi.variant_id
 				WHERE oi.order_id = ?`, o.ID)
-			
+
 			var items []OrderItemResp
 			for itemRows.Next() {
 				var i OrderItemResp
-				itemRows.Scan(&i.ProductName, &i.Quantity)
+				var options sql.NullString
+				itemRows.Scan(&i.ProductName, &options, &i.Quantity)
+				i.Variant = variantLabelJSON(options)
 				items = append(items, i)
 			}
 			itemRows.Close()
@@ -142,11 +612,34 @@ func HandleGetOrders(db *sql.DB) http.HandlerFunc {
 			orders = append(orders, o)
 		}
 
-		if orders == nil { orders = []OrderResponse{} }
+		if orders == nil {
+			orders = []OrderResponse{}
+		}
 		json.NewEncoder(w).Encode(orders)
 	}
 }
 
+// writeTransitionError terjemahin error dari orders.Transition ke HTTP status.
+func writeTransitionError(w http.ResponseWriter, err error) {
+	var te *orders.TransitionError
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound):
+		http.Error(w, "Pesanan tidak ditemukan", http.StatusNotFound)
+	case errors.Is(err, orders.ErrUnknownStatus):
+		http.Error(w, "Status tidak dikenal", http.StatusBadRequest)
+	case errors.As(err, &te):
+		writeJSONError(w, http.StatusConflict, map[string]interface{}{
+			"error":   te.Error(),
+			"from":    te.From,
+			"to":      te.To,
+			"allowed": orders.NextStatuses(te.From),
+		})
+	default:
+		log.Println("Gagal ubah status order:", err)
+		http.Error(w, "Gagal update database", http.StatusInternalServerError)
+	}
+}
+
 // =========================================================
 // 3. UPDATE STATUS ORDER (ADMIN) - FITUR BARU
 // =========================================================
@@ -154,17 +647,33 @@ func HandleUpdateOrderStatus(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		user, _ := UserFromContext(r.Context())
+
 		var req struct {
 			OrderID int    `json:"order_id"`
 			Status  string `json:"status"`
+			Note    string `json:"note"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Data json error", http.StatusBadRequest)
 			return
 		}
 
-		_, err := db.Exec("UPDATE orders SET status = ? WHERE id = ?", req.Status, req.OrderID)
+		tx, err := db.Begin()
 		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		// Semua perubahan status lewat state machine (lihat internal/orders)
+		actor := orders.Actor{Type: orders.ActorAdmin, ID: user.ID}
+		if _, err := orders.Transition(tx, req.OrderID, orders.Status(req.Status), actor, req.Note); err != nil {
+			writeTransitionError(w, err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
 			http.Error(w, "Gagal update database", http.StatusInternalServerError)
 			return
 		}
@@ -179,9 +688,18 @@ func HandleUpdateOrderStatus(db *sql.DB) http.HandlerFunc {
 func HandleGetMyOrders(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		customerID := r.URL.Query().Get("user_id")
 
-		rows, err := db.Query("SELECT id, total_price, status, created_at FROM orders WHERE customer_id = ? ORDER BY created_at DESC", customerID)
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+
+		// Sekalian status bukti transfer terakhir (buat tombol upload ulang kalau ditolak)
+		rows, err := db.Query(`SELECT o.id, o.total_price, o.transfer_amount, o.status, o.created_at, p.status, p.reject_reason
+			FROM orders o
+			LEFT JOIN transfer_proofs p ON p.id = (SELECT MAX(id) FROM transfer_proofs WHERE order_id = o.id)
+			WHERE o.customer_id = ? ORDER BY o.created_at DESC`, customer.ID)
 		if err != nil {
 			http.Error(w, "Error database", http.StatusInternalServerError)
 			return
@@ -193,13 +711,26 @@ func HandleGetMyOrders(db *sql.DB) http.HandlerFunc {
 			var id int
 			var total float64
 			var status, created string
-			rows.Scan(&id, &total, &status, &created)
-			orders = append(orders, map[string]interface{}{
+			var transferAmount sql.NullFloat64
+			var proofStatus, rejectReason sql.NullString
+			rows.Scan(&id, &total, &transferAmount, &status, &created, &proofStatus, &rejectReason)
+			order := map[string]interface{}{
 				"id": id, "total_price": total, "status": status, "created_at": created,
-			})
+				"transfer_amount": nil, "proof_status": nil, "proof_reject_reason": nil,
+			}
+			if transferAmount.Valid {
+				order["transfer_amount"] = transferAmount.Float64
+			}
+			if proofStatus.Valid {
+				order["proof_status"] = proofStatus.String
+				order["proof_reject_reason"] = rejectReason.String
+			}
+			orders = append(orders, order)
 		}
 
-		if orders == nil { orders = []map[string]interface{}{} }
+		if orders == nil {
+			orders = []map[string]interface{}{}
+		}
 		json.NewEncoder(w).Encode(orders)
 	}
 }
@@ -210,9 +741,164 @@ func HandleGetMyOrders(db *sql.DB) http.HandlerFunc {
 func HandleCompleteOrder(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		var req struct { OrderID int `json:"order_id"` }
-		json.NewDecoder(r.Body).Decode(&req)
-		db.Exec("UPDATE orders SET status = 'Selesai' WHERE id = ?", req.OrderID)
+
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			OrderID int `json:"order_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data json error", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		// Cuma boleh nyelesaiin pesanan milik sendiri yang statusnya sudah Dikirim
+		actor := orders.Actor{Type: orders.ActorCustomer, ID: customer.ID}
+		if _, err := orders.Transition(tx, req.OrderID, orders.StatusDelivered, actor, ""); err != nil {
+			writeTransitionError(w, err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Gagal update database", http.StatusInternalServerError)
+			return
+		}
 		json.NewEncoder(w).Encode(map[string]string{"message": "Pesanan Selesai"})
 	}
-}
\ No newline at end of file
+}
+
+// =========================================================
+// 6. BATALKAN ORDER (CUSTOMER & ADMIN)
+// =========================================================
+type CancelOrderRequest struct {
+	OrderID int    `json:"order_id"`
+	Reason  string `json:"reason"`
+}
+
+// Customer cuma bisa batalin order miliknya yang masih Pending.
+func HandleCustomerCancelOrder(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+
+		var req CancelOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data json error", http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = "Dibatalkan oleh customer"
+		}
+
+		actor := orders.Actor{Type: orders.ActorCustomer, ID: customer.ID}
+		if err := orders.Cancel(db, req.OrderID, actor, req.Reason); err != nil {
+			writeTransitionError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Pesanan dibatalkan, stok sudah dikembalikan"})
+	}
+}
+
+// Admin bisa batalin order yang belum dikirim, alasan wajib diisi.
+func HandleAdminCancelOrder(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		user, _ := UserFromContext(r.Context())
+
+		var req CancelOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Data json error", http.StatusBadRequest)
+			return
+		}
+		if req.Reason == "" {
+			http.Error(w, "Alasan pembatalan wajib diisi", http.StatusBadRequest)
+			return
+		}
+
+		actor := orders.Actor{Type: orders.ActorAdmin, ID: user.ID}
+		if err := orders.Cancel(db, req.OrderID, actor, req.Reason); err != nil {
+			writeTransitionError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Pesanan dibatalkan, stok sudah dikembalikan"})
+	}
+}
+
+// =========================================================
+// 7. DAFTAR STATUS ORDER (BUAT DASHBOARD)
+// =========================================================
+// Dashboard ambil daftar status & perpindahan yang sah dari sini,
+// jadi gak perlu hard-code lagi di frontend.
+func HandleOrderStatuses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		type statusInfo struct {
+			Status orders.Status   `json:"status"`
+			Next   []orders.Status `json:"next"`
+		}
+
+		var list []statusInfo
+		for _, s := range orders.AllStatuses {
+			next := orders.NextStatuses(s)
+			if next == nil {
+				next = []orders.Status{}
+			}
+			list = append(list, statusInfo{Status: s, Next: next})
+		}
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// =========================================================
+// 8. RIWAYAT STATUS ORDER (ADMIN & CUSTOMER)
+// =========================================================
+// Admin (yang punya izin orders:read) bisa lihat semua order,
+// customer cuma order miliknya sendiri.
+func HandleOrderHistory(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		orderID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || orderID <= 0 {
+			http.Error(w, "ID order tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		if customer, ok := CustomerFromContext(r.Context()); ok {
+			var ownerID int
+			err := db.QueryRow("SELECT customer_id FROM orders WHERE id = ?", orderID).Scan(&ownerID)
+			if err != nil || ownerID != customer.ID {
+				http.Error(w, "Pesanan tidak ditemukan", http.StatusNotFound)
+				return
+			}
+		} else if user, ok := UserFromContext(r.Context()); !ok || !user.HasPermission(PermOrdersRead) {
+			http.Error(w, "Role kamu tidak punya akses ke sini", http.StatusForbidden)
+			return
+		}
+
+		history, err := orders.History(db, orderID)
+		if err != nil {
+			http.Error(w, "Gagal ambil riwayat", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(history)
+	}
+}
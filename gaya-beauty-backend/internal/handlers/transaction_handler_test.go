@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gaya-beauty-backend/internal/testdb"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func checkoutRequest(t *testing.T, customerID int, body interface{}) *http.Request {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	r := httptest.NewRequest(http.MethodPost, "/checkout", bytes.NewReader(b))
+	return r.WithContext(context.WithValue(r.Context(), customerContextKey, AuthCustomer{ID: customerID}))
+}
+
+// Banyak checkout barengan rebutan stok terbatas: yang sukses harus pas
+// sebanyak stok, sisanya 409 dengan rincian kekurangan, stok gak pernah minus.
+func TestCheckoutConcurrentOversell(t *testing.T) {
+	db := testdb.Open(t)
+
+	const stock = 3
+	const buyers = 12
+	productID := testdb.Product(t, db, "Lipstik Rebutan", 50000, stock)
+	customers := make([]int, buyers)
+	for i := range customers {
+		customers[i] = testdb.Customer(t, db, fmt.Sprintf("buyer%d@test.local", i))
+		testdb.Exec(t, db, "INSERT INTO carts (customer_id, product_id, variant_id, quantity) VALUES (?, ?, 0, 1)", customers[i], productID)
+	}
+
+	handler := HandleCheckout(db, nil, nil)
+	type outcome struct {
+		code int
+		body []byte
+	}
+	results := make([]outcome, buyers)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := checkoutRequest(t, customers[i], CheckoutRequest{PaymentMethod: "COD"})
+			<-start
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			results[i] = outcome{code: rec.Code, body: rec.Body.Bytes()}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for i, res := range results {
+		switch res.code {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusConflict:
+			var body struct {
+				Error string          `json:"error"`
+				Items []StockShortage `json:"items"`
+			}
+			if err := json.Unmarshal(res.body, &body); err != nil {
+				t.Fatalf("buyer %d: body 409 bukan JSON: %s", i, res.body)
+			}
+			if body.Error != "Stok tidak mencukupi" || len(body.Items) != 1 {
+				t.Fatalf("buyer %d: body 409 gak sesuai: %s", i, res.body)
+			}
+			if it := body.Items[0]; it.ProductID != productID || it.Requested != 1 || it.Available != 0 {
+				t.Errorf("buyer %d: rincian kekurangan salah: %+v", i, it)
+			}
+		default:
+			t.Fatalf("buyer %d: status %d: %s", i, res.code, res.body)
+		}
+	}
+	if succeeded != stock {
+		t.Errorf("checkout sukses = %d, mau %d", succeeded, stock)
+	}
+
+	var left, orderCount int
+	if err := db.QueryRow("SELECT stock FROM products WHERE id = ?", productID).Scan(&left); err != nil {
+		t.Fatal(err)
+	}
+	if left != 0 {
+		t.Errorf("stok akhir = %d, mau 0 (gak boleh minus)", left)
+	}
+	db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&orderCount)
+	if orderCount != stock {
+		t.Errorf("jumlah order = %d, mau %d", orderCount, stock)
+	}
+
+	// Keranjang yang jadi pesanan dikosongin, yang gagal tetap utuh
+	var cartRows int
+	db.QueryRow("SELECT COUNT(*) FROM carts").Scan(&cartRows)
+	if cartRows != buyers-stock {
+		t.Errorf("sisa baris keranjang = %d, mau %d", cartRows, buyers-stock)
+	}
+}
+
+// Checkout selalu ngambil isi keranjang tersimpan: item di body diabaikan,
+// keranjang kosong ditolak.
+func TestCheckoutUsesStoredCart(t *testing.T) {
+	db := testdb.Open(t)
+
+	cheap := testdb.Product(t, db, "Bedak", 20000, 10)
+	pricey := testdb.Product(t, db, "Serum", 150000, 10)
+	customerID := testdb.Customer(t, db, "cart@test.local")
+	handler := HandleCheckout(db, nil, nil)
+
+	rec := httptest.NewRecorder()
+	handler(rec, checkoutRequest(t, customerID, map[string]interface{}{"payment_method": "COD"}))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("keranjang kosong: status %d, mau 400: %s", rec.Code, rec.Body)
+	}
+
+	testdb.Exec(t, db, "INSERT INTO carts (customer_id, product_id, variant_id, quantity) VALUES (?, ?, 0, 2)", customerID, pricey)
+	rec = httptest.NewRecorder()
+	handler(rec, checkoutRequest(t, customerID, map[string]interface{}{
+		"payment_method": "COD",
+		"cart_items":     []map[string]interface{}{{"product_id": cheap, "quantity": 1, "price": 1}},
+	}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("checkout: status %d: %s", rec.Code, rec.Body)
+	}
+
+	var productID, quantity int
+	if err := db.QueryRow("SELECT product_id, quantity FROM order_items").Scan(&productID, &quantity); err != nil {
+		t.Fatal(err)
+	}
+	if productID != pricey || quantity != 2 {
+		t.Errorf("item order = produk %d x%d, mau produk %d x2 dari keranjang", productID, quantity, pricey)
+	}
+	var cartRows int
+	db.QueryRow("SELECT COUNT(*) FROM carts WHERE customer_id = ?", customerID).Scan(&cartRows)
+	if cartRows != 0 {
+		t.Errorf("keranjang masih %d baris setelah checkout", cartRows)
+	}
+}
+
+// Stok yang tinggal di batch kadaluarsa gak boleh kejual lewat checkout
+// (capSellable), walau products.stock masih ngitung batch itu.
+func TestCheckoutSkipsExpiredBatches(t *testing.T) {
+	db := testdb.Open(t)
+
+	productID := testdb.Product(t, db, "Krim Malam", 120000, 1)
+	testdb.Exec(t, db, `INSERT INTO stock_batches (product_id, variant_id, batch_code, expiry_date, quantity_received, quantity_remaining)
+		VALUES (?, 0, 'B-OLD', CURDATE() - INTERVAL 3 DAY, 4, 4)`, productID)
+	testdb.Exec(t, db, "UPDATE products SET stock = stock + 4 WHERE id = ?", productID)
+	customerID := testdb.Customer(t, db, "expired@test.local")
+	testdb.Exec(t, db, "INSERT INTO carts (customer_id, product_id, variant_id, quantity) VALUES (?, ?, 0, 2)", customerID, productID)
+
+	rec := httptest.NewRecorder()
+	HandleCheckout(db, nil, nil)(rec, checkoutRequest(t, customerID, CheckoutRequest{PaymentMethod: "COD"}))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status %d, mau 409: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Items []StockShortage `json:"items"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if len(body.Items) != 1 || body.Items[0].Available != 1 {
+		t.Errorf("rincian kekurangan = %+v, mau available 1 (batch kadaluarsa gak dihitung)", body.Items)
+	}
+
+	var stock int
+	db.QueryRow("SELECT stock FROM products WHERE id = ?", productID).Scan(&stock)
+	if stock != 5 {
+		t.Errorf("stok = %d, mau tetap 5", stock)
+	}
+}
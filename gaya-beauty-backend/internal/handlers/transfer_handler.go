@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gaya-beauty-backend/internal/imaging"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/storage"
+	"gaya-beauty-backend/internal/transfer"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultProofLimit = 50
+	maxProofLimit     = 200
+
+	maxSenderNameLength   = 100
+	maxSenderBankLength   = 50
+	maxRejectReasonLength = 255
+)
+
+func writeProofError(w http.ResponseWriter, err error) {
+	switch err {
+	case orders.ErrOrderNotFound:
+		http.Error(w, "Pesanan tidak ditemukan", http.StatusNotFound)
+	case transfer.ErrNotTransfer:
+		http.Error(w, "Pesanan ini bukan pembayaran transfer bank", http.StatusBadRequest)
+	case transfer.ErrNotPending:
+		http.Error(w, "Pesanan sudah tidak menunggu pembayaran", http.StatusConflict)
+	case transfer.ErrProofPending:
+		http.Error(w, "Bukti transfer sebelumnya masih dicek admin", http.StatusConflict)
+	case transfer.ErrProofNotFound:
+		http.Error(w, "Bukti transfer tidak ditemukan", http.StatusNotFound)
+	case transfer.ErrAlreadyReviewed:
+		http.Error(w, "Bukti transfer sudah diproses admin lain", http.StatusConflict)
+	default:
+		// Error dari state machine order (misal order keburu dibatalkan)
+		writeTransitionError(w, err)
+	}
+}
+
+// =========================================================
+// 1. UPLOAD BUKTI TRANSFER (CUSTOMER) - POST /my-orders/transfer-proof
+// =========================================================
+// Body multipart: order_id, sender_name (nama pemilik rekening), sender_bank
+// (opsional), receipt (foto/screenshot bukti transfer).
+// Foto disimpan ulang sebagai JPEG (EXIF dibuang) di storage privat, bukan di
+// storage foto produk: isinya nama & rekening customer. Dibuka lewat
+// GET /payments/transfer-proofs/{id}/receipt (lihat nomor 5).
+func HandleUploadTransferProof(db *sql.DB, receipts *storage.Private, maxUploadMB int) http.HandlerFunc {
+	maxFileSize := int64(maxUploadMB) << 20
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		customer, ok := CustomerFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Silakan login dulu", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxFileSize+(1<<20))
+		if err := r.ParseMultipartForm(8 << 20); err != nil {
+			http.Error(w, "Upload terlalu besar atau bukan multipart/form-data", http.StatusRequestEntityTooLarge)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		orderID, err := strconv.Atoi(r.FormValue("order_id"))
+		if err != nil || orderID <= 0 {
+			http.Error(w, "order_id wajib diisi", http.StatusBadRequest)
+			return
+		}
+		senderName := strings.TrimSpace(r.FormValue("sender_name"))
+		senderBank := strings.TrimSpace(r.FormValue("sender_bank"))
+		if senderName == "" {
+			http.Error(w, "Nama pemilik rekening pengirim wajib diisi", http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(senderName) > maxSenderNameLength || utf8.RuneCountInString(senderBank) > maxSenderBankLength {
+			http.Error(w, "Nama rekening atau bank pengirim terlalu panjang", http.StatusBadRequest)
+			return
+		}
+
+		f, fh, err := r.FormFile("receipt")
+		if err != nil {
+			http.Error(w, "Foto bukti transfer wajib di-upload (field: receipt)", http.StatusBadRequest)
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxFileSize+1))
+		f.Close()
+		if err != nil {
+			http.Error(w, "Gagal baca file upload", http.StatusBadRequest)
+			return
+		}
+		if fh.Size > maxFileSize || int64(len(data)) > maxFileSize {
+			http.Error(w, fmt.Sprintf("Bukti transfer lebih dari %d MB", maxUploadMB), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !allowedImageTypes[http.DetectContentType(data)] {
+			http.Error(w, "Bukti transfer harus gambar JPG/PNG/WebP", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		// Ambil ukuran paling besar versi JPEG aja (cukup buat dibaca admin)
+		renditions, err := imaging.Process(data)
+		if err == imaging.ErrTooLarge {
+			http.Error(w, "Resolusi bukti transfer terlalu besar", http.StatusRequestEntityTooLarge)
+			return
+		} else if err == imaging.ErrNotImage {
+			http.Error(w, "Bukti transfer bukan gambar JPG/PNG/WebP yang valid", http.StatusUnsupportedMediaType)
+			return
+		} else if err != nil {
+			log.Println("Gagal olah bukti transfer:", err)
+			http.Error(w, "Gagal olah bukti transfer", http.StatusInternalServerError)
+			return
+		}
+		var receipt imaging.Rendition
+		for _, rd := range renditions {
+			if rd.Size == imaging.Sizes[0].Name && rd.Format == "jpeg" {
+				receipt = rd
+				break
+			}
+		}
+
+		name, err := randomKey()
+		if err != nil {
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		key := fmt.Sprintf("transfer-proofs/%d/%s%s", orderID, name, receipt.Ext)
+		if err := receipts.Save(r.Context(), key, bytes.NewReader(receipt.Data)); err != nil {
+			log.Println("Gagal upload bukti transfer:", err)
+			http.Error(w, "Gagal upload bukti transfer", http.StatusBadGateway)
+			return
+		}
+
+		id, err := transfer.Submit(db, transfer.Submission{
+			OrderID:    orderID,
+			CustomerID: customer.ID,
+			SenderName: senderName,
+			SenderBank: senderBank,
+			ImageKey:   key,
+		})
+		if err != nil {
+			// Bukti ditolak (atau DB error): file yang terlanjur di-upload dibuang lagi
+			if derr := receipts.Delete(r.Context(), key); derr != nil {
+				log.Println("Gagal hapus bukti transfer yang batal:", key, derr)
+			}
+			writeProofError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":     "Bukti transfer terkirim, tunggu verifikasi admin ya",
+			"id":          id,
+			"receipt_url": receiptURL(id),
+		})
+	}
+}
+
+// =========================================================
+// 2. ANTRIAN VERIFIKASI (ADMIN) - GET /payments/transfer-proofs
+// =========================================================
+// Filter: status (default pending, "all" = semua), order_id. Pending diurut
+// paling lama dulu biar yang nunggu dari tadi dicek duluan.
+func HandleTransferProofs(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		f := transfer.Filter{Status: transfer.ProofPending, Limit: defaultProofLimit}
+		switch s := transfer.ProofStatus(q.Get("status")); {
+		case s == "":
+		case s == "all":
+			f.Status = ""
+		case transfer.ValidProofStatus(s):
+			f.Status = s
+		default:
+			http.Error(w, "Status tidak valid (pending, approved, rejected, all)", http.StatusBadRequest)
+			return
+		}
+		if !parseListParams(w, q, &f.Limit, maxProofLimit, intParam{"order_id", &f.OrderID}) {
+			return
+		}
+
+		items, err := transfer.List(db, f)
+		if err != nil {
+			http.Error(w, "Gagal ambil bukti transfer", http.StatusInternalServerError)
+			return
+		}
+		for i := range items {
+			items[i].ReceiptURL = receiptURL(items[i].ID)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	}
+}
+
+// =========================================================
+// 3. SETUJUI BUKTI TRANSFER (ADMIN) - POST /payments/transfer-proofs/approve
+// =========================================================
+// Body: {"id": 5}. Order pindah ke Lunas.
+func HandleApproveTransferProof(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+			http.Error(w, "ID bukti transfer wajib diisi", http.StatusBadRequest)
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+		if err := transfer.Approve(db, req.ID, user.ID); err != nil {
+			writeProofError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Pembayaran dikonfirmasi, pesanan Lunas"})
+	}
+}
+
+// =========================================================
+// 4. TOLAK BUKTI TRANSFER (ADMIN) - POST /payments/transfer-proofs/reject
+// =========================================================
+// Body: {"id": 5, "reason": "Nominal kurang"}. Alasan wajib, ditampilkan ke customer.
+func HandleRejectTransferProof(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			ID     int    `json:"id"`
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+			http.Error(w, "ID bukti transfer wajib diisi", http.StatusBadRequest)
+			return
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" {
+			http.Error(w, "Alasan penolakan wajib diisi", http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(req.Reason) > maxRejectReasonLength {
+			http.Error(w, "Alasan penolakan terlalu panjang", http.StatusBadRequest)
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+		if err := transfer.Reject(db, req.ID, user.ID, req.Reason); err != nil {
+			writeProofError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Bukti transfer ditolak"})
+	}
+}
+
+// Batas file mutasi yang di-upload
+const maxStatementUpload = 2 << 20
+
+const (
+	defaultStatementLineLimit = 100
+	maxStatementLineLimit     = 500
+
+	// Maksimal baris per sekali konfirmasi/abaikan
+	maxStatementBatch = 200
+)
+
+// =========================================================
+// 5. FOTO BUKTI TRANSFER (ADMIN / CUSTOMER PEMILIK) - GET /payments/transfer-proofs/{id}/receipt
+// =========================================================
+// Foto gak punya URL publik. Customer cuma bisa buka bukti miliknya sendiri,
+// admin/staff butuh permission orders:read.
+func HandleTransferProofReceipt(db *sql.DB, receipts *storage.Private) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		proofID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || proofID <= 0 {
+			http.Error(w, "ID bukti transfer tidak valid", http.StatusBadRequest)
+			return
+		}
+
+		ownerID, key, err := transfer.Receipt(db, proofID)
+		if err != nil && err != transfer.ErrProofNotFound {
+			http.Error(w, "Gagal ambil bukti transfer", http.StatusInternalServerError)
+			return
+		}
+		if customer, ok := CustomerFromContext(r.Context()); ok {
+			// Punya customer lain dianggap gak ada, biar ID-nya gak bisa ditebak-tebak
+			if err != nil || ownerID != customer.ID {
+				http.Error(w, "Bukti transfer tidak ditemukan", http.StatusNotFound)
+				return
+			}
+		} else if user, ok := UserFromContext(r.Context()); !ok || !user.HasPermission(PermOrdersRead) {
+			http.Error(w, "Role kamu tidak punya akses ke sini", http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, "Bukti transfer tidak ditemukan", http.StatusNotFound)
+			return
+		}
+
+		f, err := receipts.Open(key)
+		if err != nil {
+			log.Println("Gagal buka bukti transfer:", key, err)
+			http.Error(w, "File bukti transfer tidak ditemukan", http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "Gagal baca bukti transfer", http.StatusInternalServerError)
+			return
+		}
+
+		// Jangan disimpan di cache bersama (proxy/CDN), isinya data rekening
+		w.Header().Set("Cache-Control", "private, no-store")
+		http.ServeContent(w, r, path.Base(key), info.ModTime(), f)
+	}
+}
+
+// receiptURL = path API foto bukti transfer (relatif, frontend nambahin VITE_API_URL)
+func receiptURL(proofID int) string {
+	return fmt.Sprintf("payments/transfer-proofs/%d/receipt", proofID)
+}
+
+// =========================================================
+// 6. IMPORT MUTASI REKENING (ADMIN) - POST /payments/reconciliation/import
+// =========================================================
+// Body multipart, field "file" = CSV mutasi (kolom tanggal, keterangan, nominal).
+// Query opsional window_days (default 3, max 14) = jarak maksimal order dibuat
+// sampai transfer masuk. Balikin ringkasan; usulan per baris dilihat di
+// GET /payments/reconciliation?import_id=.
+func HandleImportBankStatement(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		windowDays := transfer.DefaultMatchWindowDays
+		if v := r.URL.Query().Get("window_days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > transfer.MaxMatchWindowDays {
+				http.Error(w, fmt.Sprintf("window_days harus 0 - %d", transfer.MaxMatchWindowDays), http.StatusBadRequest)
+				return
+			}
+			windowDays = n
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxStatementUpload+(1<<20))
+		if err := r.ParseMultipartForm(maxStatementUpload); err != nil {
+			http.Error(w, "Upload terlalu besar atau bukan multipart/form-data", http.StatusRequestEntityTooLarge)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		f, fh, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "File CSV mutasi wajib di-upload (field: file)", http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+
+		lines, lineErrs, err := transfer.ParseStatement(f)
+		if err != nil {
+			http.Error(w, "File mutasi tidak bisa dibaca: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(lines) == 0 {
+			writeJSONError(w, http.StatusBadRequest, map[string]interface{}{
+				"error":  "Tidak ada baris mutasi yang bisa dibaca",
+				"errors": lineErrs,
+			})
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+		result, err := transfer.Import(db, fh.Filename, lines, lineErrs, windowDays, user.ID)
+		if err != nil {
+			log.Println("Gagal import mutasi rekening:", err)
+			http.Error(w, "Gagal simpan mutasi rekening", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// =========================================================
+// 7. DAFTAR BARIS MUTASI (ADMIN) - GET /payments/reconciliation
+// =========================================================
+// Filter: import_id, status (default open = matched/ambiguous/unmatched,
+// "all" = semua). Terbaru dulu; halaman berikutnya kirim before_id = next_before_id.
+func HandleBankStatementLines(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		f := transfer.LineFilter{Status: transfer.LineOpen, Limit: defaultStatementLineLimit}
+		switch s := transfer.LineStatus(q.Get("status")); {
+		case s == "":
+		case s == "all":
+			f.Status = ""
+		case transfer.ValidLineStatus(s):
+			f.Status = s
+		default:
+			http.Error(w, "Status tidak valid (open, matched, ambiguous, unmatched, confirmed, ignored, all)", http.StatusBadRequest)
+			return
+		}
+		if !parseListParams(w, q, &f.Limit, maxStatementLineLimit, intParam{"import_id", &f.ImportID}, intParam{"before_id", &f.BeforeID}) {
+			return
+		}
+
+		items, err := transfer.ListLines(db, f)
+		if err != nil {
+			http.Error(w, "Gagal ambil mutasi rekening", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{"items": items, "next_before_id": nil}
+		if len(items) == f.Limit {
+			resp["next_before_id"] = items[len(items)-1].ID
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// =========================================================
+// 8. KONFIRMASI COCOK MUTASI (ADMIN) - POST /payments/reconciliation/confirm
+// =========================================================
+// Body: {"matches": [{"line_id": 1}, {"line_id": 2, "order_id": 15}]}.
+// Tanpa order_id = terima usulan hasil import (satu klik); order_id diisi buat
+// baris ambiguous/unmatched. Tiap baris diproses sendiri-sendiri: yang gagal
+// gak ngebatalin yang lain, hasilnya dilaporkan per baris.
+func HandleConfirmStatementMatches(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			Matches []struct {
+				LineID  int `json:"line_id"`
+				OrderID int `json:"order_id"`
+			} `json:"matches"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Matches) == 0 {
+			http.Error(w, "Pilih minimal satu baris mutasi", http.StatusBadRequest)
+			return
+		}
+		if len(req.Matches) > maxStatementBatch {
+			http.Error(w, fmt.Sprintf("Maksimal %d baris sekali konfirmasi", maxStatementBatch), http.StatusBadRequest)
+			return
+		}
+		for _, m := range req.Matches {
+			if m.LineID <= 0 || m.OrderID < 0 {
+				http.Error(w, "line_id / order_id tidak valid", http.StatusBadRequest)
+				return
+			}
+		}
+
+		user, _ := UserFromContext(r.Context())
+		type result struct {
+			LineID  int    `json:"line_id"`
+			OrderID int    `json:"order_id,omitempty"`
+			Error   string `json:"error,omitempty"`
+		}
+		results := make([]result, 0, len(req.Matches))
+		confirmed := 0
+		for _, m := range req.Matches {
+			orderID, err := transfer.Confirm(db, m.LineID, m.OrderID, user.ID)
+			if err != nil {
+				results = append(results, result{LineID: m.LineID, OrderID: m.OrderID, Error: statementErrorText(err)})
+				continue
+			}
+			confirmed++
+			results = append(results, result{LineID: m.LineID, OrderID: orderID})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"confirmed": confirmed,
+			"failed":    len(results) - confirmed,
+			"results":   results,
+		})
+	}
+}
+
+// statementErrorText ubah error konfirmasi jadi pesan buat admin
+func statementErrorText(err error) string {
+	var te *orders.TransitionError
+	switch {
+	case err == transfer.ErrLineNotFound, err == transfer.ErrLineResolved, err == transfer.ErrNoProposal,
+		err == transfer.ErrAmountMismatch, err == transfer.ErrNotTransfer, errors.Is(err, orders.ErrOrderNotFound):
+		return err.Error()
+	case errors.As(err, &te):
+		return te.Error()
+	default:
+		log.Println("Gagal konfirmasi mutasi rekening:", err)
+		return "Gagal update database"
+	}
+}
+
+// =========================================================
+// 9. ABAIKAN BARIS MUTASI (ADMIN) - POST /payments/reconciliation/ignore
+// =========================================================
+// Body: {"line_ids": [3, 4], "note": "Setoran modal"}. Buat uang masuk yang
+// memang bukan pembayaran order, biar gak nongol terus di daftar open.
+func HandleIgnoreStatementLines(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			LineIDs []int  `json:"line_ids"`
+			Note    string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.LineIDs) == 0 {
+			http.Error(w, "Pilih minimal satu baris mutasi", http.StatusBadRequest)
+			return
+		}
+		if len(req.LineIDs) > maxStatementBatch {
+			http.Error(w, fmt.Sprintf("Maksimal %d baris sekali proses", maxStatementBatch), http.StatusBadRequest)
+			return
+		}
+		req.Note = strings.TrimSpace(req.Note)
+		if utf8.RuneCountInString(req.Note) > maxRejectReasonLength {
+			http.Error(w, "Catatan terlalu panjang", http.StatusBadRequest)
+			return
+		}
+
+		user, _ := UserFromContext(r.Context())
+		n, err := transfer.Ignore(db, req.LineIDs, user.ID, req.Note)
+		if err != nil {
+			http.Error(w, "Gagal update mutasi rekening", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": "Mutasi ditandai bukan pembayaran order", "updated": n})
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/storage"
+	"gaya-beauty-backend/internal/testdb"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func receiptPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 60))
+	for x := 0; x < 40; x++ {
+		img.Set(x, x, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTransferProofReceiptIsPrivate(t *testing.T) {
+	db := testdb.Open(t)
+	dir := t.TempDir()
+	receipts, err := storage.NewPrivate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ownerID := testdb.Customer(t, db, "ani@test.local")
+	otherID := testdb.Customer(t, db, "budi@test.local")
+	orderID := testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status)
+		VALUES (?, 'Ani', 'Transfer Bank - BCA', 150000, ?)`, ownerID, orders.StatusPending)
+
+	// Upload bukti sebagai customer pemilik order
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("order_id", strconv.Itoa(orderID))
+	mw.WriteField("sender_name", "ANI LESTARI")
+	fw, _ := mw.CreateFormFile("receipt", "bukti.png")
+	fw.Write(receiptPNG(t))
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/my-orders/transfer-proof", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r = r.WithContext(context.WithValue(r.Context(), customerContextKey, AuthCustomer{ID: ownerID}))
+	rec := httptest.NewRecorder()
+	HandleUploadTransferProof(db, receipts, 5)(rec, r)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: status %d (%s)", rec.Code, rec.Body)
+	}
+	var uploaded struct {
+		ID         int    `json:"id"`
+		ReceiptURL string `json:"receipt_url"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &uploaded)
+	if uploaded.ReceiptURL != "payments/transfer-proofs/"+strconv.Itoa(uploaded.ID)+"/receipt" {
+		t.Errorf("receipt_url = %q, mau path API", uploaded.ReceiptURL)
+	}
+
+	var key string
+	db.QueryRow("SELECT image_key FROM transfer_proofs WHERE id = ?", uploaded.ID).Scan(&key)
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key))); err != nil {
+		t.Fatalf("file bukti gak ada di storage privat: %v", err)
+	}
+
+	h := HandleTransferProofReceipt(db, receipts)
+	get := func(ctx func(context.Context) context.Context, id int) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/payments/transfer-proofs/x/receipt", nil)
+		r.SetPathValue("id", strconv.Itoa(id))
+		r = r.WithContext(ctx(r.Context()))
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		return rec
+	}
+	asCustomer := func(id int) func(context.Context) context.Context {
+		return func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, customerContextKey, AuthCustomer{ID: id})
+		}
+	}
+	asUser := func(role Role) func(context.Context) context.Context {
+		return func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, userContextKey, AuthUser{ID: 1, Role: role})
+		}
+	}
+
+	rec = get(asCustomer(ownerID), uploaded.ID)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/jpeg" {
+		t.Errorf("pemilik: status %d type %q, mau 200 image/jpeg", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "private, no-store" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+	if rec := get(asCustomer(otherID), uploaded.ID); rec.Code != http.StatusNotFound {
+		t.Errorf("customer lain: status %d, mau 404", rec.Code)
+	}
+	if rec := get(asUser(RoleStaff), uploaded.ID); rec.Code != http.StatusOK {
+		t.Errorf("staff: status %d, mau 200", rec.Code)
+	}
+	if rec := get(asUser(RoleStaff), uploaded.ID+100); rec.Code != http.StatusNotFound {
+		t.Errorf("ID gak ada: status %d, mau 404", rec.Code)
+	}
+	if rec := get(func(ctx context.Context) context.Context { return ctx }, uploaded.ID); rec.Code != http.StatusForbidden {
+		t.Errorf("tanpa login: status %d, mau 403", rec.Code)
+	}
+}
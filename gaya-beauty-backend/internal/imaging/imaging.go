@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Ukuran foto yang dibikin tiap upload. MaxDim = sisi terpanjang maksimal,
+// foto kecil gak di-upscale. Urut dari besar ke kecil biar tiap ukuran
+// di-resize dari ukuran sebelumnya (lebih cepat daripada dari foto asli).
+type Size struct {
+	Name   string
+	MaxDim int
+}
+
+var Sizes = []Size{
+	{Name: "zoom", MaxDim: 1600},
+	{Name: "card", MaxDim: 640},
+	{Name: "thumb", MaxDim: 240},
+}
+
+const (
+	jpegQuality = 82
+	webpQuality = 80
+
+	// Batas resolusi (50 MP) biar file kecil berisi gambar raksasa gak bikin RAM habis
+	maxPixels = 50_000_000
+)
+
+var (
+	ErrNotImage = errors.New("file bukan gambar JPG/PNG/WebP yang valid")
+	ErrTooLarge = errors.New("resolusi gambar terlalu besar")
+)
+
+// Rendition = satu file hasil olahan (satu ukuran, satu format)
+type Rendition struct {
+	Size        string // zoom, card, thumb
+	Format      string // webp, jpeg
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Process decode foto upload, putar sesuai EXIF Orientation, lalu bikin semua
+// ukuran dalam WebP + JPEG (fallback). Hasilnya di-encode ulang dari piksel,
+// jadi metadata EXIF (lokasi GPS, tipe HP, dst.) otomatis kebuang.
+func Process(data []byte) ([]Rendition, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrNotImage
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	// Decode penuh: file yang cuma header-nya doang mirip gambar bakal gagal di sini
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	img := flatten(decoded)
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	var out []Rendition
+	for _, s := range Sizes {
+		img = fit(img, s.MaxDim)
+		b := img.Bounds()
+
+		if WebPSupported {
+			webpData, err := encodeWebP(img)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, Rendition{Size: s.Name, Format: "webp", ContentType: "image/webp", Ext: ".webp",
+				Width: b.Dx(), Height: b.Dy(), Data: webpData})
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		out = append(out, Rendition{Size: s.Name, Format: "jpeg", ContentType: "image/jpeg", Ext: ".jpg",
+			Width: b.Dx(), Height: b.Dy(), Data: buf.Bytes()})
+	}
+	return out, nil
+}
+
+// flatten salin gambar ke RGBA mulai (0,0). Bagian transparan (PNG/WebP)
+// dikasih latar putih, soalnya JPEG gak punya alpha.
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// fit kecilin gambar biar sisi terpanjangnya maksimal maxDim (rasio tetap).
+func fit(src *image.RGBA, maxDim int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxDim && h <= maxDim {
+		return src
+	}
+
+	if w >= h {
+		h = max(1, h*maxDim/w)
+		w = maxDim
+	} else {
+		w = max(1, w*maxDim/h)
+		h = maxDim
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
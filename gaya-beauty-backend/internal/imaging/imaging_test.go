@@ -0,0 +1,250 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+// twoTone bikin gambar w x h: separuh kiri merah, separuh kanan biru.
+func twoTone(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withExif sisipin segmen APP1 Exif (IFD0 isinya cuma Orientation) habis SOI.
+func withExif(jpg []byte, order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1) // jumlah entry
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+// near = warnanya kira-kira sama (JPEG lossy)
+func near(c color.Color, want color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+	diff := func(a uint32, b uint8) bool {
+		d := int(a>>8) - int(b)
+		return d > -40 && d < 40
+	}
+	return diff(r, want.R) && diff(g, want.G) && diff(b, want.B)
+}
+
+func TestProcessRejectsNonImage(t *testing.T) {
+	jpg := encodeJPEG(t, twoTone(20, 10))
+	for name, data := range map[string][]byte{
+		"kosong":        nil,
+		"teks":          []byte("ini bukan gambar, cuma teks biasa"),
+		"pdf":           []byte("%PDF-1.7\n1 0 obj\n"),
+		"jpeg kepotong": jpg[:len(jpg)/3], // header valid, datanya gak ada
+	} {
+		if _, err := Process(data); err != ErrNotImage {
+			t.Errorf("%s: err = %v, mau ErrNotImage", name, err)
+		}
+	}
+}
+
+func TestProcessTooLarge(t *testing.T) {
+	// PNG kecil yang header IHDR-nya ngaku 10000 x 10000 (100 MP)
+	data := encodePNG(t, twoTone(4, 4))
+	ihdr := data[8+8 : 8+8+13] // signature, lalu panjang + tipe chunk
+	binary.BigEndian.PutUint32(ihdr[0:], 10000)
+	binary.BigEndian.PutUint32(ihdr[4:], 10000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+	if _, err := Process(data); err != ErrTooLarge {
+		t.Errorf("err = %v, mau ErrTooLarge", err)
+	}
+}
+
+func TestProcessRenditions(t *testing.T) {
+	renditions, err := Process(encodePNG(t, twoTone(2000, 1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formats := []string{"jpeg"}
+	if WebPSupported {
+		formats = []string{"webp", "jpeg"}
+	}
+	if len(renditions) != len(Sizes)*len(formats) {
+		t.Fatalf("jumlah rendition = %d, mau %d", len(renditions), len(Sizes)*len(formats))
+	}
+
+	want := map[string][2]int{"zoom": {1600, 800}, "card": {640, 320}, "thumb": {240, 120}}
+	for i, r := range renditions {
+		if wantFormat := formats[i%len(formats)]; r.Format != wantFormat {
+			t.Errorf("rendition %d format %s, mau %s", i, r.Format, wantFormat)
+		}
+		if size := want[r.Size]; r.Width != size[0] || r.Height != size[1] {
+			t.Errorf("%s/%s = %dx%d, mau %dx%d", r.Size, r.Format, r.Width, r.Height, size[0], size[1])
+		}
+
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(r.Data))
+		if err != nil {
+			t.Fatalf("%s/%s gak bisa dibaca: %v", r.Size, r.Format, err)
+		}
+		if format != r.Format || cfg.Width != r.Width || cfg.Height != r.Height {
+			t.Errorf("%s/%s isinya %s %dx%d", r.Size, r.Format, format, cfg.Width, cfg.Height)
+		}
+		if (r.Format == "jpeg") != (r.ContentType == "image/jpeg" && r.Ext == ".jpg") {
+			t.Errorf("%s/%s content type %s ext %s", r.Size, r.Format, r.ContentType, r.Ext)
+		}
+	}
+}
+
+func TestProcessNoUpscale(t *testing.T) {
+	renditions, err := Process(encodePNG(t, twoTone(100, 300)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range renditions {
+		want := [2]int{100, 300}
+		if r.Size == "thumb" {
+			want = [2]int{80, 240}
+		}
+		if r.Width != want[0] || r.Height != want[1] {
+			t.Errorf("%s/%s = %dx%d, mau %dx%d", r.Size, r.Format, r.Width, r.Height, want[0], want[1])
+		}
+	}
+}
+
+func TestProcessTransparentBecomesWhite(t *testing.T) {
+	renditions, err := Process(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 10, 10))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(renditions[len(renditions)-1].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.At(5, 5); !near(c, color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("latar transparan jadi %v, mau putih", c)
+	}
+}
+
+func TestProcessExifOrientationAndStrip(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		// 40x20, kiri merah kanan biru, Orientation 6 = harus diputar 90° searah jarum jam
+		data := withExif(encodeJPEG(t, twoTone(40, 20)), order, 6)
+		if got := jpegOrientation(data); got != 6 {
+			t.Fatalf("%v: jpegOrientation = %d, mau 6", order, got)
+		}
+
+		renditions, err := Process(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range renditions {
+			if r.Width != 20 || r.Height != 40 {
+				t.Errorf("%v %s/%s = %dx%d, mau 20x40 (diputar)", order, r.Size, r.Format, r.Width, r.Height)
+			}
+			if r.Format != "jpeg" {
+				continue
+			}
+			// EXIF dibuang: gak ada segmen Exif lagi, jadi gak bakal diputar dua kali
+			if bytes.Contains(r.Data, []byte("Exif\x00\x00")) || jpegOrientation(r.Data) != 1 {
+				t.Errorf("%v %s: EXIF masih ada di hasil", order, r.Size)
+			}
+			img, err := jpeg.Decode(bytes.NewReader(r.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Kiri jadi atas, kanan jadi bawah
+			if top, bottom := img.At(10, 5), img.At(10, 34); !near(top, red) || !near(bottom, blue) {
+				t.Errorf("%v %s: atas %v, bawah %v, mau merah di atas & biru di bawah", order, r.Size, top, bottom)
+			}
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 3x2, tiap piksel beda: cek posisi piksel pojok kiri atas asli di tiap orientasi
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			src.Set(x, y, color.RGBA{uint8(x * 100), uint8(y * 100), 0, 255})
+		}
+	}
+	marker := src.At(0, 0)
+
+	cases := []struct {
+		orientation int
+		w, h        int
+		x, y        int // posisi piksel (0,0) asli setelah diputar
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, c := range cases {
+		dst := orient(src, c.orientation)
+		if b := dst.Bounds(); b.Dx() != c.w || b.Dy() != c.h {
+			t.Errorf("orientasi %d: ukuran %dx%d, mau %dx%d", c.orientation, b.Dx(), b.Dy(), c.w, c.h)
+			continue
+		}
+		if dst.At(c.x, c.y) != marker {
+			t.Errorf("orientasi %d: piksel (%d,%d) = %v, mau %v", c.orientation, c.x, c.y, dst.At(c.x, c.y), marker)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build cgo
+
+package imaging
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/chai2010/webp"
+)
+
+// WebPSupported = encoder WebP (libwebp) ikut ke-build. Butuh cgo.
+const WebPSupported = true
+
+func encodeWebP(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, &webp.Options{Quality: webpQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
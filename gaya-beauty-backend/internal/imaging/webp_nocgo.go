@@ -0,0 +1,15 @@
+//go:build !cgo
+
+package imaging
+
+import (
+	"errors"
+	"image"
+)
+
+// Build tanpa cgo (CGO_ENABLED=0) gak punya libwebp: foto cuma dibikin JPEG.
+const WebPSupported = false
+
+func encodeWebP(image.Image) ([]byte, error) {
+	return nil, errors.New("encoder WebP butuh build dengan cgo")
+}
@@ -0,0 +1,386 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Batch = satu batch produksi (kode BPOM + tanggal kadaluarsa) di satu unit stok.
+// Batch yang kadaluarsa gak bisa dijual; sisanya dihapus dari stok lewat WriteOffExpired.
+type Batch struct {
+	ID                int       `json:"id"`
+	ProductID         int       `json:"product_id"`
+	ProductName       string    `json:"product_name,omitempty"`
+	VariantID         int       `json:"variant_id"`
+	SKU               string    `json:"sku,omitempty"`
+	Code              string    `json:"batch_code"`          // "" = stok tanpa info batch
+	ExpiryDate        *string   `json:"expiry_date"`         // "2027-05-31", null = gak kadaluarsa
+	DaysLeft          *int      `json:"days_left,omitempty"` // Minus = sudah lewat
+	Expired           bool      `json:"expired"`
+	QuantityReceived  int       `json:"quantity_received"`
+	QuantityRemaining int       `json:"quantity_remaining"`
+	ReceivedAt        time.Time `json:"received_at"`
+}
+
+// Format tanggal kadaluarsa di API
+const DateLayout = "2006-01-02"
+
+var (
+	ErrBatchNotFound       = errors.New("batch tidak ditemukan")
+	ErrBatchExpiryMismatch = errors.New("kode batch sudah ada dengan tanggal kadaluarsa berbeda")
+)
+
+// Kondisi SQL batch yang masih boleh dijual (kadaluarsa hari ini masih boleh)
+const sellableBatch = "(expiry_date IS NULL OR expiry_date >= CURDATE())"
+
+// Urutan FEFO: yang paling cepat kadaluarsa keluar duluan, tanpa tanggal paling akhir
+const fefoOrder = "ORDER BY expiry_date IS NULL, expiry_date, id"
+
+// Unit = satu unit stok (VariantID 0 = produk tanpa varian)
+type Unit struct {
+	ProductID int
+	VariantID int
+}
+
+// Sellable = stok yang masih boleh dijual per unit (batch kadaluarsa gak dihitung)
+// buat produk-produk ini. Dipanggil checkout setelah baris produknya dikunci.
+func Sellable(tx *sql.Tx, productIDs []int) (map[Unit]int, error) {
+	result := map[Unit]int{}
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	rows, err := tx.Query(`SELECT product_id, variant_id, SUM(quantity_remaining) FROM stock_batches
+		WHERE product_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",")+`) AND `+sellableBatch+`
+		GROUP BY product_id, variant_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u Unit
+		var qty int
+		if err := rows.Scan(&u.ProductID, &u.VariantID, &qty); err != nil {
+			return nil, err
+		}
+		result[u] = qty
+	}
+	return result, rows.Err()
+}
+
+// findOrCreateBatch cari batch unit berdasarkan kode, bikin baru (qty 0) kalau belum ada.
+// Kode yang sama dengan tanggal kadaluarsa beda ditolak.
+func findOrCreateBatch(tx *sql.Tx, productID, variantID int, code string, expiry *time.Time) (int, error) {
+	var id int
+	var stored sql.NullTime
+	err := tx.QueryRow(`SELECT id, expiry_date FROM stock_batches
+		WHERE product_id = ? AND variant_id = ? AND batch_code = ? FOR UPDATE`,
+		productID, variantID, code).Scan(&id, &stored)
+	if err == nil {
+		same := !stored.Valid && expiry == nil ||
+			stored.Valid && expiry != nil && stored.Time.Format(DateLayout) == expiry.Format(DateLayout)
+		if !same {
+			return 0, ErrBatchExpiryMismatch
+		}
+		return id, nil
+	} else if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	var exp interface{}
+	if expiry != nil {
+		exp = expiry.Format(DateLayout)
+	}
+	res, err := tx.Exec("INSERT INTO stock_batches (product_id, variant_id, batch_code, expiry_date) VALUES (?, ?, ?, ?)",
+		productID, variantID, code, exp)
+	if err != nil {
+		return 0, err
+	}
+	newID, _ := res.LastInsertId()
+	return int(newID), nil
+}
+
+// Bagian movement yang kena ke satu batch
+type batchSlice struct {
+	BatchID  int
+	Quantity int
+}
+
+// allocate bagi perubahan stok m ke batch-batch unitnya dan langsung update
+// quantity_remaining. Aturannya:
+//   - m.BatchID diisi: semuanya ke/dari batch itu
+//   - keluar (minus): FEFO; penjualan cuma boleh ambil batch yang belum kadaluarsa
+//   - masuk dari order batal: balik ke batch yang dulu dipakai order itu
+//   - masuk lainnya: batch tanpa kode
+func allocate(tx *sql.Tx, m Movement) ([]batchSlice, error) {
+	if m.BatchID != 0 {
+		var remaining int
+		err := tx.QueryRow("SELECT quantity_remaining FROM stock_batches WHERE id = ? AND product_id = ? AND variant_id = ? FOR UPDATE",
+			m.BatchID, m.ProductID, m.VariantID).Scan(&remaining)
+		if err == sql.ErrNoRows {
+			return nil, ErrBatchNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		if remaining+m.Quantity < 0 {
+			return nil, ErrNegativeStock
+		}
+		slices := []batchSlice{{m.BatchID, m.Quantity}}
+		return slices, applySlices(tx, slices, m.Reason)
+	}
+
+	var slices []batchSlice
+	if m.Quantity < 0 {
+		filter := ""
+		if m.Reason == ReasonSale {
+			filter = " AND " + sellableBatch
+		}
+		rows, err := tx.Query(`SELECT id, quantity_remaining FROM stock_batches
+			WHERE product_id = ? AND variant_id = ? AND quantity_remaining > 0`+filter+`
+			`+fefoOrder+` FOR UPDATE`, m.ProductID, m.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		need := -m.Quantity
+		for need > 0 && rows.Next() {
+			var id, remaining int
+			if err := rows.Scan(&id, &remaining); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			take := min(need, remaining)
+			slices = append(slices, batchSlice{id, -take})
+			need -= take
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if need > 0 {
+			return nil, ErrNegativeStock
+		}
+		return slices, applySlices(tx, slices, m.Reason)
+	}
+
+	left := m.Quantity
+	if m.OrderID != 0 {
+		// Yang masih "di luar" per batch = total quantity order ini di batch itu (jual minus, batal plus)
+		rows, err := tx.Query(`SELECT batch_id, -SUM(quantity) FROM inventory_movements
+			WHERE order_id = ? AND product_id = ? AND variant_id = ? AND batch_id IS NOT NULL
+			GROUP BY batch_id HAVING SUM(quantity) < 0 ORDER BY batch_id`, m.OrderID, m.ProductID, m.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		for left > 0 && rows.Next() {
+			var id, out int
+			if err := rows.Scan(&id, &out); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			give := min(left, out)
+			slices = append(slices, batchSlice{id, give})
+			left -= give
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	// Sisanya (atau order lama yang belum kenal batch) masuk batch tanpa kode
+	if left > 0 {
+		id, err := findOrCreateBatch(tx, m.ProductID, m.VariantID, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		slices = append(slices, batchSlice{id, left})
+	}
+	return slices, applySlices(tx, slices, m.Reason)
+}
+
+// applySlices update sisa batch. Barang masuk (selain balikan order batal)
+// juga nambah quantity_received.
+func applySlices(tx *sql.Tx, slices []batchSlice, reason Reason) error {
+	for _, s := range slices {
+		received := 0
+		if s.Quantity > 0 && reason != ReasonCancellation {
+			received = s.Quantity
+		}
+		_, err := tx.Exec("UPDATE stock_batches SET quantity_remaining = quantity_remaining + ?, quantity_received = quantity_received + ? WHERE id = ?",
+			s.Quantity, received, s.BatchID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive terima barang masuk ke batch `code` (dibikin kalau belum ada) lalu catat di ledger.
+func Receive(tx *sql.Tx, m Movement, code string, expiry *time.Time) ([]Movement, error) {
+	if m.Quantity <= 0 {
+		return nil, ErrNegativeStock
+	}
+	if _, err := lockUnit(tx, m.ProductID, m.VariantID); err != nil {
+		return nil, err
+	}
+	id, err := findOrCreateBatch(tx, m.ProductID, m.VariantID, code, expiry)
+	if err != nil {
+		return nil, err
+	}
+	m.BatchID = id
+	return apply(tx, m)
+}
+
+const batchColumns = `b.id, b.product_id, p.name, b.variant_id, COALESCE(v.sku, ''), b.batch_code,
+	DATE_FORMAT(b.expiry_date, '%Y-%m-%d'), DATEDIFF(b.expiry_date, CURDATE()),
+	b.quantity_received, b.quantity_remaining, b.received_at
+	FROM stock_batches b
+	JOIN products p ON p.id = b.product_id
+	LEFT JOIN product_variants v ON v.id = b.variant_id`
+
+func scanBatches(rows *sql.Rows) ([]Batch, error) {
+	defer rows.Close()
+	list := []Batch{}
+	for rows.Next() {
+		var b Batch
+		var expiry sql.NullString
+		var daysLeft sql.NullInt64
+		if err := rows.Scan(&b.ID, &b.ProductID, &b.ProductName, &b.VariantID, &b.SKU, &b.Code,
+			&expiry, &daysLeft, &b.QuantityReceived, &b.QuantityRemaining, &b.ReceivedAt); err != nil {
+			return nil, err
+		}
+		if expiry.Valid {
+			b.ExpiryDate = &expiry.String
+			d := int(daysLeft.Int64)
+			b.DaysLeft = &d
+			b.Expired = d < 0
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
+
+// ListBatches ambil batch satu produk (variantID 0 = semua unitnya), urut FEFO.
+// withEmpty = false nyembunyiin batch yang sudah habis.
+func ListBatches(db *sql.DB, productID, variantID int, withEmpty bool) ([]Batch, error) {
+	query := "SELECT " + batchColumns + " WHERE b.product_id = ?"
+	args := []interface{}{productID}
+	if variantID != 0 {
+		query += " AND b.variant_id = ?"
+		args = append(args, variantID)
+	}
+	if !withEmpty {
+		query += " AND b.quantity_remaining > 0"
+	}
+	rows, err := db.Query(query+" ORDER BY b.variant_id, b.expiry_date IS NULL, b.expiry_date, b.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBatches(rows)
+}
+
+// Expiring ambil batch yang masih ada sisanya dan kadaluarsa dalam `days` hari
+// ke depan, termasuk yang sudah lewat tapi belum dihapus dari stok.
+func Expiring(db *sql.DB, days int) ([]Batch, error) {
+	rows, err := db.Query("SELECT "+batchColumns+`
+		WHERE b.quantity_remaining > 0 AND b.expiry_date IS NOT NULL
+			AND b.expiry_date <= CURDATE() + INTERVAL ? DAY
+		ORDER BY b.expiry_date, b.product_id, b.variant_id, b.id`, days)
+	if err != nil {
+		return nil, err
+	}
+	return scanBatches(rows)
+}
+
+// WriteOffExpired keluarin sisa batch yang sudah kadaluarsa dari stok
+// (dicatat di ledger dengan alasan expired). Tiap batch satu transaksi.
+func WriteOffExpired(db *sql.DB) (int, error) {
+	rows, err := db.Query(`SELECT id, product_id, variant_id FROM stock_batches
+		WHERE expiry_date < CURDATE() AND quantity_remaining > 0 ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	var batches []Movement
+	for rows.Next() {
+		var m Movement
+		if err := rows.Scan(&m.BatchID, &m.ProductID, &m.VariantID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batches = append(batches, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range batches {
+		if err := writeOffBatch(db, m); err != nil {
+			log.Printf("Hapus stok batch kadaluarsa %d gagal: %v", m.BatchID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func writeOffBatch(db *sql.DB, m Movement) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Unit dikunci dulu (urutan sama kayak checkout), baru sisa batch dibaca ulang
+	if _, err := lockUnit(tx, m.ProductID, m.VariantID); err != nil {
+		return err
+	}
+	var code, expiry string
+	var remaining int
+	err = tx.QueryRow(`SELECT batch_code, DATE_FORMAT(expiry_date, '%Y-%m-%d'), quantity_remaining FROM stock_batches
+		WHERE id = ? AND expiry_date < CURDATE() FOR UPDATE`, m.BatchID).Scan(&code, &expiry, &remaining)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return nil
+	}
+
+	m.Quantity = -remaining
+	m.Reason = ReasonExpired
+	m.Note = fmt.Sprintf("Batch %s kadaluarsa %s", code, expiry)
+	if _, err := apply(tx, m); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// StartExpiryWriteOff jalanin WriteOffExpired sekali, lalu tiap `interval` sampai ctx selesai.
+func StartExpiryWriteOff(ctx context.Context, db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := WriteOffExpired(db)
+		if err != nil {
+			log.Println("Hapus stok kadaluarsa gagal:", err)
+		} else if n > 0 {
+			log.Printf("Stok kadaluarsa: %d batch dikeluarkan dari stok", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package inventory
+
+import (
+	"database/sql"
+	"gaya-beauty-backend/internal/testdb"
+	"testing"
+)
+
+// stockBatch bikin batch langsung di tabel (expiryDays nil = tanpa tanggal,
+// minus = sudah kadaluarsa) dan nambah stok produknya.
+func stockBatch(t *testing.T, db *sql.DB, productID int, code string, expiryDays *int, qty int) int {
+	t.Helper()
+	// Tanggal dihitung dari CURDATE() server biar sama persis sama query yang dites
+	var offset interface{}
+	if expiryDays != nil {
+		offset = *expiryDays
+	}
+	id := testdb.Exec(t, db, `INSERT INTO stock_batches (product_id, variant_id, batch_code, expiry_date, quantity_received, quantity_remaining)
+		VALUES (?, 0, ?, CURDATE() + INTERVAL ? DAY, ?, ?)`, productID, code, offset, qty, qty)
+	testdb.Exec(t, db, "UPDATE products SET stock = stock + ? WHERE id = ?", qty, productID)
+	return id
+}
+
+func days(n int) *int { return &n }
+
+func remaining(t *testing.T, db *sql.DB, batchID int) int {
+	t.Helper()
+	var qty int
+	if err := db.QueryRow("SELECT quantity_remaining FROM stock_batches WHERE id = ?", batchID).Scan(&qty); err != nil {
+		t.Fatal(err)
+	}
+	return qty
+}
+
+// adjust jalanin Adjust di transaksi sendiri
+func adjust(t *testing.T, db *sql.DB, m Movement) error {
+	t.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := Adjust(tx, m); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func TestAllocateFEFO(t *testing.T) {
+	db := testdb.Open(t)
+	productID := testdb.Exec(t, db, "INSERT INTO products (name, price, stock, status) VALUES ('Toner', 80000, 0, 'active')")
+
+	late := stockBatch(t, db, productID, "B-LATE", days(60), 2)
+	noExpiry := stockBatch(t, db, productID, "B-NONE", nil, 2)
+	soon := stockBatch(t, db, productID, "B-SOON", days(10), 2)
+	expired := stockBatch(t, db, productID, "B-OLD", days(-5), 2)
+
+	// Jual 3: habisin yang paling cepat kadaluarsa dulu, batch kadaluarsa dilewati
+	if err := adjust(t, db, Movement{ProductID: productID, Quantity: -3, Reason: ReasonSale}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name  string
+		batch int
+		want  int
+	}{{"B-SOON", soon, 0}, {"B-LATE", late, 1}, {"B-NONE", noExpiry, 2}, {"B-OLD", expired, 2}} {
+		if got := remaining(t, db, c.batch); got != c.want {
+			t.Errorf("setelah jual 3, sisa %s = %d, mau %d", c.name, got, c.want)
+		}
+	}
+
+	// Jual 3 lagi: batch tanpa tanggal kadaluarsa paling akhir
+	if err := adjust(t, db, Movement{ProductID: productID, Quantity: -3, Reason: ReasonSale}); err != nil {
+		t.Fatal(err)
+	}
+	if remaining(t, db, late) != 0 || remaining(t, db, noExpiry) != 0 {
+		t.Errorf("sisa B-LATE = %d, B-NONE = %d, mau dua-duanya 0", remaining(t, db, late), remaining(t, db, noExpiry))
+	}
+
+	// Sisa stok tinggal batch kadaluarsa: gak boleh kejual
+	if err := adjust(t, db, Movement{ProductID: productID, Quantity: -1, Reason: ReasonSale}); err != ErrNegativeStock {
+		t.Errorf("jual dari batch kadaluarsa = %v, mau ErrNegativeStock", err)
+	}
+	if got := remaining(t, db, expired); got != 2 {
+		t.Errorf("sisa B-OLD = %d, mau tetap 2", got)
+	}
+
+	// Pengurangan selain jual (barang rusak) boleh ngambil batch kadaluarsa
+	if err := adjust(t, db, Movement{ProductID: productID, Quantity: -1, Reason: ReasonDamaged}); err != nil {
+		t.Fatal(err)
+	}
+	if got := remaining(t, db, expired); got != 1 {
+		t.Errorf("sisa B-OLD setelah rusak 1 = %d, mau 1", got)
+	}
+}
+
+func TestSellableSkipsExpired(t *testing.T) {
+	db := testdb.Open(t)
+	productID := testdb.Exec(t, db, "INSERT INTO products (name, price, stock, status) VALUES ('Masker', 30000, 0, 'active')")
+	stockBatch(t, db, productID, "B-OK", days(30), 4)
+	stockBatch(t, db, productID, "B-TODAY", days(0), 1) // Kadaluarsa hari ini masih boleh
+	stockBatch(t, db, productID, "B-OLD", days(-1), 5)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	sellable, err := Sellable(tx, []int{productID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sellable[Unit{ProductID: productID}]; got != 5 {
+		t.Errorf("stok yang bisa dijual = %d, mau 5 (batch kadaluarsa gak dihitung)", got)
+	}
+}
+
+func TestCancellationReturnsToOriginalBatches(t *testing.T) {
+	db := testdb.Open(t)
+	productID := testdb.Exec(t, db, "INSERT INTO products (name, price, stock, status) VALUES ('Sunscreen', 95000, 0, 'active')")
+	customerID := testdb.Customer(t, db, "batal@test.local")
+	orderID := testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status)
+		VALUES (?, 'Test', 'COD', 285000, 'Pending')`, customerID)
+
+	soon := stockBatch(t, db, productID, "B-SOON", days(10), 2)
+	late := stockBatch(t, db, productID, "B-LATE", days(90), 5)
+
+	if err := adjust(t, db, Movement{ProductID: productID, Quantity: -3, Reason: ReasonSale, OrderID: orderID}); err != nil {
+		t.Fatal(err)
+	}
+	// Restock batch lain di tengah-tengah: balikan order batal gak boleh nyasar ke sini
+	fresh := stockBatch(t, db, productID, "B-FRESH", days(5), 3)
+
+	if err := adjust(t, db, Movement{ProductID: productID, Quantity: 3, Reason: ReasonCancellation, OrderID: orderID}); err != nil {
+		t.Fatal(err)
+	}
+	if remaining(t, db, soon) != 2 || remaining(t, db, late) != 5 || remaining(t, db, fresh) != 3 {
+		t.Errorf("sisa setelah batal: B-SOON %d, B-LATE %d, B-FRESH %d, mau 2, 5, 3",
+			remaining(t, db, soon), remaining(t, db, late), remaining(t, db, fresh))
+	}
+
+	// Balikan order batal gak dihitung barang masuk baru
+	var received int
+	db.QueryRow("SELECT quantity_received FROM stock_batches WHERE id = ?", soon).Scan(&received)
+	if received != 2 {
+		t.Errorf("quantity_received B-SOON = %d, mau tetap 2", received)
+	}
+}
@@ -0,0 +1,56 @@
+package inventory
+
+import "database/sql"
+
+// Drift = satu unit stok yang angkanya beda sama hasil hitung ulang ledger.
+type Drift struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	VariantID   int    `json:"variant_id"`
+	SKU         string `json:"sku"`
+	Stock       int    `json:"stock"`            // Angka di products / product_variants
+	LedgerStock int    `json:"ledger_stock"`     // Total quantity di ledger
+	Difference  int    `json:"difference"`       // Stock - LedgerStock
+	BatchStock  int    `json:"batch_stock"`      // Total sisa semua batch
+	BatchDiff   int    `json:"batch_difference"` // Stock - BatchStock
+}
+
+// Reconcile hitung ulang stok tiap unit dari ledger & batch dan bandingin sama stok sekarang.
+// all = false cuma balikin unit yang selisih.
+// Produk bervarian dicek per varian (products.stock-nya cuma total varian).
+func Reconcile(db *sql.DB, all bool) (checked int, drifts []Drift, err error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.name, 0, '', p.stock, COALESCE(SUM(m.quantity), 0),
+			(SELECT COALESCE(SUM(b.quantity_remaining), 0) FROM stock_batches b WHERE b.product_id = p.id AND b.variant_id = 0)
+		FROM products p
+		LEFT JOIN inventory_movements m ON m.product_id = p.id AND m.variant_id = 0
+		WHERE NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+		GROUP BY p.id, p.name, p.stock
+		UNION ALL
+		SELECT v.product_id, p.name, v.id, v.sku, v.stock, COALESCE(SUM(m.quantity), 0),
+			(SELECT COALESCE(SUM(b.quantity_remaining), 0) FROM stock_batches b WHERE b.variant_id = v.id)
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN inventory_movements m ON m.variant_id = v.id
+		GROUP BY v.id, v.product_id, p.name, v.sku, v.stock
+		ORDER BY 1, 3`)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	drifts = []Drift{}
+	for rows.Next() {
+		var d Drift
+		if err := rows.Scan(&d.ProductID, &d.ProductName, &d.VariantID, &d.SKU, &d.Stock, &d.LedgerStock, &d.BatchStock); err != nil {
+			return 0, nil, err
+		}
+		checked++
+		d.Difference = d.Stock - d.LedgerStock
+		d.BatchDiff = d.Stock - d.BatchStock
+		if all || d.Difference != 0 || d.BatchDiff != 0 {
+			drifts = append(drifts, d)
+		}
+	}
+	return checked, drifts, rows.Err()
+}
@@ -0,0 +1,267 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Alasan perubahan stok
+type Reason string
+
+const (
+	ReasonSale         Reason = "sale"         // Checkout
+	ReasonCancellation Reason = "cancellation" // Order batal, stok balik
+	ReasonRestock      Reason = "restock"      // Barang masuk dari supplier
+	ReasonAdjustment   Reason = "adjustment"   // Koreksi manual / stock opname / edit produk
+	ReasonDamaged      Reason = "damaged"
+	ReasonExpired      Reason = "expired"
+	ReasonInitial      Reason = "initial" // Stok awal produk/varian baru
+)
+
+// ManualReason = alasan yang boleh dipakai admin lewat POST /inventory/adjust.
+// sale & cancellation cuma dicatat otomatis dari order.
+func ManualReason(r Reason) bool {
+	switch r {
+	case ReasonRestock, ReasonAdjustment, ReasonDamaged, ReasonExpired:
+		return true
+	}
+	return false
+}
+
+// Movement = satu baris ledger. Satu "unit stok" = produk tanpa varian
+// (VariantID 0) atau satu varian. Perubahan yang kena beberapa batch
+// dicatat satu baris per batch.
+type Movement struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	VariantID  int       `json:"variant_id"`
+	BatchID    int       `json:"batch_id,omitempty"`
+	Quantity   int       `json:"quantity"` // + masuk, - keluar
+	StockAfter int       `json:"stock_after"`
+	Reason     Reason    `json:"reason"`
+	OrderID    int       `json:"order_id,omitempty"`
+	UserID     int       `json:"user_id,omitempty"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+var (
+	ErrNotFound        = errors.New("produk/varian tidak ditemukan")
+	ErrVariantRequired = errors.New("produk ini punya varian, pilih variannya")
+	ErrNegativeStock   = errors.New("stok tidak boleh minus")
+)
+
+func nullInt(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// Record catat perubahan stok yang BARU SAJA dilakukan caller di transaksi yang sama,
+// sekalian ngubah sisa batch-nya (lihat allocate). stock_after diambil dari stok
+// unit itu sekarang. Quantity 0 gak dicatat.
+func Record(tx *sql.Tx, m Movement) error {
+	_, err := record(tx, m)
+	return err
+}
+
+func record(tx *sql.Tx, m Movement) ([]Movement, error) {
+	if m.Quantity == 0 {
+		return nil, nil
+	}
+
+	var stock int
+	var err error
+	if m.VariantID != 0 {
+		err = tx.QueryRow("SELECT stock FROM product_variants WHERE id = ?", m.VariantID).Scan(&stock)
+	} else {
+		err = tx.QueryRow("SELECT stock FROM products WHERE id = ?", m.ProductID).Scan(&stock)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slices, err := allocate(tx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	var note interface{}
+	if m.Note != "" {
+		note = m.Note
+	}
+	// Satu baris per batch; stock_after naik/turun bertahap sampai stok sekarang
+	running := stock - m.Quantity
+	var rows []Movement
+	for _, s := range slices {
+		row := m
+		row.BatchID = s.BatchID
+		row.Quantity = s.Quantity
+		running += s.Quantity
+		row.StockAfter = running
+
+		res, err := tx.Exec(`INSERT INTO inventory_movements (product_id, variant_id, batch_id, quantity, stock_after, reason, order_id, user_id, note)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			row.ProductID, row.VariantID, row.BatchID, row.Quantity, row.StockAfter, row.Reason, nullInt(row.OrderID), nullInt(row.UserID), note)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := res.LastInsertId()
+		row.ID = int(id)
+		row.CreatedAt = time.Now()
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// lockUnit kunci baris produk dulu baru varian (urutannya sama kayak checkout)
+// dan balikin stok unit itu sekarang.
+func lockUnit(tx *sql.Tx, productID, variantID int) (int, error) {
+	var productStock int
+	var hasVariants bool
+	err := tx.QueryRow(`SELECT stock, EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+		FROM products WHERE id = ? FOR UPDATE`, productID).Scan(&productStock, &hasVariants)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	if variantID == 0 {
+		if hasVariants {
+			return 0, ErrVariantRequired
+		}
+		return productStock, nil
+	}
+
+	var variantStock int
+	err = tx.QueryRow("SELECT stock FROM product_variants WHERE id = ? AND product_id = ? FOR UPDATE",
+		variantID, productID).Scan(&variantStock)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return variantStock, err
+}
+
+// apply ubah stok unit sebesar m.Quantity (varian ikut ngubah products.stock) lalu catat.
+func apply(tx *sql.Tx, m Movement) ([]Movement, error) {
+	if m.VariantID != 0 {
+		if _, err := tx.Exec("UPDATE product_variants SET stock = stock + ? WHERE id = ?", m.Quantity, m.VariantID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE products SET stock = stock + ? WHERE id = ?", m.Quantity, m.ProductID); err != nil {
+		return nil, err
+	}
+	return record(tx, m)
+}
+
+// Adjust tambah/kurangi stok satu unit sebesar m.Quantity lalu catat di ledger.
+// m.BatchID kosong = pengurangan diambil FEFO, penambahan masuk batch tanpa kode.
+func Adjust(tx *sql.Tx, m Movement) ([]Movement, error) {
+	current, err := lockUnit(tx, m.ProductID, m.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	if current+m.Quantity < 0 {
+		return nil, ErrNegativeStock
+	}
+	return apply(tx, m)
+}
+
+// SetCount samain stok dengan hasil hitung fisik (stock opname): satu unit,
+// atau satu batch kalau m.BatchID diisi. Selisihnya yang dicatat; kalau sudah
+// sama, gak ada yang ditulis (hasilnya kosong).
+func SetCount(tx *sql.Tx, m Movement, counted int) ([]Movement, error) {
+	if counted < 0 {
+		return nil, ErrNegativeStock
+	}
+	current, err := lockUnit(tx, m.ProductID, m.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	if m.BatchID != 0 {
+		err := tx.QueryRow("SELECT quantity_remaining FROM stock_batches WHERE id = ? AND product_id = ? AND variant_id = ? FOR UPDATE",
+			m.BatchID, m.ProductID, m.VariantID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return nil, ErrBatchNotFound
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	m.Quantity = counted - current
+	if m.Quantity == 0 {
+		return nil, nil
+	}
+	return apply(tx, m)
+}
+
+// Filter daftar ledger (nilai kosong = gak difilter)
+type Filter struct {
+	ProductID int
+	VariantID int
+	BatchID   int
+	Reason    Reason
+	OrderID   int
+	BeforeID  int // Halaman berikutnya: ID terakhir halaman sebelumnya
+	Limit     int
+}
+
+// List ambil ledger terbaru dulu.
+func List(db *sql.DB, f Filter) ([]Movement, error) {
+	conds := []string{"1=1"}
+	var args []interface{}
+	if f.ProductID != 0 {
+		conds = append(conds, "product_id = ?")
+		args = append(args, f.ProductID)
+	}
+	if f.VariantID != 0 {
+		conds = append(conds, "variant_id = ?")
+		args = append(args, f.VariantID)
+	}
+	if f.BatchID != 0 {
+		conds = append(conds, "batch_id = ?")
+		args = append(args, f.BatchID)
+	}
+	if f.Reason != "" {
+		conds = append(conds, "reason = ?")
+		args = append(args, f.Reason)
+	}
+	if f.OrderID != 0 {
+		conds = append(conds, "order_id = ?")
+		args = append(args, f.OrderID)
+	}
+	if f.BeforeID != 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, f.BeforeID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Query(`SELECT id, product_id, variant_id, batch_id, quantity, stock_after, reason, order_id, user_id, note, created_at
+		FROM inventory_movements WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Movement{}
+	for rows.Next() {
+		var m Movement
+		var batchID, orderID, userID sql.NullInt64
+		var note sql.NullString
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.VariantID, &batchID, &m.Quantity, &m.StockAfter, &m.Reason,
+			&orderID, &userID, &note, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		m.BatchID = int(batchID.Int64)
+		m.OrderID = int(orderID.Int64)
+		m.UserID = int(userID.Int64)
+		m.Note = note.String
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,94 @@
+package migrations
+
+import "database/sql"
+
+// Skema dasar gabungan dari auto-migrate ConnectDB lama dan endpoint /reset-db-now.
+// Pakai IF NOT EXISTS biar database yang sudah jalan bisa langsung diadopsi.
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				// A. Tabel Users (Admin)
+				`CREATE TABLE IF NOT EXISTS users (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					full_name VARCHAR(100) NOT NULL,
+					email VARCHAR(100) NOT NULL UNIQUE,
+					password VARCHAR(255) NOT NULL,
+					role VARCHAR(50) NOT NULL DEFAULT 'admin',
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)`,
+
+				// B. Tabel Products
+				`CREATE TABLE IF NOT EXISTS products (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					name VARCHAR(255) NOT NULL,
+					price DECIMAL(10,2) NOT NULL,
+					stock INT NOT NULL,
+					category VARCHAR(100),
+					description TEXT,
+					image_url VARCHAR(255),
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)`,
+
+				// C. Tabel Customers (Pembeli)
+				`CREATE TABLE IF NOT EXISTS customers (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					full_name VARCHAR(100) NOT NULL,
+					email VARCHAR(100) NOT NULL UNIQUE,
+					password VARCHAR(255) NOT NULL,
+					phone VARCHAR(20),
+					address TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)`,
+
+				// D. Tabel Carts (Keranjang Belanja)
+				`CREATE TABLE IF NOT EXISTS carts (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					customer_id INT NOT NULL,
+					product_id INT NOT NULL,
+					quantity INT NOT NULL DEFAULT 1,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+					FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+				)`,
+
+				// E. Tabel Orders
+				`CREATE TABLE IF NOT EXISTS orders (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					customer_id INT NOT NULL,
+					customer_name VARCHAR(255),
+					total_price DECIMAL(10,2) NOT NULL,
+					status VARCHAR(50) DEFAULT 'Pending',
+					payment_method VARCHAR(50),
+					snap_token VARCHAR(255), -- Buat Midtrans nanti
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE
+				)`,
+
+				// F. Tabel Order Items (Rincian Barang per Order)
+				`CREATE TABLE IF NOT EXISTS order_items (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					order_id INT NOT NULL,
+					product_id INT NOT NULL,
+					quantity INT NOT NULL,
+					price DECIMAL(10,2) NOT NULL, -- Harga saat beli (buat histori)
+					FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+					FOREIGN KEY (product_id) REFERENCES products(id)
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			// Urutan penting karena foreign key
+			return execAll(tx,
+				"DROP TABLE IF EXISTS order_items",
+				"DROP TABLE IF EXISTS orders",
+				"DROP TABLE IF EXISTS carts",
+				"DROP TABLE IF EXISTS customers",
+				"DROP TABLE IF EXISTS products",
+				"DROP TABLE IF EXISTS users",
+			)
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Database lama dibuat dari salah satu dari dua skema (auto-migrate atau /reset-db-now),
+// jadi ada kolom yang hilang di salah satunya. Migration ini nambahin yang kurang
+// dan nyamain tipe kolom, sehingga semua environment berakhir di skema yang sama.
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "reconcile_legacy_schema",
+		Up: func(tx *sql.Tx) error {
+			columns := []struct{ table, column, definition string }{
+				{"users", "role", "VARCHAR(50) NOT NULL DEFAULT 'admin'"},
+				{"products", "created_at", "TIMESTAMP DEFAULT CURRENT_TIMESTAMP"},
+				{"orders", "customer_name", "VARCHAR(255) AFTER customer_id"},
+				{"orders", "payment_method", "VARCHAR(50) AFTER status"},
+				{"orders", "snap_token", "VARCHAR(255) AFTER payment_method"},
+			}
+			for _, c := range columns {
+				if err := addColumnIfMissing(tx, c.table, c.column, c.definition); err != nil {
+					return err
+				}
+			}
+
+			// Skema auto-migrate pakai VARCHAR(20) & default 'pending' huruf kecil
+			if err := execAll(tx,
+				"ALTER TABLE orders MODIFY status VARCHAR(50) DEFAULT 'Pending'",
+				"UPDATE orders SET status = 'Pending' WHERE status = 'pending'",
+			); err != nil {
+				return err
+			}
+
+			// Skema /reset-db-now gak punya foreign key orders -> customers
+			exists, err := foreignKeyExists(tx, "orders", "customer_id", "customers")
+			if err != nil || exists {
+				return err
+			}
+
+			var orphans int
+			err = tx.QueryRow(`
+				SELECT COUNT(*) FROM orders o
+				LEFT JOIN customers c ON c.id = o.customer_id
+				WHERE c.id IS NULL`).Scan(&orphans)
+			if err != nil {
+				return err
+			}
+			if orphans > 0 {
+				return fmt.Errorf("ada %d order dengan customer_id yang tidak ada di tabel customers, bereskan dulu sebelum migrate", orphans)
+			}
+
+			_, err = tx.Exec("ALTER TABLE orders ADD FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE")
+			return err
+		},
+		Down: func(tx *sql.Tx) error {
+			// Sengaja kosong: kolom di atas juga bagian dari skema 0001,
+			// jadi gak ada yang aman buat dihapus di sini.
+			return nil
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import "database/sql"
+
+// Undangan akun staff: admin bikin token (yang disimpan cuma hash-nya),
+// calon staff pakai token itu buat set password sendiri.
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "user_invites",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx, `
+				CREATE TABLE IF NOT EXISTS user_invites (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					token_hash CHAR(64) NOT NULL UNIQUE,
+					email VARCHAR(100) NOT NULL,
+					role VARCHAR(50) NOT NULL,
+					created_by INT NOT NULL,
+					expires_at TIMESTAMP NOT NULL,
+					used_at TIMESTAMP NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+				)`)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, "DROP TABLE IF EXISTS user_invites")
+		},
+	})
+}
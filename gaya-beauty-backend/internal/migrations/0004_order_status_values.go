@@ -0,0 +1,29 @@
+package migrations
+
+import "database/sql"
+
+// Samain nilai status lama dengan state machine di package orders.
+// "Diproses" dari dashboard lama dianggap setara "Dikemas".
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "order_status_values",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"UPDATE orders SET status = 'Pending' WHERE status IS NULL OR status IN ('', 'pending')",
+				"UPDATE orders SET status = 'Lunas' WHERE status = 'paid'",
+				"UPDATE orders SET status = 'Dikemas' WHERE status = 'Diproses'",
+				"UPDATE orders SET status = 'Dikirim' WHERE status = 'shipped'",
+				"UPDATE orders SET status = 'Selesai' WHERE status = 'done'",
+				"UPDATE orders SET status = 'Dibatalkan' WHERE status = 'cancelled'",
+				"ALTER TABLE orders MODIFY status VARCHAR(50) NOT NULL DEFAULT 'Pending'",
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"ALTER TABLE orders MODIFY status VARCHAR(50) DEFAULT 'Pending'",
+				"UPDATE orders SET status = 'Diproses' WHERE status = 'Dikemas'",
+			)
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import "database/sql"
+
+// Riwayat perubahan status order: siapa (admin/customer/sistem), kapan, dari apa ke apa.
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "order_status_history",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx, `
+				CREATE TABLE IF NOT EXISTS order_status_history (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					order_id INT NOT NULL,
+					old_status VARCHAR(50) NULL, -- NULL = order baru dibuat
+					new_status VARCHAR(50) NOT NULL,
+					actor_type VARCHAR(20) NOT NULL, -- admin, customer, system
+					actor_id INT NULL,
+					note TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+					INDEX idx_order_status_history_order (order_id, created_at)
+				)`)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, "DROP TABLE IF EXISTS order_status_history")
+		},
+	})
+}
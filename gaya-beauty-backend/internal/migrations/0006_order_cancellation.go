@@ -0,0 +1,28 @@
+package migrations
+
+import "database/sql"
+
+// Alasan & waktu pembatalan order (stoknya dikembalikan oleh orders.Transition).
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "order_cancellation",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "orders", "cancel_reason", "TEXT NULL"); err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "orders", "cancelled_at", "TIMESTAMP NULL"); err != nil {
+				return err
+			}
+			// Buat job auto-cancel yang nyari order Pending lama
+			return execAll(tx, "CREATE INDEX idx_orders_status_created ON orders (status, created_at)")
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"DROP INDEX idx_orders_status_created ON orders",
+				"ALTER TABLE orders DROP COLUMN cancelled_at",
+				"ALTER TABLE orders DROP COLUMN cancel_reason",
+			)
+		},
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import "database/sql"
+
+// Satu produk cuma boleh satu baris per customer di keranjang (qty yang ditambah),
+// plus updated_at buat tahu kapan keranjang terakhir disentuh.
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "cart_unique_items",
+		Up: func(tx *sql.Tx) error {
+			// Gabungin baris dobel (kalau ada) sebelum pasang UNIQUE
+			if err := execAll(tx,
+				`CREATE TEMPORARY TABLE carts_merged AS
+					SELECT MIN(id) AS id, customer_id, product_id, SUM(quantity) AS quantity
+					FROM carts GROUP BY customer_id, product_id`,
+				`DELETE FROM carts WHERE id NOT IN (SELECT id FROM carts_merged)`,
+				`UPDATE carts c JOIN carts_merged m ON m.id = c.id SET c.quantity = m.quantity`,
+				`DROP TEMPORARY TABLE carts_merged`,
+			); err != nil {
+				return err
+			}
+
+			if err := addColumnIfMissing(tx, "carts", "updated_at",
+				"TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"); err != nil {
+				return err
+			}
+			return execAll(tx, "ALTER TABLE carts ADD UNIQUE KEY uq_carts_customer_product (customer_id, product_id)")
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"ALTER TABLE carts DROP INDEX uq_carts_customer_product",
+				"ALTER TABLE carts DROP COLUMN updated_at",
+			)
+		},
+	})
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "database/sql"
+
+// updated_at dipakai buat header Last-Modified/ETag di GET /products/{id}.
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "products_updated_at",
+		Up: func(tx *sql.Tx) error {
+			return addColumnIfMissing(tx, "products", "updated_at",
+				"TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP")
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, "ALTER TABLE products DROP COLUMN updated_at")
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import "database/sql"
+
+// Index buat filter & sort katalog di GET /products.
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "product_catalog_indexes",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"CREATE INDEX idx_products_category ON products (category)",
+				"CREATE INDEX idx_products_price ON products (price, id)",
+				"CREATE INDEX idx_order_items_product ON order_items (product_id, quantity)",
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"DROP INDEX idx_order_items_product ON order_items",
+				"DROP INDEX idx_products_price ON products",
+				"DROP INDEX idx_products_category ON products",
+			)
+		},
+	})
+}
@@ -0,0 +1,164 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kategori pindah dari teks bebas (products.category) ke tabel categories yang
+// bisa bertingkat (Makeup > Lips > Lipstick). Nilai lama yang cuma beda huruf
+// besar/spasi ("Skincare", "skin care", "SkinCare") digabung jadi satu kategori.
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "categories",
+		Up: func(tx *sql.Tx) error {
+			if err := execAll(tx,
+				`CREATE TABLE IF NOT EXISTS categories (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					parent_id INT NULL,
+					slug VARCHAR(120) NOT NULL UNIQUE,
+					name VARCHAR(100) NOT NULL,
+					sort_order INT NOT NULL DEFAULT 0,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+					INDEX idx_categories_parent (parent_id, sort_order),
+					FOREIGN KEY (parent_id) REFERENCES categories(id)
+				)`,
+			); err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "products", "category_id", "INT NULL"); err != nil {
+				return err
+			}
+			if err := execAll(tx,
+				"ALTER TABLE products ADD CONSTRAINT fk_products_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL",
+			); err != nil {
+				return err
+			}
+
+			if err := mapLegacyCategories(tx); err != nil {
+				return err
+			}
+
+			// Kolom teks lama dibuang biar sumber datanya cuma satu
+			return execAll(tx, "ALTER TABLE products DROP COLUMN category")
+		},
+		Down: func(tx *sql.Tx) error {
+			if err := execAll(tx,
+				"ALTER TABLE products ADD COLUMN category VARCHAR(100) AFTER stock",
+				"UPDATE products p JOIN categories c ON c.id = p.category_id SET p.category = c.name",
+				"CREATE INDEX idx_products_category ON products (category)",
+				"ALTER TABLE products DROP FOREIGN KEY fk_products_category",
+				"ALTER TABLE products DROP COLUMN category_id",
+			); err != nil {
+				return err
+			}
+			return execAll(tx, "DROP TABLE categories")
+		},
+	})
+}
+
+// Kategori bawaan (sama kayak pilihan di form tambah produk admin)
+var defaultCategories = []string{"Skincare", "Makeup", "Bodycare", "Hairstyle", "Lainnya"}
+
+// mapLegacyCategories bikin satu baris categories per nilai teks lama (setelah
+// dinormalisasi) lalu isi products.category_id.
+func mapLegacyCategories(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT TRIM(category), COUNT(*) FROM products
+		WHERE category IS NOT NULL AND TRIM(category) <> ''
+		GROUP BY TRIM(category) ORDER BY COUNT(*) DESC, TRIM(category)`)
+	if err != nil {
+		return err
+	}
+
+	type group struct {
+		name     string   // nama tampilan = variasi yang paling banyak dipakai
+		variants []string // semua tulisan lama yang masuk grup ini
+	}
+	var order []string
+	groups := make(map[string]*group)
+	for rows.Next() {
+		var raw string
+		var count int
+		if err := rows.Scan(&raw, &count); err != nil {
+			rows.Close()
+			return err
+		}
+		key := categoryKey(raw)
+		if key == "" {
+			continue
+		}
+		if groups[key] == nil {
+			groups[key] = &group{name: raw}
+			order = append(order, key)
+		}
+		groups[key].variants = append(groups[key].variants, raw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range defaultCategories {
+		if key := categoryKey(name); groups[key] == nil {
+			groups[key] = &group{name: name}
+			order = append(order, key)
+		}
+	}
+
+	usedSlugs := make(map[string]bool)
+	for i, key := range order {
+		g := groups[key]
+		slug := migrationSlug(g.name)
+		for n := 2; usedSlugs[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", migrationSlug(g.name), n)
+		}
+		usedSlugs[slug] = true
+
+		res, err := tx.Exec("INSERT INTO categories (slug, name, sort_order) VALUES (?, ?, ?)", slug, g.name, i)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, v := range g.variants {
+			if _, err := tx.Exec("UPDATE products SET category_id = ? WHERE TRIM(category) = ?", id, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// categoryKey: huruf kecil + cuma huruf/angka, jadi "Skin Care" == "skincare"
+func categoryKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Sengaja disalin (bukan import dari handlers) biar hasil migration gak berubah
+// kalau aturan slug di aplikasi nanti diganti.
+func migrationSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import "database/sql"
+
+// Varian produk (shade, ukuran, dst.) dengan stok & harga sendiri.
+// Keranjang dan order_items ikut nyimpen varian yang dipilih.
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "product_variants",
+		Up: func(tx *sql.Tx) error {
+			if err := execAll(tx,
+				`CREATE TABLE IF NOT EXISTS product_variants (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					product_id INT NOT NULL,
+					sku VARCHAR(64) NOT NULL UNIQUE,
+					options TEXT NOT NULL, -- JSON, contoh {"shade":"02 Ivory","size":"30ml"}
+					price DECIMAL(10,2) NULL, -- NULL = ikut harga produk
+					stock INT NOT NULL DEFAULT 0,
+					swatch_url VARCHAR(255) NULL,
+					sort_order INT NOT NULL DEFAULT 0,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+					INDEX idx_product_variants_product (product_id, sort_order),
+					FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+				)`,
+			); err != nil {
+				return err
+			}
+
+			// Di keranjang 0 = produk tanpa varian (bukan NULL, biar UNIQUE tetap jalan)
+			if err := addColumnIfMissing(tx, "carts", "variant_id", "INT NOT NULL DEFAULT 0 AFTER product_id"); err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "order_items", "variant_id", "INT NULL AFTER product_id"); err != nil {
+				return err
+			}
+			return execAll(tx,
+				// Index baru dulu biar FK carts.customer_id tetap punya index waktu yang lama dibuang
+				"ALTER TABLE carts ADD UNIQUE KEY uq_carts_customer_product_variant (customer_id, product_id, variant_id)",
+				"ALTER TABLE carts DROP INDEX uq_carts_customer_product",
+				"ALTER TABLE order_items ADD CONSTRAINT fk_order_items_variant FOREIGN KEY (variant_id) REFERENCES product_variants(id) ON DELETE SET NULL",
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"ALTER TABLE order_items DROP FOREIGN KEY fk_order_items_variant",
+				"ALTER TABLE order_items DROP COLUMN variant_id",
+				// Baris keranjang beda varian digabung lagi jadi satu per produk
+				`DELETE c1 FROM carts c1 JOIN carts c2
+					ON c1.customer_id = c2.customer_id AND c1.product_id = c2.product_id AND c1.id > c2.id`,
+				"ALTER TABLE carts ADD UNIQUE KEY uq_carts_customer_product (customer_id, product_id)",
+				"ALTER TABLE carts DROP INDEX uq_carts_customer_product_variant",
+				"ALTER TABLE carts DROP COLUMN variant_id",
+				"DROP TABLE product_variants",
+			)
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import "database/sql"
+
+// Satu produk bisa punya banyak foto, urutannya diatur sort_order.
+// Foto urutan pertama juga disalin ke products.image_url (cover di katalog).
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "product_images",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS product_images (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					product_id INT NOT NULL,
+					storage_key VARCHAR(255) NOT NULL, -- nama file di storage (buat hapus)
+					url VARCHAR(500) NOT NULL,
+					content_type VARCHAR(50) NOT NULL,
+					size_bytes INT NOT NULL,
+					sort_order INT NOT NULL DEFAULT 0,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					INDEX idx_product_images_product (product_id, sort_order),
+					FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+				)`,
+				"ALTER TABLE products MODIFY image_url VARCHAR(500)",
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			// image_url sengaja gak dikecilin lagi, URL panjang bisa kepotong
+			return execAll(tx, "DROP TABLE product_images")
+		},
+	})
+}
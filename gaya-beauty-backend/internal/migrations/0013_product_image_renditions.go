@@ -0,0 +1,56 @@
+package migrations
+
+import "database/sql"
+
+// Tiap foto produk disimpan dalam beberapa ukuran (thumb, card, zoom) x format
+// (webp + jpeg). File asli dari HP gak disimpan lagi, jadi kolom file di
+// product_images pindah ke tabel ini.
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "product_image_renditions",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS product_image_renditions (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					image_id INT NOT NULL,
+					size VARCHAR(16) NOT NULL,   -- thumb, card, zoom
+					format VARCHAR(16) NOT NULL, -- webp, jpeg (foto lama: format aslinya)
+					storage_key VARCHAR(255) NOT NULL,
+					url VARCHAR(500) NOT NULL,
+					width INT NOT NULL DEFAULT 0, -- 0 = gak diketahui (foto lama)
+					height INT NOT NULL DEFAULT 0,
+					size_bytes INT NOT NULL DEFAULT 0,
+					UNIQUE KEY uq_product_image_renditions (image_id, size, format),
+					FOREIGN KEY (image_id) REFERENCES product_images(id) ON DELETE CASCADE
+				)`,
+				// Foto yang di-upload sebelum ada resize: file aslinya dipakai buat semua ukuran
+				`INSERT INTO product_image_renditions (image_id, size, format, storage_key, url, size_bytes)
+					SELECT i.id, s.size, SUBSTRING_INDEX(i.content_type, '/', -1), i.storage_key, i.url, i.size_bytes
+					FROM product_images i
+					CROSS JOIN (SELECT 'thumb' AS size UNION ALL SELECT 'card' UNION ALL SELECT 'zoom') s`,
+				`ALTER TABLE product_images
+					DROP COLUMN storage_key,
+					DROP COLUMN url,
+					DROP COLUMN content_type,
+					DROP COLUMN size_bytes`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`ALTER TABLE product_images
+					ADD COLUMN storage_key VARCHAR(255) NOT NULL DEFAULT '' AFTER product_id,
+					ADD COLUMN url VARCHAR(500) NOT NULL DEFAULT '' AFTER storage_key,
+					ADD COLUMN content_type VARCHAR(50) NOT NULL DEFAULT '' AFTER url,
+					ADD COLUMN size_bytes INT NOT NULL DEFAULT 0 AFTER content_type`,
+				// Ambil ukuran zoom; JPEG didahulukan, WebP cuma kalau gak ada yang lain
+				`UPDATE product_images i JOIN product_image_renditions r ON r.image_id = i.id AND r.size = 'zoom'
+					SET i.storage_key = r.storage_key, i.url = r.url, i.content_type = CONCAT('image/', r.format), i.size_bytes = r.size_bytes`,
+				`UPDATE product_images i JOIN product_image_renditions r ON r.image_id = i.id AND r.size = 'zoom' AND r.format <> 'webp'
+					SET i.storage_key = r.storage_key, i.url = r.url, i.content_type = CONCAT('image/', r.format), i.size_bytes = r.size_bytes`,
+				// File ukuran lain ditinggal di storage (jadi sampah, tapi data tetap utuh)
+				"DROP TABLE product_image_renditions",
+			)
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import "database/sql"
+
+// Produk gak dihapus permanen lagi (order_items masih nunjuk ke situ),
+// tapi diarsip: status draft/active/archived + deleted_at.
+// Nama produk juga disalin ke order_items biar riwayat order tetap kebaca.
+func init() {
+	register(Migration{
+		Version: 14,
+		Name:    "product_status",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "products", "status", "VARCHAR(16) NOT NULL DEFAULT 'active' AFTER image_url"); err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "products", "deleted_at", "TIMESTAMP NULL DEFAULT NULL AFTER status"); err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "order_items", "product_name", "VARCHAR(255) NULL AFTER variant_id"); err != nil {
+				return err
+			}
+			return execAll(tx,
+				// Katalog publik selalu filter status = 'active'
+				"CREATE INDEX idx_products_status ON products (status, id)",
+				`UPDATE order_items oi JOIN products p ON p.id = oi.product_id
+					SET oi.product_name = p.name
+					WHERE oi.product_name IS NULL`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"ALTER TABLE order_items DROP COLUMN product_name",
+				"DROP INDEX idx_products_status ON products",
+				"ALTER TABLE products DROP COLUMN deleted_at",
+				"ALTER TABLE products DROP COLUMN status",
+			)
+		},
+	})
+}
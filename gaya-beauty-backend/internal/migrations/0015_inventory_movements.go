@@ -0,0 +1,45 @@
+package migrations
+
+import "database/sql"
+
+// Buku besar stok: tiap perubahan stok (jual, batal, restock, koreksi, rusak,
+// kadaluarsa) dicatat +/- qty, alasannya, dan order/user yang bikin.
+// Stok sekarang harusnya = total quantity semua baris unit itu.
+func init() {
+	register(Migration{
+		Version: 15,
+		Name:    "inventory_movements",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS inventory_movements (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					product_id INT NOT NULL,
+					variant_id INT NOT NULL DEFAULT 0, -- 0 = produk tanpa varian (varian bisa dihapus, jadi tanpa FK)
+					quantity INT NOT NULL,             -- + masuk, - keluar
+					stock_after INT NOT NULL,
+					reason VARCHAR(20) NOT NULL,
+					order_id INT NULL,
+					user_id INT NULL, -- admin yang ngubah (NULL = sistem / customer checkout)
+					note VARCHAR(255) NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					INDEX idx_inventory_movements_unit (product_id, variant_id, id),
+					INDEX idx_inventory_movements_created (created_at),
+					FOREIGN KEY (product_id) REFERENCES products(id),
+					FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL,
+					FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+				)`,
+				// Saldo awal = stok yang ada sekarang (produk bervarian dicatat per varian)
+				`INSERT INTO inventory_movements (product_id, variant_id, quantity, stock_after, reason, note)
+					SELECT p.id, 0, p.stock, p.stock, 'initial', 'Saldo awal ledger'
+					FROM products p
+					WHERE NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)`,
+				`INSERT INTO inventory_movements (product_id, variant_id, quantity, stock_after, reason, note)
+					SELECT v.product_id, v.id, v.stock, v.stock, 'initial', 'Saldo awal ledger'
+					FROM product_variants v`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, "DROP TABLE inventory_movements")
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import "database/sql"
+
+// Batas restock per produk / varian + tabel notifikasi admin.
+// reorder_level NULL = ikut default (varian ikut produk, produk ikut config).
+// open_key diisi selama notifikasinya belum selesai, UNIQUE biar checker yang
+// jalan barengan gak bikin peringatan dobel buat stok yang sama.
+func init() {
+	register(Migration{
+		Version: 16,
+		Name:    "low_stock_alerts",
+		Up: func(tx *sql.Tx) error {
+			if err := addColumnIfMissing(tx, "products", "reorder_level", "INT NULL"); err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "product_variants", "reorder_level", "INT NULL"); err != nil {
+				return err
+			}
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS admin_notifications (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					kind VARCHAR(32) NOT NULL,
+					product_id INT NULL,
+					variant_id INT NOT NULL DEFAULT 0,
+					title VARCHAR(255) NOT NULL,
+					message TEXT NOT NULL,
+					stock INT NULL,
+					threshold INT NULL,
+					open_key VARCHAR(100) NULL,
+					acknowledged_at TIMESTAMP NULL,
+					acknowledged_by INT NULL,
+					resolved_at TIMESTAMP NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					UNIQUE KEY uq_admin_notifications_open (open_key),
+					INDEX idx_admin_notifications_product (product_id, variant_id),
+					FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+					FOREIGN KEY (acknowledged_by) REFERENCES users(id) ON DELETE SET NULL
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"DROP TABLE admin_notifications",
+				"ALTER TABLE product_variants DROP COLUMN reorder_level",
+				"ALTER TABLE products DROP COLUMN reorder_level",
+			)
+		},
+	})
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import "database/sql"
+
+// Stok per batch produksi (kode batch BPOM + tanggal kadaluarsa).
+// Stok produk/varian = total quantity_remaining semua batch unit itu.
+// batch_code kosong ("") = stok tanpa info batch (stok lama / diisi dari form produk).
+// Ledger stok ikut nyatet batch mana yang berubah.
+func init() {
+	register(Migration{
+		Version: 17,
+		Name:    "stock_batches",
+		Up: func(tx *sql.Tx) error {
+			err := execAll(tx,
+				`CREATE TABLE IF NOT EXISTS stock_batches (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					product_id INT NOT NULL,
+					variant_id INT NOT NULL DEFAULT 0, -- 0 = produk tanpa varian (sama kayak inventory_movements)
+					batch_code VARCHAR(64) NOT NULL,
+					expiry_date DATE NULL,             -- NULL = gak ada tanggal kadaluarsa
+					quantity_received INT NOT NULL DEFAULT 0,
+					quantity_remaining INT NOT NULL DEFAULT 0,
+					received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					UNIQUE KEY uq_stock_batches_code (product_id, variant_id, batch_code),
+					INDEX idx_stock_batches_expiry (expiry_date),
+					FOREIGN KEY (product_id) REFERENCES products(id)
+				)`,
+			)
+			if err != nil {
+				return err
+			}
+			if err := addColumnIfMissing(tx, "inventory_movements", "batch_id", "INT NULL AFTER variant_id"); err != nil {
+				return err
+			}
+			exists, err := foreignKeyExists(tx, "inventory_movements", "batch_id", "stock_batches")
+			if err != nil {
+				return err
+			}
+			if !exists {
+				if err := execAll(tx, `ALTER TABLE inventory_movements ADD CONSTRAINT fk_inventory_movements_batch
+					FOREIGN KEY (batch_id) REFERENCES stock_batches(id) ON DELETE SET NULL`); err != nil {
+					return err
+				}
+			}
+
+			// Stok yang ada sekarang masuk batch tanpa kode
+			return execAll(tx,
+				`INSERT IGNORE INTO stock_batches (product_id, variant_id, batch_code, quantity_received, quantity_remaining)
+					SELECT p.id, 0, '', p.stock, p.stock
+					FROM products p
+					WHERE p.stock > 0 AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)`,
+				`INSERT IGNORE INTO stock_batches (product_id, variant_id, batch_code, quantity_received, quantity_remaining)
+					SELECT v.product_id, v.id, '', v.stock, v.stock
+					FROM product_variants v
+					WHERE v.stock > 0`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"ALTER TABLE inventory_movements DROP FOREIGN KEY fk_inventory_movements_batch",
+				"ALTER TABLE inventory_movements DROP COLUMN batch_id",
+				"DROP TABLE stock_batches",
+			)
+		},
+	})
+}
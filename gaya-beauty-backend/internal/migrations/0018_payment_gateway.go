@@ -0,0 +1,53 @@
+package migrations
+
+import "database/sql"
+
+// Pembayaran online lewat payment gateway (lihat package payment).
+// orders.snap_token (dari skema awal) akhirnya dipakai buat token sesi bayar.
+// Semua notifikasi dari gateway disimpan apa adanya, termasuk yang ditolak.
+func init() {
+	register(Migration{
+		Version: 18,
+		Name:    "payment_gateway",
+		Up: func(tx *sql.Tx) error {
+			columns := []struct{ name, definition string }{
+				{"payment_provider", "VARCHAR(20) NULL AFTER payment_method"},
+				{"payment_redirect_url", "VARCHAR(255) NULL AFTER snap_token"},
+				{"payment_reference", "VARCHAR(100) NULL AFTER payment_redirect_url"}, // ID transaksi di gateway
+				{"paid_at", "TIMESTAMP NULL AFTER payment_reference"},
+			}
+			for _, c := range columns {
+				if err := addColumnIfMissing(tx, "orders", c.name, c.definition); err != nil {
+					return err
+				}
+			}
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS payment_notifications (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					provider VARCHAR(20) NOT NULL,
+					order_ref VARCHAR(64) NULL,  -- order_id versi gateway, contoh "GB-123"
+					order_id INT NULL,
+					transaction_id VARCHAR(100) NULL,
+					transaction_status VARCHAR(32) NULL, -- Status asli dari gateway
+					status VARCHAR(16) NULL,             -- paid, pending, failed, refunded, unknown
+					amount DECIMAL(15,2) NULL,
+					signature_valid BOOLEAN NOT NULL DEFAULT FALSE,
+					result VARCHAR(32) NOT NULL,         -- Apa yang dilakukan server (lihat payment.Result)
+					payload TEXT NOT NULL,
+					received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					INDEX idx_payment_notifications_order (order_id, id),
+					FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"DROP TABLE payment_notifications",
+				"ALTER TABLE orders DROP COLUMN paid_at",
+				"ALTER TABLE orders DROP COLUMN payment_reference",
+				"ALTER TABLE orders DROP COLUMN payment_redirect_url",
+				"ALTER TABLE orders DROP COLUMN payment_provider",
+			)
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package migrations
+
+import "database/sql"
+
+// Transfer bank manual: nominal unik per order (total + kode 3 digit) dan bukti
+// transfer yang di-upload customer buat dicek admin (lihat package transfer).
+// open_transfer_amount cuma diisi selama order Pending; UNIQUE-nya yang jamin
+// gak ada dua order menunggu bayar dengan nominal sama.
+func init() {
+	register(Migration{
+		Version: 19,
+		Name:    "bank_transfer",
+		Up: func(tx *sql.Tx) error {
+			columns := []struct{ name, definition string }{
+				{"unique_code", "SMALLINT NULL AFTER total_price"},
+				{"transfer_amount", "DECIMAL(10,2) NULL AFTER unique_code"}, // total_price + unique_code
+				{"open_transfer_amount", "DECIMAL(10,2) NULL AFTER transfer_amount"},
+			}
+			for _, c := range columns {
+				if err := addColumnIfMissing(tx, "orders", c.name, c.definition); err != nil {
+					return err
+				}
+			}
+			return execAll(tx,
+				"ALTER TABLE orders ADD UNIQUE KEY uq_orders_open_transfer_amount (open_transfer_amount)",
+				`CREATE TABLE IF NOT EXISTS transfer_proofs (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					order_id INT NOT NULL,
+					customer_id INT NOT NULL,
+					sender_name VARCHAR(100) NOT NULL, -- Nama pemilik rekening pengirim
+					sender_bank VARCHAR(50) NULL,
+					image_key VARCHAR(255) NOT NULL, -- Key di storage privat, fotonya cuma lewat API
+					status VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+					reject_reason VARCHAR(255) NULL,
+					reviewed_by INT NULL,
+					reviewed_at TIMESTAMP NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					INDEX idx_transfer_proofs_status (status, id),
+					INDEX idx_transfer_proofs_order (order_id, id),
+					FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+					FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+					FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"DROP TABLE transfer_proofs",
+				"ALTER TABLE orders DROP INDEX uq_orders_open_transfer_amount",
+				"ALTER TABLE orders DROP COLUMN open_transfer_amount",
+				"ALTER TABLE orders DROP COLUMN transfer_amount",
+				"ALTER TABLE orders DROP COLUMN unique_code",
+			)
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import "database/sql"
+
+// Import mutasi rekening (CSV) buat dicocokin otomatis ke order transfer yang
+// masih Pending. Tiap baris mutasi disimpan bareng usulan order-nya; admin
+// tinggal konfirmasi. fingerprint UNIQUE biar file yang sama di-import dua
+// kali gak bikin baris dobel.
+func init() {
+	register(Migration{
+		Version: 20,
+		Name:    "bank_statement_reconciliation",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS bank_statement_imports (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					filename VARCHAR(255) NOT NULL,
+					window_days INT NOT NULL, -- Jarak maksimal tanggal order ke tanggal transfer
+					line_count INT NOT NULL DEFAULT 0,
+					uploaded_by INT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+				)`,
+				`CREATE TABLE IF NOT EXISTS bank_statement_lines (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					import_id INT NOT NULL,
+					line_no INT NOT NULL, -- Nomor baris di file CSV
+					txn_date DATE NOT NULL,
+					description VARCHAR(255) NOT NULL,
+					amount DECIMAL(15,2) NOT NULL,
+					fingerprint CHAR(64) NOT NULL,
+					status VARCHAR(16) NOT NULL, -- matched, ambiguous, unmatched, confirmed, ignored
+					order_id INT NULL,           -- Usulan (matched) atau yang dikonfirmasi (confirmed)
+					candidate_order_ids VARCHAR(255) NULL, -- Buat yang ambiguous, dipisah koma
+					note VARCHAR(255) NULL,
+					resolved_by INT NULL,
+					resolved_at TIMESTAMP NULL,
+					UNIQUE KEY uq_bank_statement_lines_fingerprint (fingerprint),
+					INDEX idx_bank_statement_lines_status (status, id),
+					INDEX idx_bank_statement_lines_import (import_id, line_no),
+					FOREIGN KEY (import_id) REFERENCES bank_statement_imports(id) ON DELETE CASCADE,
+					FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL,
+					FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+				)`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx,
+				"DROP TABLE bank_statement_lines",
+				"DROP TABLE bank_statement_imports",
+			)
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import "database/sql"
+
+// Tabel permission per role (owner/admin/staff/packer). Dibaca ulang tiap
+// request bareng role user-nya, jadi ngubah akses cukup INSERT/DELETE baris di
+// sini tanpa deploy. Isi awalnya sama persis dengan aturan sebelumnya yang
+// masih ditulis di kode. Customer gak punya baris = gak punya akses admin.
+func init() {
+	register(Migration{
+		Version: 21,
+		Name:    "role_permissions",
+		Up: func(tx *sql.Tx) error {
+			return execAll(tx,
+				`CREATE TABLE IF NOT EXISTS role_permissions (
+					role VARCHAR(50) NOT NULL,
+					permission VARCHAR(50) NOT NULL,
+					PRIMARY KEY (role, permission)
+				)`,
+				`INSERT IGNORE INTO role_permissions (role, permission) VALUES
+					('owner', 'orders:read'), ('owner', 'orders:update'),
+					('owner', 'products:write'), ('owner', 'products:delete'),
+					('owner', 'users:invite'),
+					('admin', 'orders:read'), ('admin', 'orders:update'),
+					('admin', 'products:write'), ('admin', 'products:delete'),
+					('admin', 'users:invite'),
+					('staff', 'orders:read'), ('staff', 'orders:update'),
+					('staff', 'products:write'),
+					('packer', 'orders:read'), ('packer', 'orders:update')`,
+			)
+		},
+		Down: func(tx *sql.Tx) error {
+			return execAll(tx, "DROP TABLE IF EXISTS role_permissions")
+		},
+	})
+}
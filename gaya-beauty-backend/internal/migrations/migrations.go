@@ -0,0 +1,224 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migration = satu langkah perubahan skema. Version harus unik & naik terus,
+// jangan pernah ubah isi migration yang sudah jalan di server, bikin yang baru aja.
+//
+// Catatan MySQL: perintah DDL (CREATE/ALTER/DROP) auto-commit, jadi transaksi
+// di sini cuma benar-benar atomik buat perubahan data (INSERT/UPDATE).
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+	Down    func(tx *sql.Tx) error
+}
+
+var registry []Migration
+
+// register dipanggil dari init() tiap file migration.
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// All balikin semua migration urut dari versi paling kecil.
+func All() []Migration {
+	list := make([]Migration, len(registry))
+	copy(list, registry)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+func ensureTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// Applied balikin versi yang sudah pernah dijalankan beserta waktunya.
+func Applied(db *sql.DB) (map[int]time.Time, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// Up jalanin semua migration yang belum pernah dijalankan, urut dari versi kecil.
+func Up(db *sql.DB) (int, error) {
+	applied, err := Applied(db)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range All() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return count, err
+		}
+		if err := m.Up(tx); err != nil {
+			tx.Rollback()
+			return count, fmt.Errorf("migration %04d_%s gagal: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			tx.Rollback()
+			return count, err
+		}
+		if err := tx.Commit(); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down rollback sejumlah `steps` migration terakhir yang sudah jalan.
+func Down(db *sql.DB, steps int) (int, error) {
+	applied, err := Applied(db)
+	if err != nil {
+		return 0, err
+	}
+
+	all := All()
+	count := 0
+	for i := len(all) - 1; i >= 0 && count < steps; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return count, err
+		}
+		if err := m.Down(tx); err != nil {
+			tx.Rollback()
+			return count, fmt.Errorf("rollback %04d_%s gagal: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+			tx.Rollback()
+			return count, err
+		}
+		if err := tx.Commit(); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Status tulis daftar migration + kapan dijalankan (atau "pending").
+func Status(db *sql.DB, w io.Writer) error {
+	applied, err := Applied(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range All() {
+		state := "pending"
+		if at, ok := applied[m.Version]; ok {
+			state = "applied " + at.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d  %-40s %s\n", m.Version, m.Name, state)
+	}
+	return nil
+}
+
+// Run = entry point subcommand CLI: `migrate up|down [n]|status`.
+func Run(db *sql.DB, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("pemakaian: migrate up|down [n]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := Up(db)
+		fmt.Fprintf(w, "%d migration dijalankan\n", n)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v < 1 {
+				return fmt.Errorf("jumlah langkah down tidak valid: %q", args[1])
+			}
+			steps = v
+		}
+		n, err := Down(db, steps)
+		fmt.Fprintf(w, "%d migration di-rollback\n", n)
+		return err
+	case "status":
+		return Status(db, w)
+	default:
+		return fmt.Errorf("subcommand tidak dikenal: %q (pakai up|down|status)", args[0])
+	}
+}
+
+// execAll jalanin beberapa query berurutan, berhenti di error pertama.
+func execAll(tx *sql.Tx, queries ...string) error {
+	for _, q := range queries {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`,
+		table, column).Scan(&n)
+	return n > 0, err
+}
+
+// addColumnIfMissing dipakai buat nyamain database lama yang dibuat sebelum ada migration.
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+func foreignKeyExists(tx *sql.Tx, table, column, refTable string) (bool, error) {
+	var n int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ? AND REFERENCED_TABLE_NAME = ?`,
+		table, column, refTable).Scan(&n)
+	return n > 0, err
+}
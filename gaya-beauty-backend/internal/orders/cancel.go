@@ -0,0 +1,141 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gaya-beauty-backend/internal/inventory"
+	"log"
+	"time"
+)
+
+// restoreStock balikin qty semua item order ke products.stock (plus stok varian kalau ada)
+// dan catat di ledger stok; qty-nya balik ke batch yang dulu dipakai order ini.
+// Dipanggil dari Transition, jadi ikut transaksi perubahan status.
+func restoreStock(tx *sql.Tx, orderID int, to Status, actor Actor) error {
+	rows, err := tx.Query("SELECT product_id, variant_id, quantity FROM order_items WHERE order_id = ? ORDER BY product_id", orderID)
+	if err != nil {
+		return err
+	}
+
+	type item struct {
+		productID int
+		variantID sql.NullInt64
+		quantity  int
+	}
+	var items []item
+	for rows.Next() {
+		var it item
+		if err := rows.Scan(&it.productID, &it.variantID, &it.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		items = append(items, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, it := range items {
+		if _, err := tx.Exec("UPDATE products SET stock = stock + ? WHERE id = ?", it.quantity, it.productID); err != nil {
+			return err
+		}
+		if it.variantID.Valid {
+			if _, err := tx.Exec("UPDATE product_variants SET stock = stock + ? WHERE id = ?", it.quantity, it.variantID.Int64); err != nil {
+				return err
+			}
+		}
+
+		m := inventory.Movement{
+			ProductID: it.productID,
+			VariantID: int(it.variantID.Int64),
+			Quantity:  it.quantity,
+			Reason:    inventory.ReasonCancellation,
+			OrderID:   orderID,
+			Note:      "Order " + string(to),
+		}
+		if actor.Type == ActorAdmin {
+			m.UserID = actor.ID
+		}
+		if err := inventory.Record(tx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel batalin order + balikin stok dalam satu transaksi.
+// Customer cuma bisa kalau masih Pending, admin selama belum dikirim
+// (aturannya ada di tabel transitions).
+func Cancel(db *sql.DB, orderID int, actor Actor, reason string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := Transition(tx, orderID, StatusCancelled, actor, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelExpiredPending batalin order transfer yang masih Pending lebih lama dari timeout.
+// COD gak ikut karena memang dibayar belakangan, order yang bukti transfernya
+// lagi dicek admin juga dilewati.
+func CancelExpiredPending(db *sql.DB, timeout time.Duration) (int, error) {
+	// Pakai jam database (NOW()) biar gak kena beda timezone server vs DB
+	rows, err := db.Query(`SELECT id FROM orders
+		WHERE status = ? AND created_at < NOW() - INTERVAL ? SECOND
+		AND UPPER(COALESCE(payment_method, '')) <> 'COD'
+		AND NOT EXISTS (SELECT 1 FROM transfer_proofs p WHERE p.order_id = orders.id AND p.status = 'pending')`,
+		StatusPending, int(timeout.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	reason := fmt.Sprintf("Otomatis dibatalkan: belum dibayar dalam %s", timeout)
+	count := 0
+	for _, id := range ids {
+		err := Cancel(db, id, Actor{Type: ActorSystem}, reason)
+		if err != nil {
+			// Bisa jadi statusnya keburu berubah (misal baru dibayar), lanjut aja
+			log.Printf("Auto-cancel order %d dilewati: %v", id, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// StartAutoCancel jalanin CancelExpiredPending tiap `interval` sampai ctx selesai.
+func StartAutoCancel(ctx context.Context, db *sql.DB, timeout, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := CancelExpiredPending(db, timeout)
+			if err != nil {
+				log.Println("Auto-cancel gagal:", err)
+			} else if n > 0 {
+				log.Printf("Auto-cancel: %d order Pending dibatalkan", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package orders
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Satu baris riwayat status order
+type HistoryEntry struct {
+	ID        int       `json:"id"`
+	OldStatus *Status   `json:"old_status"`
+	NewStatus Status    `json:"new_status"`
+	ActorType ActorType `json:"actor_type"`
+	ActorID   *int      `json:"actor_id"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func writeHistory(tx *sql.Tx, orderID int, from *Status, to Status, actor Actor, note string) error {
+	var actorID interface{}
+	if actor.ID != 0 {
+		actorID = actor.ID
+	}
+	var old interface{}
+	if from != nil {
+		old = *from
+	}
+	_, err := tx.Exec(`INSERT INTO order_status_history (order_id, old_status, new_status, actor_type, actor_id, note)
+		VALUES (?, ?, ?, ?, ?, ?)`, orderID, old, to, actor.Type, actorID, note)
+	return err
+}
+
+// RecordCreated catat status awal order baru (dipanggil di transaksi checkout).
+func RecordCreated(tx *sql.Tx, orderID int, status Status, actor Actor) error {
+	return writeHistory(tx, orderID, nil, status, actor, "")
+}
+
+// History ambil riwayat status satu order, urut dari paling lama.
+func History(db *sql.DB, orderID int) ([]HistoryEntry, error) {
+	rows, err := db.Query(`SELECT id, old_status, new_status, actor_type, actor_id, note, created_at
+		FROM order_status_history WHERE order_id = ? ORDER BY created_at, id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []HistoryEntry{}
+	for rows.Next() {
+		var e HistoryEntry
+		var old, note sql.NullString
+		var actorID sql.NullInt64
+		if err := rows.Scan(&e.ID, &old, &e.NewStatus, &e.ActorType, &actorID, &note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if old.Valid {
+			s := Status(old.String)
+			e.OldStatus = &s
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			e.ActorID = &id
+		}
+		e.Note = note.String
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,182 @@
+package orders
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status pesanan. Nilainya yang disimpan di kolom orders.status
+// dan yang tampil di dashboard, jadi pakai bahasa yang dipakai toko.
+type Status string
+
+const (
+	StatusPending   Status = "Pending"
+	StatusPaid      Status = "Lunas"
+	StatusPacked    Status = "Dikemas"
+	StatusShipped   Status = "Dikirim"
+	StatusDelivered Status = "Selesai"
+	StatusCancelled Status = "Dibatalkan"
+	StatusRefunded  Status = "Refund"
+)
+
+// Urutan lifecycle buat ditampilin di dashboard
+var AllStatuses = []Status{
+	StatusPending, StatusPaid, StatusPacked, StatusShipped,
+	StatusDelivered, StatusCancelled, StatusRefunded,
+}
+
+// Tabel perpindahan status yang sah (dari -> boleh ke mana aja).
+// Dibatalkan & Refund = status akhir, gak bisa ke mana-mana lagi.
+var transitions = map[Status][]Status{
+	StatusPending:   {StatusPaid, StatusPacked, StatusCancelled},
+	StatusPaid:      {StatusPacked, StatusCancelled, StatusRefunded},
+	StatusPacked:    {StatusShipped, StatusCancelled, StatusRefunded},
+	StatusShipped:   {StatusDelivered, StatusRefunded},
+	StatusDelivered: {StatusRefunded},
+}
+
+// Pindah yang boleh dilakukan customer sendiri (sisanya cuma admin):
+// batalin selama masih Pending, dan konfirmasi terima barang.
+var customerTransitions = map[Status][]Status{
+	StatusPending: {StatusCancelled},
+	StatusShipped: {StatusDelivered},
+}
+
+// Status sebelum barang keluar gudang (stok masih bisa dikembalikan)
+func isPreShipment(s Status) bool {
+	return s == StatusPending || s == StatusPaid || s == StatusPacked
+}
+
+// Siapa yang ngubah status
+type ActorType string
+
+const (
+	ActorAdmin    ActorType = "admin"
+	ActorCustomer ActorType = "customer"
+	ActorSystem   ActorType = "system"
+)
+
+type Actor struct {
+	Type ActorType
+	ID   int
+}
+
+var (
+	ErrOrderNotFound = errors.New("pesanan tidak ditemukan")
+	ErrUnknownStatus = errors.New("status tidak dikenal")
+)
+
+// TransitionError = perpindahan status yang gak sah (dibalas 409 di handler).
+type TransitionError struct {
+	From   Status
+	To     Status
+	Reason string
+}
+
+func (e *TransitionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("status tidak bisa diubah dari %s ke %s: %s", e.From, e.To, e.Reason)
+	}
+	return fmt.Sprintf("status tidak bisa diubah dari %s ke %s", e.From, e.To)
+}
+
+func IsValid(s Status) bool {
+	for _, v := range AllStatuses {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses balikin status tujuan yang sah dari status sekarang.
+func NextStatuses(from Status) []Status {
+	return transitions[from]
+}
+
+func contains(list []Status, s Status) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isCOD: pembayaran COD lunas pas barang sampai, jadi boleh langsung dikemas.
+func isCOD(paymentMethod string) bool {
+	return strings.EqualFold(strings.TrimSpace(paymentMethod), "COD")
+}
+
+// CanTransition cek aturan state machine tanpa nyentuh database.
+func CanTransition(from, to Status, actor Actor, paymentMethod string) error {
+	if !IsValid(to) {
+		return ErrUnknownStatus
+	}
+
+	allowed := transitions[from]
+	if actor.Type == ActorCustomer {
+		allowed = customerTransitions[from]
+	}
+	if !contains(allowed, to) {
+		return &TransitionError{From: from, To: to}
+	}
+
+	if from == StatusPending && to == StatusPacked && !isCOD(paymentMethod) {
+		return &TransitionError{From: from, To: to, Reason: "pesanan non-COD harus lunas dulu"}
+	}
+	return nil
+}
+
+// Transition = satu-satunya jalan buat ngubah orders.status. Baris order dikunci
+// dulu (FOR UPDATE) biar dua perubahan barengan gak saling timpa, dan riwayatnya
+// ditulis di transaksi yang sama. Kalau actor customer, order wajib milik customer
+// itu (kalau bukan dianggap gak ada).
+func Transition(tx *sql.Tx, orderID int, to Status, actor Actor, note string) (Status, error) {
+	var from Status
+	var customerID int
+	var paymentMethod sql.NullString
+	err := tx.QueryRow("SELECT status, customer_id, payment_method FROM orders WHERE id = ? FOR UPDATE", orderID).
+		Scan(&from, &customerID, &paymentMethod)
+	if err == sql.ErrNoRows {
+		return "", ErrOrderNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if actor.Type == ActorCustomer && customerID != actor.ID {
+		return "", ErrOrderNotFound
+	}
+
+	if err := CanTransition(from, to, actor, paymentMethod.String); err != nil {
+		return from, err
+	}
+
+	if _, err := tx.Exec("UPDATE orders SET status = ? WHERE id = ?", to, orderID); err != nil {
+		return from, err
+	}
+
+	// Keluar dari Pending = nominal unik transfer dilepas, boleh dipakai order lain
+	if from == StatusPending {
+		if _, err := tx.Exec("UPDATE orders SET open_transfer_amount = NULL WHERE id = ?", orderID); err != nil {
+			return from, err
+		}
+	}
+
+	// Dibatalkan (atau refund sebelum dikirim) = barang balik ke rak
+	if to == StatusCancelled || (to == StatusRefunded && isPreShipment(from)) {
+		if err := restoreStock(tx, orderID, to, actor); err != nil {
+			return from, err
+		}
+	}
+	if to == StatusCancelled {
+		if _, err := tx.Exec("UPDATE orders SET cancel_reason = ?, cancelled_at = NOW() WHERE id = ?", note, orderID); err != nil {
+			return from, err
+		}
+	}
+
+	return from, writeHistory(tx, orderID, &from, to, actor, note)
+}
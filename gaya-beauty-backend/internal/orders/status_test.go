@@ -0,0 +1,98 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+)
+
+// Lifecycle yang disepakati, ditulis ulang di sini (bukan baca dari map
+// transitions) biar perubahan tabel yang gak sengaja langsung ketahuan.
+var adminAllowed = map[[2]Status]bool{
+	{StatusPending, StatusPaid}:       true,
+	{StatusPending, StatusPacked}:     true, // COD doang, dicek terpisah
+	{StatusPending, StatusCancelled}:  true,
+	{StatusPaid, StatusPacked}:        true,
+	{StatusPaid, StatusCancelled}:     true,
+	{StatusPaid, StatusRefunded}:      true,
+	{StatusPacked, StatusShipped}:     true,
+	{StatusPacked, StatusCancelled}:   true,
+	{StatusPacked, StatusRefunded}:    true,
+	{StatusShipped, StatusDelivered}:  true,
+	{StatusShipped, StatusRefunded}:   true,
+	{StatusDelivered, StatusRefunded}: true,
+}
+
+var customerAllowed = map[[2]Status]bool{
+	{StatusPending, StatusCancelled}: true,
+	{StatusShipped, StatusDelivered}: true,
+}
+
+func TestCanTransitionAllPairs(t *testing.T) {
+	admin := Actor{Type: ActorAdmin, ID: 1}
+	customer := Actor{Type: ActorCustomer, ID: 2}
+
+	for _, from := range AllStatuses {
+		for _, to := range AllStatuses {
+			pair := [2]Status{from, to}
+
+			for _, method := range []string{"COD", "Transfer Bank - BCA"} {
+				err := CanTransition(from, to, admin, method)
+				want := adminAllowed[pair]
+				if pair == [2]Status{StatusPending, StatusPacked} {
+					want = method == "COD"
+				}
+				if want && err != nil {
+					t.Errorf("admin %s -> %s (%s) ditolak: %v", from, to, method, err)
+				}
+				if !want {
+					var te *TransitionError
+					if !errors.As(err, &te) {
+						t.Errorf("admin %s -> %s (%s) = %v, mau TransitionError", from, to, method, err)
+					}
+				}
+			}
+
+			err := CanTransition(from, to, customer, "COD")
+			if customerAllowed[pair] && err != nil {
+				t.Errorf("customer %s -> %s ditolak: %v", from, to, err)
+			}
+			if !customerAllowed[pair] && err == nil {
+				t.Errorf("customer %s -> %s harusnya ditolak", from, to)
+			}
+		}
+	}
+}
+
+func TestCanTransitionPendingToPacked(t *testing.T) {
+	admin := Actor{Type: ActorAdmin}
+	for _, method := range []string{"COD", "cod", " COD "} {
+		if err := CanTransition(StatusPending, StatusPacked, admin, method); err != nil {
+			t.Errorf("COD %q ditolak: %v", method, err)
+		}
+	}
+	for _, method := range []string{"", "Online", "Transfer Bank - BRI", "COD (Bayar di Tempat)"} {
+		var te *TransitionError
+		err := CanTransition(StatusPending, StatusPacked, admin, method)
+		if !errors.As(err, &te) || te.Reason == "" {
+			t.Errorf("metode %q = %v, mau ditolak dengan alasan", method, err)
+		}
+	}
+}
+
+func TestCanTransitionTerminal(t *testing.T) {
+	for _, from := range []Status{StatusCancelled, StatusRefunded} {
+		if next := NextStatuses(from); len(next) != 0 {
+			t.Errorf("%s status akhir tapi masih bisa ke %v", from, next)
+		}
+	}
+}
+
+func TestCanTransitionUnknownStatus(t *testing.T) {
+	admin := Actor{Type: ActorAdmin}
+	if err := CanTransition(StatusPending, "Hilang", admin, "COD"); err != ErrUnknownStatus {
+		t.Errorf("status tujuan ngaco = %v, mau ErrUnknownStatus", err)
+	}
+	if err := CanTransition("Hilang", StatusPaid, admin, "COD"); err == nil {
+		t.Error("status asal ngaco harusnya ditolak")
+	}
+}
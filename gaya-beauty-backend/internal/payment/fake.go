@@ -0,0 +1,68 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// Header tanda tangan notifikasi Fake: hex HMAC-SHA256 body pakai fake_secret
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// Fake = gateway bohongan buat development & test: gak ada uang yang pindah.
+// Body notifikasinya sama kayak Midtrans (tanpa signature_key), contoh:
+//
+//	{"order_id":"GB-12","transaction_id":"t-1","transaction_status":"settlement","gross_amount":"150000.00"}
+//
+// dengan header X-Fake-Signature = Sign(body).
+type Fake struct {
+	secret []byte
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{secret: []byte(secret)}
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) CreateSession(_ context.Context, req SessionRequest) (Session, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return Session{}, err
+	}
+	token := "fake-" + hex.EncodeToString(b)
+	return Session{
+		Provider:    f.Name(),
+		Token:       token,
+		RedirectURL: "https://fake-payment.local/pay/" + token + "?order_id=" + req.OrderRef,
+	}, nil
+}
+
+// Sign bikin tanda tangan notifikasi (dipakai test / script simulasi bayar).
+func (f *Fake) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *Fake) ParseNotification(body []byte, header http.Header) (Notification, error) {
+	var raw snapNotification
+	if err := json.Unmarshal(body, &raw); err != nil || raw.OrderID == "" {
+		return Notification{}, ErrBadNotification
+	}
+	n := raw.notification()
+
+	got, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil {
+		return n, ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(f.Sign(body))
+	if !hmac.Equal(got, want) {
+		return n, ErrInvalidSignature
+	}
+	return n, nil
+}
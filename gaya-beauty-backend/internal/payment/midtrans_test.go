@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+)
+
+// Vektor dihitung terpisah: SHA512("GB-12" + "200" + "150000.00" + "SB-Mid-server-TEST123")
+const midtransTestSignature = "96c32abd654952d47d06d59cec017078b5f916c68aea0188e0f7e34a51564767b829986e8f8bf2fbafa8a8831013fa9468734eda65824f48338aae85160e95d2"
+
+func midtransBody(status, signature string) []byte {
+	return []byte(`{"order_id":"GB-12","status_code":"200","gross_amount":"150000.00",` +
+		`"transaction_id":"t-1","transaction_status":"` + status + `","payment_type":"qris",` +
+		`"signature_key":"` + signature + `"}`)
+}
+
+func TestMidtransParseNotification(t *testing.T) {
+	m := NewMidtrans("SB-Mid-server-TEST123", false)
+
+	n, err := m.ParseNotification(midtransBody("settlement", midtransTestSignature), nil)
+	if err != nil {
+		t.Fatalf("signature valid ditolak: %v", err)
+	}
+	want := Notification{OrderRef: "GB-12", TransactionID: "t-1", RawStatus: "settlement",
+		Status: StatusPaid, Amount: 150000, PaymentType: "qris"}
+	if n != want {
+		t.Errorf("notifikasi = %+v, mau %+v", n, want)
+	}
+
+	// Huruf besar di signature tetap diterima (hex gak case-sensitive)
+	if _, err := m.ParseNotification(midtransBody("settlement", strings.ToUpper(midtransTestSignature)), nil); err != nil {
+		t.Errorf("signature huruf besar ditolak: %v", err)
+	}
+
+	// Server key beda = signature salah, tapi isi notifikasi tetap kebaca buat dicatat
+	other := NewMidtrans("SB-Mid-server-LAIN", false)
+	n, err = other.ParseNotification(midtransBody("settlement", midtransTestSignature), nil)
+	if err != ErrInvalidSignature {
+		t.Errorf("error = %v, mau ErrInvalidSignature", err)
+	}
+	if n.OrderRef != "GB-12" {
+		t.Errorf("OrderRef notifikasi gak sah = %q, mau tetap diisi", n.OrderRef)
+	}
+
+	if _, err := m.ParseNotification([]byte(`{"status_code":"200"}`), nil); err != ErrBadNotification {
+		t.Errorf("tanpa order_id: error = %v, mau ErrBadNotification", err)
+	}
+	if _, err := m.ParseNotification([]byte(`bukan json`), nil); err != ErrBadNotification {
+		t.Errorf("bukan JSON: error = %v, mau ErrBadNotification", err)
+	}
+}
+
+func TestSnapStatus(t *testing.T) {
+	tests := []struct {
+		status, fraud string
+		want          Status
+	}{
+		{"settlement", "", StatusPaid},
+		{"capture", "accept", StatusPaid},
+		{"capture", "challenge", StatusPending},
+		{"pending", "", StatusPending},
+		{"expire", "", StatusFailed},
+		{"deny", "", StatusFailed},
+		{"refund", "", StatusRefunded},
+		{"aneh", "", StatusUnknown},
+	}
+	for _, tt := range tests {
+		if got := snapStatus(tt.status, tt.fraud); got != tt.want {
+			t.Errorf("snapStatus(%q, %q) = %q, mau %q", tt.status, tt.fraud, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package payment
+
+import (
+	"database/sql"
+	"fmt"
+	"gaya-beauty-backend/internal/orders"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// Result = apa yang dilakukan server sama satu notifikasi (disimpan di payment_notifications)
+type Result string
+
+const (
+	ResultPaid             Result = "paid"              // Order pindah ke Lunas
+	ResultDuplicate        Result = "duplicate"         // Order sudah lunas/diproses sebelumnya
+	ResultIgnored          Result = "ignored"           // Status selain lunas, cuma dicatat
+	ResultPaidAfterCancel  Result = "paid_after_cancel" // Dibayar padahal order sudah batal: perlu refund manual
+	ResultAmountMismatch   Result = "amount_mismatch"
+	ResultOrderNotFound    Result = "order_not_found"
+	ResultInvalidSignature Result = "invalid_signature"
+	ResultInvalidPayload   Result = "invalid_payload"
+	ResultError            Result = "error"
+)
+
+// Selisih nominal yang masih dianggap sama (gateway bulatin ke rupiah)
+const amountTolerance = 1.0
+
+// Apply proses satu notifikasi webhook: kalau sah & lunas, order dipindah ke
+// Lunas lewat state machine orders. Aman dipanggil berkali-kali buat notifikasi
+// yang sama (gateway suka kirim ulang). Semua notifikasi dicatat, apapun hasilnya.
+func Apply(db *sql.DB, provider string, n Notification, parseErr error, payload []byte) (Result, error) {
+	result, orderID, err := apply(db, provider, n, parseErr)
+	if err != nil {
+		result = ResultError
+	}
+	if rerr := record(db, provider, n, orderID, parseErr == nil, result, payload); rerr != nil && err == nil {
+		err = rerr
+	}
+	return result, err
+}
+
+func apply(db *sql.DB, provider string, n Notification, parseErr error) (Result, int, error) {
+	switch parseErr {
+	case nil:
+	case ErrInvalidSignature:
+		return ResultInvalidSignature, 0, nil
+	default:
+		return ResultInvalidPayload, 0, nil
+	}
+
+	orderID, ok := ParseOrderRef(n.OrderRef)
+	if !ok {
+		return ResultOrderNotFound, 0, nil
+	}
+	if n.Status != StatusPaid {
+		return ResultIgnored, orderID, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", orderID, err
+	}
+	defer tx.Rollback()
+
+	var status orders.Status
+	var total float64
+	err = tx.QueryRow("SELECT status, total_price FROM orders WHERE id = ? FOR UPDATE", orderID).Scan(&status, &total)
+	if err == sql.ErrNoRows {
+		return ResultOrderNotFound, 0, nil
+	} else if err != nil {
+		return "", orderID, err
+	}
+
+	switch {
+	case status == orders.StatusCancelled:
+		log.Printf("Order %d dibayar lewat %s (transaksi %s) padahal sudah dibatalkan, perlu refund manual",
+			orderID, provider, n.TransactionID)
+		return ResultPaidAfterCancel, orderID, nil
+	case status != orders.StatusPending:
+		return ResultDuplicate, orderID, nil
+	case math.Abs(n.Amount-math.Round(total)) > amountTolerance:
+		log.Printf("Nominal bayar order %d gak cocok: %.2f vs total %.2f", orderID, n.Amount, total)
+		return ResultAmountMismatch, orderID, nil
+	}
+
+	note := fmt.Sprintf("Dibayar via %s", provider)
+	if n.PaymentType != "" {
+		note += " (" + n.PaymentType + ")"
+	}
+	if n.TransactionID != "" {
+		note += ", transaksi " + n.TransactionID
+	}
+	if _, err := orders.Transition(tx, orderID, orders.StatusPaid, orders.Actor{Type: orders.ActorSystem}, note); err != nil {
+		return "", orderID, err
+	}
+	if _, err := tx.Exec("UPDATE orders SET paid_at = NOW(), payment_reference = ? WHERE id = ?",
+		nullString(n.TransactionID), orderID); err != nil {
+		return "", orderID, err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", orderID, err
+	}
+	return ResultPaid, orderID, nil
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func record(db *sql.DB, provider string, n Notification, orderID int, signatureValid bool, result Result, payload []byte) error {
+	var order, amount interface{}
+	if orderID != 0 {
+		order = orderID
+	}
+	if n.OrderRef != "" {
+		amount = n.Amount
+	}
+	var status interface{}
+	if n.Status != "" {
+		status = n.Status
+	}
+	_, err := db.Exec(`INSERT INTO payment_notifications
+			(provider, order_ref, order_id, transaction_id, transaction_status, status, amount, signature_valid, result, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		provider, nullString(n.OrderRef), order, nullString(n.TransactionID), nullString(n.RawStatus), status,
+		amount, signatureValid, result, string(payload))
+	return err
+}
+
+// LoggedNotification = satu baris payment_notifications (buat admin)
+type LoggedNotification struct {
+	ID                int       `json:"id"`
+	Provider          string    `json:"provider"`
+	OrderRef          string    `json:"order_ref"`
+	OrderID           int       `json:"order_id,omitempty"`
+	TransactionID     string    `json:"transaction_id"`
+	TransactionStatus string    `json:"transaction_status"`
+	Status            Status    `json:"status"`
+	Amount            *float64  `json:"amount"`
+	SignatureValid    bool      `json:"signature_valid"`
+	Result            Result    `json:"result"`
+	Payload           string    `json:"payload"`
+	ReceivedAt        time.Time `json:"received_at"`
+}
+
+// Filter daftar notifikasi (nilai kosong = gak difilter)
+type Filter struct {
+	OrderID  int
+	Result   Result
+	BeforeID int
+	Limit    int
+}
+
+// List ambil notifikasi pembayaran terbaru dulu.
+func List(db *sql.DB, f Filter) ([]LoggedNotification, error) {
+	conds := []string{"1=1"}
+	var args []interface{}
+	if f.OrderID != 0 {
+		conds = append(conds, "order_id = ?")
+		args = append(args, f.OrderID)
+	}
+	if f.Result != "" {
+		conds = append(conds, "result = ?")
+		args = append(args, f.Result)
+	}
+	if f.BeforeID != 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, f.BeforeID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Query(`SELECT id, provider, order_ref, order_id, transaction_id, transaction_status, status, amount,
+			signature_valid, result, payload, received_at
+		FROM payment_notifications WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []LoggedNotification{}
+	for rows.Next() {
+		var n LoggedNotification
+		var orderRef, transactionID, transactionStatus, status sql.NullString
+		var orderID sql.NullInt64
+		var amount sql.NullFloat64
+		if err := rows.Scan(&n.ID, &n.Provider, &orderRef, &orderID, &transactionID, &transactionStatus, &status, &amount,
+			&n.SignatureValid, &n.Result, &n.Payload, &n.ReceivedAt); err != nil {
+			return nil, err
+		}
+		n.OrderRef = orderRef.String
+		n.OrderID = int(orderID.Int64)
+		n.TransactionID = transactionID.String
+		n.TransactionStatus = transactionStatus.String
+		n.Status = Status(status.String)
+		if amount.Valid {
+			n.Amount = &amount.Float64
+		}
+		list = append(list, n)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,105 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gaya-beauty-backend/internal/config"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Provider = payment gateway yang bisa bikin sesi bayar dan ngirim notifikasi
+// (webhook) waktu status pembayaran berubah. Implementasi: Midtrans (Snap) dan
+// Fake buat development/test.
+type Provider interface {
+	Name() string
+	// CreateSession bikin sesi bayar; customer diarahkan ke RedirectURL
+	// (atau frontend buka popup Snap pakai Token).
+	CreateSession(ctx context.Context, req SessionRequest) (Session, error)
+	// ParseNotification baca body webhook dan cek tanda tangannya.
+	// Kalau tanda tangan salah, Notification tetap diisi sebisanya (buat dicatat)
+	// dan error-nya ErrInvalidSignature.
+	ParseNotification(body []byte, header http.Header) (Notification, error)
+}
+
+type SessionRequest struct {
+	OrderRef      string // ID order versi gateway, lihat OrderRef
+	Amount        int64  // Rupiah bulat
+	CustomerName  string
+	CustomerEmail string
+	CustomerPhone string
+}
+
+type Session struct {
+	Provider    string `json:"provider"`
+	Token       string `json:"token"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+// Status pembayaran yang sudah diseragamkan dari status asli gateway
+type Status string
+
+const (
+	StatusPaid     Status = "paid"
+	StatusPending  Status = "pending"
+	StatusFailed   Status = "failed" // Ditolak, dibatalkan, atau kadaluarsa
+	StatusRefunded Status = "refunded"
+	StatusUnknown  Status = "unknown"
+)
+
+type Notification struct {
+	OrderRef      string
+	TransactionID string
+	RawStatus     string // Status asli dari gateway, contoh "settlement"
+	Status        Status
+	Amount        float64
+	PaymentType   string // bank_transfer, gopay, qris, dst.
+}
+
+var (
+	ErrInvalidSignature = errors.New("tanda tangan notifikasi tidak valid")
+	ErrBadNotification  = errors.New("format notifikasi tidak dikenal")
+)
+
+// Metode bayar yang dikirim frontend buat pembayaran lewat gateway.
+// Metode lain (COD, transfer manual) gak bikin sesi bayar.
+const MethodOnline = "Online"
+
+func IsOnline(paymentMethod string) bool {
+	return strings.EqualFold(strings.TrimSpace(paymentMethod), MethodOnline)
+}
+
+// Prefix biar order_id di gateway gak bentrok sama toko lain di akun yang sama
+const orderRefPrefix = "GB-"
+
+func OrderRef(orderID int) string {
+	return fmt.Sprintf("%s%d", orderRefPrefix, orderID)
+}
+
+func ParseOrderRef(ref string) (int, bool) {
+	s, ok := strings.CutPrefix(ref, orderRefPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// New bikin provider sesuai config. Provider kosong = pembayaran online mati (nil, nil).
+func New(cfg config.PaymentConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "midtrans":
+		return NewMidtrans(cfg.MidtransServerKey, cfg.MidtransProduction), nil
+	case "fake":
+		return NewFake(cfg.FakeSecret), nil
+	default:
+		return nil, fmt.Errorf("payment provider tidak dikenal: %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,58 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gaya-beauty-backend/internal/orders"
+	"math"
+)
+
+var (
+	ErrNotOnline  = errors.New("pesanan ini bukan pembayaran online")
+	ErrNotPending = errors.New("pesanan sudah tidak menunggu pembayaran")
+)
+
+// StartSession bikin sesi bayar buat order online milik customerID yang masih Pending.
+// Sesi yang sudah pernah dibikin dipakai ulang (order_id di gateway cuma boleh sekali).
+// Dipanggil di luar transaksi checkout biar request ke gateway gak nahan lock stok.
+func StartSession(ctx context.Context, db *sql.DB, p Provider, orderID, customerID int) (Session, error) {
+	var status orders.Status
+	var total float64
+	var method, token, redirectURL, provider, phone sql.NullString
+	var name, email string
+	err := db.QueryRow(`SELECT o.status, o.total_price, o.payment_method, o.snap_token, o.payment_redirect_url, o.payment_provider,
+			c.full_name, c.email, c.phone
+		FROM orders o JOIN customers c ON c.id = o.customer_id
+		WHERE o.id = ? AND o.customer_id = ?`, orderID, customerID).
+		Scan(&status, &total, &method, &token, &redirectURL, &provider, &name, &email, &phone)
+	if err == sql.ErrNoRows {
+		return Session{}, orders.ErrOrderNotFound
+	} else if err != nil {
+		return Session{}, err
+	}
+
+	if !IsOnline(method.String) {
+		return Session{}, ErrNotOnline
+	}
+	if status != orders.StatusPending {
+		return Session{}, ErrNotPending
+	}
+	if token.String != "" {
+		return Session{Provider: provider.String, Token: token.String, RedirectURL: redirectURL.String}, nil
+	}
+
+	s, err := p.CreateSession(ctx, SessionRequest{
+		OrderRef:      OrderRef(orderID),
+		Amount:        int64(math.Round(total)),
+		CustomerName:  name,
+		CustomerEmail: email,
+		CustomerPhone: phone.String,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	_, err = db.Exec("UPDATE orders SET payment_provider = ?, snap_token = ?, payment_redirect_url = ? WHERE id = ?",
+		s.Provider, s.Token, s.RedirectURL, orderID)
+	return s, err
+}
@@ -0,0 +1,294 @@
+package search
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Bobot per field: kecocokan di nama produk jauh lebih penting dari deskripsi
+const (
+	weightName        = 3.0
+	weightCategory    = 2.0
+	weightDescription = 1.0
+)
+
+// Parameter BM25 standar
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Penalti skor buat term yang ketemu lewat toleransi typo / prefix
+const (
+	prefixPenalty = 0.8
+	typo1Penalty  = 0.6
+	typo2Penalty  = 0.35
+)
+
+// Document = data produk yang di-index
+type Document struct {
+	ID          int
+	Name        string
+	Category    string
+	Description string
+}
+
+// Result = satu hasil pencarian (ID produk + skor relevansi)
+type Result struct {
+	ID    int     `json:"id"`
+	Score float64 `json:"score"`
+}
+
+type docInfo struct {
+	length float64            // panjang dokumen (sudah dibobot per field)
+	terms  map[string]float64 // term -> frekuensi terbobot
+}
+
+// Index = inverted index di memori, aman dipakai bareng banyak goroutine.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[int]*docInfo
+	postings map[string]map[int]float64 // term -> productID -> frekuensi terbobot
+	totalLen float64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[int]*docInfo),
+		postings: make(map[string]map[int]float64),
+	}
+}
+
+// Load isi ulang index dari tabel products (dipanggil sekali waktu server start).
+// Cuma produk aktif; draft & arsip gak boleh nongol di pencarian.
+func (ix *Index) Load(db *sql.DB) error {
+	rows, err := db.Query(`SELECT p.id, p.name, COALESCE(c.name, ''), COALESCE(p.description, '')
+		FROM products p LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.status = 'active'`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var docs []Document
+	for rows.Next() {
+		var d Document
+		if err := rows.Scan(&d.ID, &d.Name, &d.Category, &d.Description); err != nil {
+			return err
+		}
+		docs = append(docs, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.docs = make(map[int]*docInfo)
+	ix.postings = make(map[string]map[int]float64)
+	ix.totalLen = 0
+	for _, d := range docs {
+		ix.add(d)
+	}
+	return nil
+}
+
+// Len = jumlah produk di index
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Upsert tambah/ganti dokumen (dipanggil habis produk dibuat atau diupdate).
+func (ix *Index) Upsert(d Document) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(d.ID)
+	ix.add(d)
+}
+
+// Remove buang dokumen dari index (dipanggil habis produk dihapus).
+func (ix *Index) Remove(id int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+func (ix *Index) add(d Document) {
+	info := &docInfo{terms: make(map[string]float64)}
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{d.Name, weightName},
+		{d.Category, weightCategory},
+		{d.Description, weightDescription},
+	}
+	for _, f := range fields {
+		for _, t := range Tokenize(f.text) {
+			info.terms[t] += f.weight
+			info.length += f.weight
+		}
+	}
+
+	for t, tf := range info.terms {
+		if ix.postings[t] == nil {
+			ix.postings[t] = make(map[int]float64)
+		}
+		ix.postings[t][d.ID] = tf
+	}
+	ix.docs[d.ID] = info
+	ix.totalLen += info.length
+}
+
+func (ix *Index) remove(id int) {
+	info, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for t := range info.terms {
+		delete(ix.postings[t], id)
+		if len(ix.postings[t]) == 0 {
+			delete(ix.postings, t)
+		}
+	}
+	ix.totalLen -= info.length
+	delete(ix.docs, id)
+}
+
+// Search cari produk yang relevan sama query, urut dari skor tertinggi.
+// Tiap kata query dicocokkan persis dulu; kalau gak ada di kosakata index,
+// baru dicari kata yang mirip (typo) atau yang diawali kata itu (buat kata terakhir
+// yang mungkin masih diketik).
+func (ix *Index) Search(query string, limit int) []Result {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if len(ix.docs) == 0 {
+		return nil
+	}
+	avgLen := ix.totalLen / float64(len(ix.docs))
+
+	scores := make(map[int]float64)
+	matched := make(map[int]int) // berapa kata query yang ketemu di dokumen
+	for i, qt := range terms {
+		expansions := ix.expand(qt, i == len(terms)-1)
+		hit := make(map[int]bool)
+		for term, penalty := range expansions {
+			postings := ix.postings[term]
+			idf := math.Log(1 + (float64(len(ix.docs))-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for id, tf := range postings {
+				dl := ix.docs[id].length
+				s := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*dl/avgLen))
+				scores[id] += s * penalty
+				hit[id] = true
+			}
+		}
+		for id := range hit {
+			matched[id]++
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for id, s := range scores {
+		// Dokumen yang cocok sama semua kata query dikasih bonus biar naik ke atas
+		coverage := float64(matched[id]) / float64(len(terms))
+		results = append(results, Result{ID: id, Score: s * (0.5 + 0.5*coverage)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// expand balikin term di index yang dianggap cocok sama kata query beserta penaltinya.
+func (ix *Index) expand(qt string, allowPrefix bool) map[string]float64 {
+	out := make(map[string]float64)
+	_, exact := ix.postings[qt]
+	if exact {
+		out[qt] = 1
+	}
+
+	maxDist := 0
+	switch n := len([]rune(qt)); {
+	case n >= 8:
+		maxDist = 2
+	case n >= 4:
+		maxDist = 1
+	}
+
+	for term := range ix.postings {
+		if term == qt {
+			continue
+		}
+		if allowPrefix && len(qt) >= 3 && strings.HasPrefix(term, qt) {
+			out[term] = math.Max(out[term], prefixPenalty)
+			continue
+		}
+		// Typo cuma dicek kalau kata aslinya gak ada di index
+		if exact || maxDist == 0 {
+			continue
+		}
+		if abs(len(term)-len(qt)) > maxDist {
+			continue
+		}
+		switch d := editDistance(qt, term, maxDist); {
+		case d > maxDist:
+			continue
+		case d == 1:
+			out[term] = math.Max(out[term], typo1Penalty)
+		default:
+			out[term] = math.Max(out[term], typo2Penalty)
+		}
+	}
+	return out
+}
+
+// editDistance = jarak Damerau-Levenshtein (versi optimal string alignment).
+// Balikin max+1 kalau jaraknya sudah pasti lewat batas.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
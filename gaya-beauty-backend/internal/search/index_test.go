@@ -0,0 +1,107 @@
+package search
+
+import "testing"
+
+func testIndex() *Index {
+	ix := NewIndex()
+	for _, d := range []Document{
+		{ID: 1, Name: "Pelembap Wajah Aloe", Category: "Skincare", Description: "Melembapkan kulit kering"},
+		{ID: 2, Name: "Serum Vitamin C", Category: "Skincare", Description: "Mencerahkan dan melembapkan kulit kusam"},
+		{ID: 3, Name: "Bedak Padat Matte", Category: "Makeup", Description: "Bedak tahan lama"},
+		{ID: 4, Name: "Lipstik Matte Merah", Category: "Makeup", Description: "Warna-warni tahan lama di bibir"},
+	} {
+		ix.Upsert(d)
+	}
+	return ix
+}
+
+func resultIDs(results []Result) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestIndexRanking(t *testing.T) {
+	ix := testIndex()
+
+	// Kecocokan di nama menang dari yang cuma di deskripsi
+	got := resultIDs(ix.Search("pelembap", 0))
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("Search(pelembap) = %v, mau [1 2]", got)
+	}
+
+	// Dokumen yang cocok semua kata query di atas yang cuma sebagian
+	got = resultIDs(ix.Search("lipstik matte", 0))
+	if len(got) != 2 || got[0] != 4 || got[1] != 3 {
+		t.Errorf("Search(lipstik matte) = %v, mau [4 3]", got)
+	}
+
+	if got := ix.Search("pelembap", 1); len(got) != 1 {
+		t.Errorf("limit 1 dapat %d hasil", len(got))
+	}
+	if got := ix.Search("yang dan", 0); got != nil {
+		t.Errorf("query isinya stopword semua dapat %v, mau kosong", got)
+	}
+}
+
+func TestIndexTypo(t *testing.T) {
+	ix := testIndex()
+
+	// Satu huruf ketukar/kurang tetap ketemu
+	for _, q := range []string{"bedka", "lipstk", "serun"} {
+		if got := ix.Search(q, 0); len(got) == 0 {
+			t.Errorf("Search(%q) kosong, mau ketemu lewat toleransi typo", q)
+		}
+	}
+	got := ix.Search("bedka", 0)
+	if len(got) != 1 || got[0].ID != 3 {
+		t.Errorf("Search(bedka) = %v, mau produk 3", resultIDs(got))
+	}
+
+	// Kata yang ada persis gak ikut nyari typo: "lama" gak nyangkut ke kata lain
+	exact := ix.Search("lama", 0)
+	if ids := resultIDs(exact); len(ids) != 2 || ids[0] != 3 && ids[0] != 4 {
+		t.Errorf("Search(lama) = %v, mau produk 3 dan 4", ids)
+	}
+
+	// Typo dapat skor lebih kecil dari kata yang persis
+	if typo := ix.Search("bedka", 0); typo[0].Score >= ix.Search("bedak", 0)[0].Score {
+		t.Error("hasil typo gak boleh skornya sama/lebih dari kecocokan persis")
+	}
+}
+
+func TestIndexUpsertRemove(t *testing.T) {
+	ix := testIndex()
+	ix.Upsert(Document{ID: 3, Name: "Cushion Glow", Category: "Makeup"})
+	if got := ix.Search("bedak", 0); len(got) != 0 {
+		t.Errorf("produk yang diganti masih ketemu pakai nama lama: %v", resultIDs(got))
+	}
+	ix.Remove(4)
+	if ix.Len() != 3 {
+		t.Errorf("Len = %d, mau 3", ix.Len())
+	}
+	if got := ix.Search("lipstik", 0); len(got) != 0 {
+		t.Errorf("produk yang dihapus masih ketemu: %v", resultIDs(got))
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"bedak", "bedak", 2, 0},
+		{"bedka", "bedak", 2, 1}, // huruf ketukar = 1 edit
+		{"serun", "serum", 2, 1},
+		{"lipstk", "lipstik", 2, 1},
+		{"toner", "serum", 1, 2}, // lewat batas = max+1
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, mau %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
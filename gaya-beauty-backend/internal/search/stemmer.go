@@ -0,0 +1,158 @@
+package search
+
+import "strings"
+
+// Stemmer bahasa Indonesia versi ringan (turunan algoritma Nazief-Adriani/ECS)
+// tanpa kamus kata dasar. Urutannya: partikel (-lah, -kah, -tah, -pun),
+// kata ganti milik (-ku, -mu, -nya), akhiran turunan (-kan, -an, -i), lalu awalan
+// (maks. dua lapis). Karena gak pakai kamus, tiap potongan baru dipakai kalau sisa
+// katanya masih masuk akal sebagai kata dasar (lihat plausibleRoot).
+// Query & index dilewatkan ke stemmer yang sama, jadi sedikit over-stemming
+// tetap konsisten di dua sisi.
+
+const minRootLen = 4
+
+const vowels = "aiueo"
+
+func isVowel(c byte) bool {
+	return strings.IndexByte(vowels, c) >= 0
+}
+
+// Gabungan konsonan yang wajar di awal kata dasar (termasuk kata serapan)
+var consonantClusters = map[string]bool{
+	"bl": true, "br": true, "dr": true, "fl": true, "fr": true, "gl": true,
+	"gr": true, "kl": true, "kr": true, "ng": true, "ny": true, "pl": true,
+	"pr": true, "sk": true, "sl": true, "sm": true, "sn": true, "sp": true,
+	"st": true, "sw": true, "tr": true, "kh": true, "sy": true,
+}
+
+func plausibleRoot(s string) bool {
+	if len(s) < minRootLen {
+		return false
+	}
+	if !isVowel(s[0]) && !isVowel(s[1]) && !consonantClusters[s[:2]] {
+		return false
+	}
+	return strings.ContainsAny(s, vowels)
+}
+
+func trimSuffix(w string, suffixes ...string) string {
+	for _, suf := range suffixes {
+		if strings.HasSuffix(w, suf) && plausibleRoot(w[:len(w)-len(suf)]) {
+			return w[:len(w)-len(suf)]
+		}
+	}
+	return w
+}
+
+// Stem balikin bentuk dasar satu kata (input harus sudah huruf kecil).
+func Stem(w string) string {
+	if len(w) <= minRootLen {
+		return w
+	}
+
+	w = trimSuffix(w, "lah", "kah", "tah", "pun")
+	w = trimSuffix(w, "nya", "ku", "mu")
+	w = trimDerivationSuffix(w)
+
+	for i := 0; i < 2; i++ {
+		next := stripPrefix(w)
+		if next == w {
+			break
+		}
+		w = next
+	}
+	return w
+}
+
+// trimDerivationSuffix buang -kan/-an/-i. Tanpa kamus, "-kan" vs "-an" ambigu
+// ("cerahkan" vs "cantikan"), jadi dilihat dari awalannya: konfiks ke-an / pe-an
+// pasti pakai "-an", sisanya dicoba "-kan" dulu. "-i" cuma dibuang kalau kata dasarnya
+// gak jadi berakhiran c/j (biar "cuci" gak jadi "cuc").
+func trimDerivationSuffix(w string) string {
+	if strings.HasPrefix(w, "ke") || strings.HasPrefix(w, "pe") {
+		if s := trimSuffix(w, "an"); s != w {
+			if strings.HasPrefix(s, "ke") && plausibleRoot(s[2:]) {
+				return s[2:] // konfiks ke-an: kecantikan -> cantik
+			}
+			return s
+		}
+	}
+	if s := trimSuffix(w, "kan", "an"); s != w {
+		return s
+	}
+	if s := trimSuffix(w, "i"); s != w && strings.IndexByte("cj", s[len(s)-1]) < 0 {
+		return s
+	}
+	return w
+}
+
+// stripPrefix buang satu lapis awalan + aturan peluluhan (meny- -> s, mem+vokal -> p, dst).
+func stripPrefix(w string) string {
+	try := func(candidate string) (string, bool) {
+		if plausibleRoot(candidate) {
+			return candidate, true
+		}
+		return w, false
+	}
+
+	for _, p := range []string{"di", "se"} {
+		if strings.HasPrefix(w, p) {
+			if s, ok := try(w[len(p):]); ok {
+				return s
+			}
+		}
+	}
+
+	// me- / pe- punya aturan peluluhan yang mirip
+	for _, base := range []string{"me", "pe"} {
+		if !strings.HasPrefix(w, base) || len(w) < len(base)+2 {
+			continue
+		}
+		rest := w[len(base):]
+		switch {
+		case strings.HasPrefix(rest, "ng") && len(rest) > 2 && (isVowel(rest[2]) || rest[2] == 'g' || rest[2] == 'h' || rest[2] == 'k'):
+			if s, ok := try(rest[2:]); ok {
+				return s
+			}
+			// Kata dasar k- yang luluh (mengikis -> kikis) gak bisa dibedain dari
+			// kata dasar vokal tanpa kamus; "k" cuma dicoba kalau tanpa "k" gak masuk akal
+			if s, ok := try("k" + rest[2:]); ok {
+				return s
+			}
+		case strings.HasPrefix(rest, "ny") && len(rest) > 2 && isVowel(rest[2]):
+			if s, ok := try("s" + rest[2:]); ok { // menyapu -> sapu
+				return s
+			}
+		case strings.HasPrefix(rest, "m") && len(rest) > 1 && strings.IndexByte("bfv", rest[1]) >= 0:
+			if s, ok := try(rest[1:]); ok { // membersihkan -> bersih
+				return s
+			}
+		case strings.HasPrefix(rest, "m") && len(rest) > 1 && isVowel(rest[1]):
+			if s, ok := try("p" + rest[1:]); ok { // pemutih -> putih
+				return s
+			}
+		case strings.HasPrefix(rest, "n") && len(rest) > 1 && strings.IndexByte("cdjz", rest[1]) >= 0:
+			if s, ok := try(rest[1:]); ok { // pencuci -> cuci
+				return s
+			}
+		case strings.HasPrefix(rest, "n") && len(rest) > 1 && isVowel(rest[1]):
+			if s, ok := try("t" + rest[1:]); ok { // menata -> tata
+				return s
+			}
+		case strings.IndexByte("lrwy", rest[0]) >= 0:
+			if s, ok := try(rest); ok { // pelembap -> lembap, perona -> rona
+				return s
+			}
+		}
+	}
+
+	for _, p := range []string{"ber", "ter"} {
+		if strings.HasPrefix(w, p) {
+			if s, ok := try(w[len(p):]); ok {
+				return s
+			}
+		}
+	}
+	return w
+}
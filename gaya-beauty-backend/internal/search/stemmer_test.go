@@ -0,0 +1,48 @@
+package search
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"pelembapnya", "lembap"},
+		{"pelembap", "lembap"},
+		{"melembapkan", "lembap"},
+		{"membersihkan", "bersih"},
+		{"pembersih", "bersih"},
+		{"pemutih", "putih"},
+		{"mencerahkan", "cerah"},
+		{"kecantikan", "cantik"},
+		{"menyapu", "sapu"},
+		{"mengambil", "ambil"},
+		{"pencuci", "cuci"},
+		{"perona", "rona"},
+		{"bedaklah", "bedak"},
+		// Kata dasar / kata pendek gak boleh rusak
+		{"bedak", "bedak"},
+		{"serum", "serum"},
+		{"cuci", "cuci"},
+		{"toner", "toner"},
+		{"lip", "lip"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.in); got != tt.want {
+			t.Errorf("Stem(%q) = %q, mau %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Tanpa kamus, kata dasar berakhiran -i ("pakai", "wangi") kepotong juga.
+// Gak masalah selama bentuk turunannya jatuh ke stem yang sama.
+func TestStemConsistent(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"pakai", "dipakai"},
+		{"wangi", "wanginya"},
+		{"cerah", "pencerah"},
+		{"lembap", "kelembapan"},
+		{"bersih", "dibersihkan"},
+	} {
+		if a, b := Stem(pair[0]), Stem(pair[1]); a != b {
+			t.Errorf("Stem(%q) = %q tapi Stem(%q) = %q, mau sama", pair[0], a, pair[1], b)
+		}
+	}
+}
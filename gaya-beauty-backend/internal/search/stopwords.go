@@ -0,0 +1,20 @@
+package search
+
+// Kata umum bahasa Indonesia yang gak bantu relevansi (dibuang sebelum indexing).
+// Sengaja pendek: kata kayak "untuk" atau "kulit" bisa jadi penting di katalog kosmetik,
+// jadi yang masuk sini cuma kata sambung/penunjuk yang benar-benar netral.
+var stopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true,
+	"dengan": true, "atau": true, "ini": true, "itu": true, "pada": true,
+	"adalah": true, "juga": true, "akan": true, "sudah": true, "telah": true,
+	"bisa": true, "dapat": true, "karena": true, "agar": true, "supaya": true,
+	"serta": true, "bagi": true, "oleh": true, "dalam": true, "sebagai": true,
+	"lebih": true, "sangat": true, "para": true, "pun": true, "lah": true,
+	"kah": true, "nya": true, "si": true, "sang": true, "tersebut": true,
+	"hingga": true, "sampai": true, "tanpa": true, "per": true, "the": true,
+	"and": true, "for": true, "with": true, "of": true,
+}
+
+func isStopword(w string) bool {
+	return stopwords[w]
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize pecah teks jadi kata dasar: huruf kecil, tanda baca dibuang,
+// stopword dibuang, lalu di-stem. Kata ulang ("kosmetik-kosmetik", "warna-warni")
+// disatukan jadi satu kata dasar.
+func Tokenize(text string) []string {
+	text = strings.ToLower(text)
+
+	// Pisah per spasi/tanda baca, tapi tanda hubung dipertahankan dulu buat cek kata ulang
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+
+	var tokens []string
+	for _, word := range words {
+		for _, part := range splitReduplication(word) {
+			if part == "" || isStopword(part) {
+				continue
+			}
+			tokens = append(tokens, Stem(part))
+		}
+	}
+	return tokens
+}
+
+// splitReduplication: "kosmetik-kosmetik" -> [kosmetik], "warna-warni" -> [warna],
+// "anti-aging" -> [anti, aging].
+func splitReduplication(word string) []string {
+	parts := strings.Split(word, "-")
+	if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		a, b := parts[0], parts[1]
+		if a == b || Stem(a) == Stem(b) || sharedPrefixLen(a, b) >= 3 && abs(len(a)-len(b)) <= 1 {
+			return []string{a}
+		}
+	}
+
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func sharedPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
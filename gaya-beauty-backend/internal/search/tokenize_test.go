@@ -0,0 +1,42 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Bedak-bedak", []string{"bedak"}},
+		{"kosmetik-kosmetik", []string{"kosmetik"}},
+		{"warna-warni", []string{"warna"}},
+		{"Anti-Aging Serum", []string{"anti", "aging", "serum"}},
+		{"Pelembap yang melembapkan kulit", []string{"lembap", "lembap", "kulit"}},
+		{"Lipstik, matte & tahan lama!", []string{"lipstik", "matte", "tahan", "lama"}},
+		{"serum 20ml untuk wajah", []string{"serum", "20ml", "untuk", "wajah"}},
+		{"dan di yang itu", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, mau %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStopwords(t *testing.T) {
+	for _, w := range []string{"yang", "dan", "dengan", "the", "nya"} {
+		if !isStopword(w) {
+			t.Errorf("%q harusnya stopword", w)
+		}
+	}
+	// Kata yang penting di katalog kosmetik sengaja bukan stopword
+	for _, w := range []string{"untuk", "kulit", "wajah", "bibir"} {
+		if isStopword(w) {
+			t.Errorf("%q gak boleh jadi stopword", w)
+		}
+	}
+}
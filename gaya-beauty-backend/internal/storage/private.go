@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// Private nyimpen file yang gak boleh bisa dibuka siapa aja lewat URL (bukti
+// transfer: nama & rekening customer). Foldernya sengaja GAK disajikan main.go,
+// apa pun driver storage publiknya; file cuma dibaca lewat handler yang sudah
+// cek akses (Open).
+type Private struct {
+	local *Local
+}
+
+func NewPrivate(dir string) (*Private, error) {
+	l, err := NewLocal(dir, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Private{local: l}, nil
+}
+
+// Save simpan isi r dengan nama key. Gak ada URL yang dibalikin.
+func (p *Private) Save(ctx context.Context, key string, r io.Reader) error {
+	_, err := p.local.Save(ctx, key, r, "")
+	return err
+}
+
+// Open buka file buat dikirim ke user yang berhak (caller wajib Close).
+func (p *Private) Open(key string) (*os.File, error) {
+	path, err := p.local.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (p *Private) Delete(ctx context.Context, key string) error {
+	return p.local.Delete(ctx, key)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"gaya-beauty-backend/internal/config"
+)
+
+// Storage = tempat nyimpen file upload (gambar produk). Implementasinya bisa
+// folder lokal (dev) atau Cloudinary (production), dipilih dari config.
+type Storage interface {
+	// Save simpan isi r dengan nama key (contoh "products/12/ab34cd.jpg"),
+	// balikin URL publik file itu.
+	Save(ctx context.Context, key string, r io.Reader, contentType string) (string, error)
+	// Delete hapus file. File yang memang sudah gak ada gak dianggap error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New bikin Storage sesuai cfg.Driver.
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.LocalDir, cfg.PublicURL)
+	case "cloudinary":
+		return NewCloudinary(cfg.CloudinaryURL, cfg.Folder)
+	default:
+		return nil, fmt.Errorf("storage driver %q tidak dikenal", cfg.Driver)
+	}
+}
@@ -0,0 +1,91 @@
+// Package testdb nyiapin database MySQL sekali pakai buat test yang butuh
+// query beneran (FOR UPDATE, ON DUPLICATE KEY, dst. gak bisa dipalsuin).
+//
+// Set TEST_DB_DSN ke server MySQL kosong, contoh:
+//
+//	TEST_DB_DSN='root:secret@tcp(127.0.0.1:3306)/' go test ./...
+//
+// Tiap test dapat database baru (semua migration sudah jalan) yang dihapus
+// lagi setelah test selesai. Kalau TEST_DB_DSN kosong, test-nya di-skip.
+package testdb
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"gaya-beauty-backend/internal/migrations"
+	"os"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN kosong, test database di-skip")
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("TEST_DB_DSN tidak valid: %v", err)
+	}
+	cfg.ParseTime = true
+
+	buf := make([]byte, 6)
+	rand.Read(buf)
+	name := "gaya_test_" + hex.EncodeToString(buf)
+
+	cfg.DBName = ""
+	admin, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		admin.Close()
+		t.Fatalf("gagal bikin database test: %v", err)
+	}
+
+	cfg.DBName = name
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		admin.Exec("DROP DATABASE " + name)
+		admin.Close()
+	})
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("migration gagal: %v", err)
+	}
+	return db
+}
+
+// Exec jalanin query setup test, langsung gagal kalau error. Balikin LastInsertId.
+func Exec(t testing.TB, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("setup gagal (%s): %v", query, err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// Customer bikin akun customer test.
+func Customer(t testing.TB, db *sql.DB, email string) int {
+	t.Helper()
+	return Exec(t, db, "INSERT INTO customers (full_name, email, password) VALUES (?, ?, 'x')", "Test "+email, email)
+}
+
+// Product bikin produk aktif tanpa varian dengan stok di satu batch (kode kosong, tanpa kadaluarsa).
+func Product(t testing.TB, db *sql.DB, name string, price float64, stock int) int {
+	t.Helper()
+	id := Exec(t, db, "INSERT INTO products (name, price, stock, status) VALUES (?, ?, ?, 'active')", name, price, stock)
+	Exec(t, db, `INSERT INTO stock_batches (product_id, variant_id, batch_code, quantity_received, quantity_remaining)
+		VALUES (?, 0, '', ?, ?)`, id, stock, stock)
+	return id
+}
@@ -0,0 +1,61 @@
+package transfer
+
+import (
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Kode unik 1..999 ditambahin ke total biar tiap transfer yang masuk bisa
+// dicocokin ke satu order dari nominalnya aja.
+const maxUniqueCode = 999
+
+// Berapa kali coba kode acak sebelum nyerah (bentrok kalau banyak order
+// Pending dengan total yang sama persis)
+const uniqueCodeAttempts = 30
+
+// Error MySQL duplicate entry (UNIQUE open_transfer_amount)
+const mysqlDuplicateEntry = 1062
+
+var ErrNoUniqueCode = errors.New("nominal unik transfer lagi habis, coba lagi sebentar")
+
+// randomCode ambil kode unik acak 1..maxUniqueCode (diganti di test biar bentroknya bisa diatur)
+var randomCode = func() int {
+	return rand.IntN(maxUniqueCode) + 1
+}
+
+// IsBankTransfer = metode bayar transfer manual, contoh "Transfer Bank - BCA".
+func IsBankTransfer(paymentMethod string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(paymentMethod)), "transfer")
+}
+
+// Code = nominal yang harus ditransfer customer
+type Code struct {
+	UniqueCode int     `json:"unique_code"`
+	Amount     float64 `json:"amount"`
+}
+
+// AssignUniqueCode kasih kode unik ke order transfer yang baru dibikin (di
+// transaksi checkout). Nominalnya dijamin beda dari semua order lain yang masih
+// Pending lewat UNIQUE open_transfer_amount; dilepas lagi waktu order keluar
+// dari Pending (lihat orders.Transition).
+func AssignUniqueCode(tx *sql.Tx, orderID int, total float64) (Code, error) {
+	for range uniqueCodeAttempts {
+		code := randomCode()
+		amount := total + float64(code)
+		_, err := tx.Exec("UPDATE orders SET unique_code = ?, transfer_amount = ?, open_transfer_amount = ? WHERE id = ?",
+			code, amount, amount, orderID)
+		var myErr *mysql.MySQLError
+		if errors.As(err, &myErr) && myErr.Number == mysqlDuplicateEntry {
+			continue
+		}
+		if err != nil {
+			return Code{}, err
+		}
+		return Code{UniqueCode: code, Amount: amount}, nil
+	}
+	return Code{}, ErrNoUniqueCode
+}
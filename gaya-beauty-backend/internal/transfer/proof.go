@@ -0,0 +1,259 @@
+package transfer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gaya-beauty-backend/internal/orders"
+	"strings"
+	"time"
+)
+
+// Status bukti transfer
+type ProofStatus string
+
+const (
+	ProofPending  ProofStatus = "pending" // Nunggu dicek admin
+	ProofApproved ProofStatus = "approved"
+	ProofRejected ProofStatus = "rejected"
+)
+
+func ValidProofStatus(s ProofStatus) bool {
+	return s == ProofPending || s == ProofApproved || s == ProofRejected
+}
+
+var (
+	ErrNotTransfer     = errors.New("pesanan ini bukan pembayaran transfer bank")
+	ErrNotPending      = errors.New("pesanan sudah tidak menunggu pembayaran")
+	ErrProofPending    = errors.New("bukti transfer sebelumnya masih dicek admin")
+	ErrProofNotFound   = errors.New("bukti transfer tidak ditemukan")
+	ErrAlreadyReviewed = errors.New("bukti transfer sudah diproses")
+)
+
+// Proof = satu bukti transfer + ringkasan order-nya (buat antrian verifikasi)
+type Proof struct {
+	ID             int         `json:"id"`
+	OrderID        int         `json:"order_id"`
+	CustomerID     int         `json:"customer_id"`
+	CustomerName   string      `json:"customer_name"`
+	PaymentMethod  string      `json:"payment_method"`
+	TotalPrice     float64     `json:"total_price"`
+	TransferAmount *float64    `json:"transfer_amount"` // Nominal yang harus ditransfer (nil = order lama tanpa kode unik)
+	OrderStatus    string      `json:"order_status"`
+	SenderName     string      `json:"sender_name"`
+	SenderBank     string      `json:"sender_bank"`
+	ReceiptURL     string      `json:"receipt_url"` // Path API foto bukti (butuh token), diisi handler
+	ImageKey       string      `json:"-"`
+	Status         ProofStatus `json:"status"`
+	RejectReason   string      `json:"reject_reason,omitempty"`
+	ReviewedBy     *int        `json:"reviewed_by"`
+	ReviewedAt     *time.Time  `json:"reviewed_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// Submission = data upload bukti transfer dari customer (file sudah disimpan di storage privat)
+type Submission struct {
+	OrderID    int
+	CustomerID int
+	SenderName string
+	SenderBank string
+	ImageKey   string
+}
+
+// Submit simpan bukti transfer buat order transfer milik customer yang masih Pending.
+// Satu order cuma boleh punya satu bukti yang lagi dicek; kalau ditolak, customer
+// upload lagi.
+func Submit(db *sql.DB, s Submission) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Kunci order biar dua upload barengan gak sama-sama lolos cek "belum ada bukti"
+	var status orders.Status
+	var method sql.NullString
+	err = tx.QueryRow("SELECT status, payment_method FROM orders WHERE id = ? AND customer_id = ? FOR UPDATE",
+		s.OrderID, s.CustomerID).Scan(&status, &method)
+	if err == sql.ErrNoRows {
+		return 0, orders.ErrOrderNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if !IsBankTransfer(method.String) {
+		return 0, ErrNotTransfer
+	}
+	if status != orders.StatusPending {
+		return 0, ErrNotPending
+	}
+
+	var waiting int
+	err = tx.QueryRow("SELECT COUNT(*) FROM transfer_proofs WHERE order_id = ? AND status = ?",
+		s.OrderID, ProofPending).Scan(&waiting)
+	if err != nil {
+		return 0, err
+	}
+	if waiting > 0 {
+		return 0, ErrProofPending
+	}
+
+	var bank interface{}
+	if s.SenderBank != "" {
+		bank = s.SenderBank
+	}
+	res, err := tx.Exec(`INSERT INTO transfer_proofs (order_id, customer_id, sender_name, sender_bank, image_key, status)
+		VALUES (?, ?, ?, ?, ?, ?)`, s.OrderID, s.CustomerID, s.SenderName, bank, s.ImageKey, ProofPending)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	return int(id), tx.Commit()
+}
+
+// Receipt balikin pemilik & key file foto satu bukti transfer (buat cek akses
+// sebelum fotonya dikirim).
+func Receipt(db *sql.DB, proofID int) (customerID int, key string, err error) {
+	err = db.QueryRow("SELECT customer_id, image_key FROM transfer_proofs WHERE id = ?", proofID).Scan(&customerID, &key)
+	if err == sql.ErrNoRows {
+		return 0, "", ErrProofNotFound
+	}
+	return customerID, key, err
+}
+
+// lockProof kunci bukti transfer yang masih pending, balikin order_id-nya.
+func lockProof(tx *sql.Tx, proofID int) (int, error) {
+	var orderID int
+	var status ProofStatus
+	err := tx.QueryRow("SELECT order_id, status FROM transfer_proofs WHERE id = ? FOR UPDATE", proofID).
+		Scan(&orderID, &status)
+	if err == sql.ErrNoRows {
+		return 0, ErrProofNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if status != ProofPending {
+		return 0, ErrAlreadyReviewed
+	}
+	return orderID, nil
+}
+
+// Approve setujui bukti transfer: order pindah ke Lunas lewat state machine orders
+// (error transisi, misal order keburu dibatalkan, dibalikin apa adanya).
+func Approve(db *sql.DB, proofID, userID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	orderID, err := lockProof(tx, proofID)
+	if err != nil {
+		return err
+	}
+
+	actor := orders.Actor{Type: orders.ActorAdmin, ID: userID}
+	note := fmt.Sprintf("Bukti transfer #%d disetujui", proofID)
+	if _, err := orders.Transition(tx, orderID, orders.StatusPaid, actor, note); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE orders SET paid_at = NOW(), payment_reference = ? WHERE id = ?",
+		fmt.Sprintf("transfer-proof:%d", proofID), orderID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE transfer_proofs SET status = ?, reviewed_by = ?, reviewed_at = NOW() WHERE id = ?",
+		ProofApproved, userID, proofID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reject tolak bukti transfer. Order tetap Pending, customer bisa upload ulang.
+func Reject(db *sql.DB, proofID, userID int, reason string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockProof(tx, proofID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE transfer_proofs SET status = ?, reject_reason = ?, reviewed_by = ?, reviewed_at = NOW() WHERE id = ?",
+		ProofRejected, reason, userID, proofID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Filter daftar bukti transfer (nilai kosong = gak difilter)
+type Filter struct {
+	Status     ProofStatus
+	OrderID    int
+	CustomerID int
+	Limit      int
+}
+
+// List ambil bukti transfer. Yang pending diurut paling lama dulu (antrian),
+// sisanya terbaru dulu.
+func List(db *sql.DB, f Filter) ([]Proof, error) {
+	conds := []string{"1=1"}
+	var args []interface{}
+	if f.Status != "" {
+		conds = append(conds, "p.status = ?")
+		args = append(args, f.Status)
+	}
+	if f.OrderID != 0 {
+		conds = append(conds, "p.order_id = ?")
+		args = append(args, f.OrderID)
+	}
+	if f.CustomerID != 0 {
+		conds = append(conds, "p.customer_id = ?")
+		args = append(args, f.CustomerID)
+	}
+	order := "p.id DESC"
+	if f.Status == ProofPending {
+		order = "p.id"
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Query(`SELECT p.id, p.order_id, p.customer_id, o.customer_name, o.payment_method, o.total_price,
+			o.transfer_amount, o.status, p.sender_name, p.sender_bank, p.image_key, p.status,
+			p.reject_reason, p.reviewed_by, p.reviewed_at, p.created_at
+		FROM transfer_proofs p JOIN orders o ON o.id = p.order_id
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY `+order+` LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Proof{}
+	for rows.Next() {
+		var p Proof
+		var customerName, method, bank, reason sql.NullString
+		var amount sql.NullFloat64
+		var reviewedBy sql.NullInt64
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.OrderID, &p.CustomerID, &customerName, &method, &p.TotalPrice,
+			&amount, &p.OrderStatus, &p.SenderName, &bank, &p.ImageKey, &p.Status,
+			&reason, &reviewedBy, &reviewedAt, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.CustomerName = customerName.String
+		p.PaymentMethod = method.String
+		p.SenderBank = bank.String
+		p.RejectReason = reason.String
+		if amount.Valid {
+			p.TransferAmount = &amount.Float64
+		}
+		if reviewedBy.Valid {
+			id := int(reviewedBy.Int64)
+			p.ReviewedBy = &id
+		}
+		if reviewedAt.Valid {
+			p.ReviewedAt = &reviewedAt.Time
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,151 @@
+package transfer
+
+import (
+	"database/sql"
+	"gaya-beauty-backend/internal/orders"
+	"gaya-beauty-backend/internal/testdb"
+	"testing"
+)
+
+// transferOrder bikin order transfer Pending dengan nominal unik total+code.
+func transferOrder(t *testing.T, db *sql.DB, customerID int, total float64, code int) int {
+	t.Helper()
+	amount := total + float64(code)
+	return testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price,
+			unique_code, transfer_amount, open_transfer_amount, status)
+		VALUES (?, 'Test', 'Transfer Bank - BCA', ?, ?, ?, ?, ?)`,
+		customerID, total, code, amount, amount, orders.StatusPending)
+}
+
+func TestSubmitApproveReject(t *testing.T) {
+	db := testdb.Open(t)
+	customerID := testdb.Customer(t, db, "ani@test.local")
+	otherID := testdb.Customer(t, db, "budi@test.local")
+	adminID := testdb.Exec(t, db, "INSERT INTO users (full_name, email, password, role) VALUES ('Admin', 'admin@test.local', 'x', 'admin')")
+
+	orderID := transferOrder(t, db, customerID, 150000, 123)
+	codOrder := testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status)
+		VALUES (?, 'Test', 'COD', 50000, ?)`, customerID, orders.StatusPending)
+
+	submit := func(orderID, customerID int) (int, error) {
+		return Submit(db, Submission{OrderID: orderID, CustomerID: customerID, SenderName: "ANI", ImageKey: "transfer-proofs/x.jpg"})
+	}
+	orderState := func() (status orders.Status, open sql.NullFloat64) {
+		t.Helper()
+		if err := db.QueryRow("SELECT status, open_transfer_amount FROM orders WHERE id = ?", orderID).Scan(&status, &open); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if _, err := submit(orderID, otherID); err != orders.ErrOrderNotFound {
+		t.Errorf("upload ke order orang lain: %v, mau ErrOrderNotFound", err)
+	}
+	if _, err := submit(codOrder, customerID); err != ErrNotTransfer {
+		t.Errorf("upload ke order COD: %v, mau ErrNotTransfer", err)
+	}
+
+	first, err := submit(orderID, customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submit(orderID, customerID); err != ErrProofPending {
+		t.Errorf("upload kedua waktu yang pertama masih dicek: %v, mau ErrProofPending", err)
+	}
+
+	// Ditolak: order tetap Pending, customer boleh upload ulang
+	if err := Reject(db, first, adminID, "Nominal kurang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reject(db, first, adminID, "lagi"); err != ErrAlreadyReviewed {
+		t.Errorf("tolak dua kali: %v, mau ErrAlreadyReviewed", err)
+	}
+	if status, _ := orderState(); status != orders.StatusPending {
+		t.Errorf("status order habis ditolak = %q, mau Pending", status)
+	}
+	var reason string
+	db.QueryRow("SELECT reject_reason FROM transfer_proofs WHERE id = ?", first).Scan(&reason)
+	if reason != "Nominal kurang" {
+		t.Errorf("alasan tolak = %q", reason)
+	}
+
+	second, err := submit(orderID, customerID)
+	if err != nil {
+		t.Fatalf("upload ulang habis ditolak: %v", err)
+	}
+
+	// Disetujui: order Lunas, nominal unik dilepas buat order lain
+	if err := Approve(db, second, adminID); err != nil {
+		t.Fatal(err)
+	}
+	if err := Approve(db, second, adminID); err != ErrAlreadyReviewed {
+		t.Errorf("setujui dua kali: %v, mau ErrAlreadyReviewed", err)
+	}
+	status, open := orderState()
+	if status != orders.StatusPaid || open.Valid {
+		t.Errorf("order habis disetujui = %q (open_transfer_amount %v), mau Lunas dan dilepas", status, open)
+	}
+	if _, err := submit(orderID, customerID); err != ErrNotPending {
+		t.Errorf("upload ke order Lunas: %v, mau ErrNotPending", err)
+	}
+
+	list, err := List(db, Filter{OrderID: orderID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != second || list[0].Status != ProofApproved || list[1].Status != ProofRejected {
+		t.Errorf("riwayat bukti = %+v, mau [approved, rejected]", list)
+	}
+}
+
+func TestAssignUniqueCodeRetriesOnDuplicate(t *testing.T) {
+	db := testdb.Open(t)
+	customerID := testdb.Customer(t, db, "ani@test.local")
+	transferOrder(t, db, customerID, 150000, 5) // 150005 sudah dipakai order Pending lain
+
+	orig := randomCode
+	t.Cleanup(func() { randomCode = orig })
+	codes := []int{5, 5, 7}
+	tries := 0
+	randomCode = func() int {
+		c := codes[min(tries, len(codes)-1)]
+		tries++
+		return c
+	}
+
+	orderID := testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status)
+		VALUES (?, 'Test', 'Transfer Bank - BCA', 150000, ?)`, customerID, orders.StatusPending)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	code, err := AssignUniqueCode(tx, orderID, 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.UniqueCode != 7 || code.Amount != 150007 || tries != 3 {
+		t.Errorf("dapat %+v setelah %d percobaan, mau kode 7 di percobaan ke-3", code, tries)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Semua percobaan bentrok: nyerah dengan ErrNoUniqueCode, bukan error DB
+	codes, tries = []int{5}, 0
+	other := testdb.Exec(t, db, `INSERT INTO orders (customer_id, customer_name, payment_method, total_price, status)
+		VALUES (?, 'Test', 'Transfer Bank - BCA', 150000, ?)`, customerID, orders.StatusPending)
+	tx2, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx2.Rollback()
+	if _, err := AssignUniqueCode(tx2, other, 150000); err != ErrNoUniqueCode {
+		t.Errorf("semua bentrok: %v, mau ErrNoUniqueCode", err)
+	}
+	if tries != uniqueCodeAttempts {
+		t.Errorf("nyoba %d kali, mau %d", tries, uniqueCodeAttempts)
+	}
+}
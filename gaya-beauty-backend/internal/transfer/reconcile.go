@@ -0,0 +1,441 @@
+package transfer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gaya-beauty-backend/internal/orders"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status baris mutasi rekening
+type LineStatus string
+
+const (
+	LineMatched   LineStatus = "matched"   // Cocok ke tepat satu order, tinggal dikonfirmasi admin
+	LineAmbiguous LineStatus = "ambiguous" // Lebih dari satu order cocok, admin pilih sendiri
+	LineUnmatched LineStatus = "unmatched" // Gak ada order yang cocok
+	LineConfirmed LineStatus = "confirmed" // Sudah dikonfirmasi, order jadi Lunas
+	LineIgnored   LineStatus = "ignored"   // Bukan pembayaran order (misal setoran lain)
+)
+
+// LineOpen = filter semua baris yang belum diselesaikan (matched, ambiguous, unmatched)
+const LineOpen LineStatus = "open"
+
+var openLineStatuses = []LineStatus{LineMatched, LineAmbiguous, LineUnmatched}
+
+func ValidLineStatus(s LineStatus) bool {
+	switch s {
+	case LineMatched, LineAmbiguous, LineUnmatched, LineConfirmed, LineIgnored, LineOpen:
+		return true
+	}
+	return false
+}
+
+// Format tanggal mutasi di API & database
+const DateLayout = "2006-01-02"
+
+// Jarak maksimal (hari) dari order dibuat sampai transfernya masuk
+const (
+	DefaultMatchWindowDays = 3
+	MaxMatchWindowDays     = 14
+)
+
+// Kandidat yang disimpan buat baris ambiguous (sisanya cukup ditandai di note)
+const maxCandidates = 10
+
+var (
+	ErrLineNotFound   = errors.New("baris mutasi tidak ditemukan")
+	ErrLineResolved   = errors.New("baris mutasi sudah diproses")
+	ErrNoProposal     = errors.New("baris mutasi belum punya usulan order, pilih order_id")
+	ErrAmountMismatch = errors.New("nominal mutasi tidak sama dengan nominal transfer order")
+)
+
+// ImportResult = ringkasan satu kali import file mutasi
+type ImportResult struct {
+	ImportID   int         `json:"import_id"`
+	Lines      int         `json:"lines"` // Baris uang masuk yang disimpan
+	Matched    int         `json:"matched"`
+	Ambiguous  int         `json:"ambiguous"`
+	Unmatched  int         `json:"unmatched"`
+	Duplicates int         `json:"duplicates"` // Sudah pernah di-import sebelumnya
+	Debits     int         `json:"debits"`     // Uang keluar, dilewati
+	Errors     []LineError `json:"errors"`     // Baris CSV yang gak kebaca
+}
+
+// Hasil pencocokan satu baris sebelum disimpan
+type lineMatch struct {
+	status     LineStatus
+	orderID    int
+	candidates []int
+	note       string
+}
+
+// Import simpan baris uang masuk dari mutasi rekening sekaligus cocokin tiap
+// baris ke order transfer yang masih Pending: nominalnya sama persis dengan
+// nominal transfer (total + kode unik, atau total aja buat order lama) dan
+// order-nya dibuat paling lama windowDays hari sebelum tanggal mutasi.
+// Baris yang sudah pernah di-import dilewati.
+func Import(db *sql.DB, filename string, lines []StatementLine, lineErrs []LineError, windowDays, userID int) (ImportResult, error) {
+	result := ImportResult{Errors: lineErrs}
+	if result.Errors == nil {
+		result.Errors = []LineError{}
+	}
+
+	var credits []StatementLine
+	for _, l := range lines {
+		if l.Amount <= 0 {
+			result.Debits++
+			continue
+		}
+		credits = append(credits, l)
+	}
+	prints := fingerprints(credits)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	matches := make([]lineMatch, len(credits))
+	for i, l := range credits {
+		candidates, err := findCandidates(tx, l, windowDays)
+		if err != nil {
+			return result, err
+		}
+		matches[i] = classify(candidates)
+	}
+	flagSharedOrders(matches)
+
+	var uploadedBy interface{}
+	if userID != 0 {
+		uploadedBy = userID
+	}
+	res, err := tx.Exec("INSERT INTO bank_statement_imports (filename, window_days, uploaded_by) VALUES (?, ?, ?)",
+		filename, windowDays, uploadedBy)
+	if err != nil {
+		return result, err
+	}
+	importID, _ := res.LastInsertId()
+	result.ImportID = int(importID)
+
+	for i, l := range credits {
+		m := matches[i]
+		var orderID, candidates, note interface{}
+		if m.orderID != 0 {
+			orderID = m.orderID
+		}
+		if len(m.candidates) > 0 {
+			candidates = joinIDs(m.candidates)
+		}
+		if m.note != "" {
+			note = m.note
+		}
+		// Baris yang fingerprint-nya sudah ada dibiarin (affected rows = 0)
+		res, err := tx.Exec(`INSERT INTO bank_statement_lines
+				(import_id, line_no, txn_date, description, amount, fingerprint, status, order_id, candidate_order_ids, note)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = id`,
+			importID, l.LineNo, l.Date.Format(DateLayout), l.Description, l.Amount, prints[i],
+			m.status, orderID, candidates, note)
+		if err != nil {
+			return result, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			result.Duplicates++
+			continue
+		}
+
+		result.Lines++
+		switch m.status {
+		case LineMatched:
+			result.Matched++
+		case LineAmbiguous:
+			result.Ambiguous++
+		default:
+			result.Unmatched++
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE bank_statement_imports SET line_count = ? WHERE id = ?", result.Lines, importID); err != nil {
+		return result, err
+	}
+	return result, tx.Commit()
+}
+
+// findCandidates cari order transfer Pending yang nominal & waktunya cocok sama satu baris mutasi.
+func findCandidates(tx *sql.Tx, l StatementLine, windowDays int) ([]int, error) {
+	amount := strconv.FormatFloat(l.Amount, 'f', 2, 64)
+	date := l.Date.Format(DateLayout)
+	rows, err := tx.Query(`SELECT id, payment_method FROM orders
+		WHERE status = ?
+		AND (open_transfer_amount = ? OR (transfer_amount IS NULL AND total_price = ?))
+		AND created_at < CAST(? AS DATE) + INTERVAL 1 DAY AND created_at >= CAST(? AS DATE) - INTERVAL ? DAY
+		ORDER BY id`,
+		orders.StatusPending, amount, amount, date, date, windowDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		var method sql.NullString
+		if err := rows.Scan(&id, &method); err != nil {
+			return nil, err
+		}
+		if IsBankTransfer(method.String) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+func classify(candidates []int) lineMatch {
+	switch len(candidates) {
+	case 0:
+		return lineMatch{status: LineUnmatched, note: "Tidak ada order transfer Pending dengan nominal ini"}
+	case 1:
+		return lineMatch{status: LineMatched, orderID: candidates[0]}
+	}
+	m := lineMatch{status: LineAmbiguous, note: fmt.Sprintf("%d order Pending dengan nominal sama", len(candidates))}
+	m.candidates = candidates[:min(len(candidates), maxCandidates)]
+	return m
+}
+
+// flagSharedOrders: satu order gak boleh diusulkan ke dua baris mutasi
+// sekaligus (misal customer transfer dua kali). Semua baris itu jadi ambiguous.
+func flagSharedOrders(matches []lineMatch) {
+	count := map[int]int{}
+	for _, m := range matches {
+		if m.status == LineMatched {
+			count[m.orderID]++
+		}
+	}
+	for i, m := range matches {
+		if m.status == LineMatched && count[m.orderID] > 1 {
+			matches[i] = lineMatch{
+				status:     LineAmbiguous,
+				candidates: []int{m.orderID},
+				note:       fmt.Sprintf("Order #%d cocok dengan %d baris mutasi", m.orderID, count[m.orderID]),
+			}
+		}
+	}
+}
+
+func joinIDs(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
+
+func splitIDs(s string) []int {
+	ids := []int{}
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Confirm konfirmasi satu baris mutasi sebagai pembayaran order: order pindah
+// ke Lunas lewat state machine orders. orderID 0 = pakai usulan hasil import;
+// selain itu admin milih sendiri (buat baris ambiguous/unmatched), nominalnya
+// tetap dicek harus sama. Bukti transfer order itu yang masih nunggu ikut disetujui.
+func Confirm(db *sql.DB, lineID, orderID, userID int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var status LineStatus
+	var proposed sql.NullInt64
+	var amount float64
+	var date time.Time
+	var desc string
+	err = tx.QueryRow("SELECT status, order_id, amount, txn_date, description FROM bank_statement_lines WHERE id = ? FOR UPDATE", lineID).
+		Scan(&status, &proposed, &amount, &date, &desc)
+	if err == sql.ErrNoRows {
+		return 0, ErrLineNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if status == LineConfirmed || status == LineIgnored {
+		return 0, ErrLineResolved
+	}
+	if orderID == 0 {
+		if status != LineMatched || !proposed.Valid {
+			return 0, ErrNoProposal
+		}
+		orderID = int(proposed.Int64)
+	}
+
+	var method sql.NullString
+	var total float64
+	var transferAmount sql.NullFloat64
+	err = tx.QueryRow("SELECT payment_method, total_price, transfer_amount FROM orders WHERE id = ? FOR UPDATE", orderID).
+		Scan(&method, &total, &transferAmount)
+	if err == sql.ErrNoRows {
+		return 0, orders.ErrOrderNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if !IsBankTransfer(method.String) {
+		return 0, ErrNotTransfer
+	}
+	expected := total
+	if transferAmount.Valid {
+		expected = transferAmount.Float64
+	}
+	if math.Abs(expected-amount) >= 0.005 {
+		return 0, ErrAmountMismatch
+	}
+
+	actor := orders.Actor{Type: orders.ActorAdmin, ID: userID}
+	note := fmt.Sprintf("Cocok mutasi rekening %s: %s", date.Format(DateLayout), desc)
+	if _, err := orders.Transition(tx, orderID, orders.StatusPaid, actor, note); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE orders SET paid_at = NOW(), payment_reference = ? WHERE id = ?",
+		fmt.Sprintf("bank-statement:%d", lineID), orderID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE transfer_proofs SET status = ?, reviewed_by = ?, reviewed_at = NOW() WHERE order_id = ? AND status = ?",
+		ProofApproved, userID, orderID, ProofPending); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE bank_statement_lines SET status = ?, order_id = ?, resolved_by = ?, resolved_at = NOW() WHERE id = ?",
+		LineConfirmed, orderID, userID, lineID); err != nil {
+		return 0, err
+	}
+	return orderID, tx.Commit()
+}
+
+// Ignore tandai baris mutasi yang belum diproses sebagai bukan pembayaran order.
+// Balikin jumlah baris yang berubah.
+func Ignore(db *sql.DB, lineIDs []int, userID int, note string) (int, error) {
+	if len(lineIDs) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{LineIgnored, userID, note}
+	for _, id := range lineIDs {
+		args = append(args, id)
+	}
+	for _, s := range openLineStatuses {
+		args = append(args, s)
+	}
+	res, err := db.Exec(`UPDATE bank_statement_lines
+		SET status = ?, resolved_by = ?, resolved_at = NOW(), note = COALESCE(NULLIF(?, ''), note)
+		WHERE id IN (`+placeholders(len(lineIDs))+`) AND status IN (`+placeholders(len(openLineStatuses))+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// Line = satu baris mutasi + order usulan/terkonfirmasinya
+type Line struct {
+	ID            int        `json:"id"`
+	ImportID      int        `json:"import_id"`
+	LineNo        int        `json:"line_no"`
+	Date          string     `json:"date"`
+	Description   string     `json:"description"`
+	Amount        float64    `json:"amount"`
+	Status        LineStatus `json:"status"`
+	OrderID       *int       `json:"order_id"`
+	OrderCustomer string     `json:"order_customer,omitempty"`
+	OrderStatus   string     `json:"order_status,omitempty"`
+	Candidates    []int      `json:"candidate_order_ids"`
+	Note          string     `json:"note"`
+	ResolvedBy    *int       `json:"resolved_by"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+}
+
+// LineFilter daftar baris mutasi (nilai kosong = gak difilter)
+type LineFilter struct {
+	ImportID int
+	Status   LineStatus // LineOpen = semua yang belum diproses
+	BeforeID int
+	Limit    int
+}
+
+// ListLines ambil baris mutasi, terbaru dulu.
+func ListLines(db *sql.DB, f LineFilter) ([]Line, error) {
+	conds := []string{"1=1"}
+	var args []interface{}
+	switch f.Status {
+	case "":
+	case LineOpen:
+		conds = append(conds, "l.status IN ("+placeholders(len(openLineStatuses))+")")
+		for _, s := range openLineStatuses {
+			args = append(args, s)
+		}
+	default:
+		conds = append(conds, "l.status = ?")
+		args = append(args, f.Status)
+	}
+	if f.ImportID != 0 {
+		conds = append(conds, "l.import_id = ?")
+		args = append(args, f.ImportID)
+	}
+	if f.BeforeID != 0 {
+		conds = append(conds, "l.id < ?")
+		args = append(args, f.BeforeID)
+	}
+	args = append(args, f.Limit)
+
+	rows, err := db.Query(`SELECT l.id, l.import_id, l.line_no, l.txn_date, l.description, l.amount, l.status,
+			l.order_id, o.customer_name, o.status, l.candidate_order_ids, l.note, l.resolved_by, l.resolved_at
+		FROM bank_statement_lines l LEFT JOIN orders o ON o.id = l.order_id
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY l.id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Line{}
+	for rows.Next() {
+		var l Line
+		var date time.Time
+		var orderID, resolvedBy sql.NullInt64
+		var customer, orderStatus, candidates, note sql.NullString
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&l.ID, &l.ImportID, &l.LineNo, &date, &l.Description, &l.Amount, &l.Status,
+			&orderID, &customer, &orderStatus, &candidates, &note, &resolvedBy, &resolvedAt); err != nil {
+			return nil, err
+		}
+		l.Date = date.Format(DateLayout)
+		if orderID.Valid {
+			id := int(orderID.Int64)
+			l.OrderID = &id
+		}
+		l.OrderCustomer = customer.String
+		l.OrderStatus = orderStatus.String
+		l.Candidates = splitIDs(candidates.String)
+		l.Note = note.String
+		if resolvedBy.Valid {
+			id := int(resolvedBy.Int64)
+			l.ResolvedBy = &id
+		}
+		if resolvedAt.Valid {
+			l.ResolvedAt = &resolvedAt.Time
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
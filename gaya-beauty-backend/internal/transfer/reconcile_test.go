@@ -0,0 +1,51 @@
+package transfer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	if m := classify(nil); m.status != LineUnmatched || m.orderID != 0 {
+		t.Errorf("tanpa kandidat = %+v, mau unmatched", m)
+	}
+	if m := classify([]int{7}); m.status != LineMatched || m.orderID != 7 || m.candidates != nil {
+		t.Errorf("satu kandidat = %+v, mau matched ke #7", m)
+	}
+	if m := classify([]int{7, 9}); m.status != LineAmbiguous || m.orderID != 0 || !slices.Equal(m.candidates, []int{7, 9}) {
+		t.Errorf("dua kandidat = %+v, mau ambiguous [7 9]", m)
+	}
+
+	many := make([]int, maxCandidates+5)
+	for i := range many {
+		many[i] = i + 1
+	}
+	if m := classify(many); m.status != LineAmbiguous || len(m.candidates) != maxCandidates {
+		t.Errorf("%d kandidat disimpan %d, mau dipotong jadi %d", len(many), len(m.candidates), maxCandidates)
+	}
+}
+
+func TestFlagSharedOrders(t *testing.T) {
+	matches := []lineMatch{
+		classify([]int{5}),
+		classify([]int{6}),
+		classify([]int{5}),
+		classify(nil),
+		classify([]int{5, 6}),
+	}
+	flagSharedOrders(matches)
+
+	// Order #5 diusulkan ke dua baris: dua-duanya jadi ambiguous
+	for _, i := range []int{0, 2} {
+		m := matches[i]
+		if m.status != LineAmbiguous || m.orderID != 0 || !slices.Equal(m.candidates, []int{5}) {
+			t.Errorf("baris %d = %+v, mau ambiguous dengan kandidat [5]", i, m)
+		}
+	}
+	if m := matches[1]; m.status != LineMatched || m.orderID != 6 {
+		t.Errorf("baris 1 = %+v, mau tetap matched ke #6", m)
+	}
+	if matches[3].status != LineUnmatched || matches[4].status != LineAmbiguous {
+		t.Errorf("baris yang gak matched ikut berubah: %+v, %+v", matches[3], matches[4])
+	}
+}
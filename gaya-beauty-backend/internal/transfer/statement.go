@@ -0,0 +1,267 @@
+package transfer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Batas baris per file (mutasi sebulan toko kecil gak sampai segini)
+const MaxStatementLines = 5000
+
+// Panjang keterangan yang disimpan (sisanya dipotong)
+const maxDescriptionLength = 255
+
+var ErrEmptyStatement = errors.New("file mutasi kosong")
+
+// StatementLine = satu baris mutasi rekening yang sudah di-parse
+type StatementLine struct {
+	LineNo      int       `json:"line_no"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"` // Negatif = uang keluar (debit)
+}
+
+// LineError = baris CSV yang gak bisa dibaca
+type LineError struct {
+	LineNo int    `json:"line_no"`
+	Error  string `json:"error"`
+}
+
+// Format tanggal yang biasa muncul di export internet banking
+var statementDateLayouts = []string{
+	"2006-01-02",
+	"02/01/2006",
+	"02-01-2006",
+	"02/01/06",
+	"2/1/2006",
+	"02 Jan 2006",
+	"2006/01/02",
+}
+
+// Nama kolom header yang dikenali (huruf kecil)
+var statementHeaders = map[string][]string{
+	"date":        {"date", "tanggal", "tgl", "tanggal transaksi"},
+	"description": {"description", "keterangan", "deskripsi", "uraian"},
+	"amount":      {"amount", "nominal", "jumlah", "mutasi"},
+}
+
+// ParseStatement baca CSV mutasi rekening: kolom tanggal, keterangan, nominal.
+// Header boleh ada (urutan kolom ikut header) atau gak ada (urutan date,
+// description, amount). Pemisah koma atau titik koma. Baris yang rusak gak
+// bikin satu file gagal, cuma dilaporkan di LineError.
+func ParseStatement(r io.Reader) ([]StatementLine, []LineError, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, err
+	}
+	if len(strings.TrimSpace(string(first))) == 0 {
+		return nil, nil, ErrEmptyStatement
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = sniffDelimiter(first)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.LazyQuotes = true
+
+	cols := [3]int{0, 1, 2} // date, description, amount
+	var lines []StatementLine
+	var lineErrs []LineError
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lineErrs = append(lineErrs, LineError{LineNo: parseErr.StartLine, Error: "format CSV rusak"})
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		// Nomor baris asli di file (baris kosong dilewati csv.Reader)
+		lineNo, _ := cr.FieldPos(0)
+		if first {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff") // BOM dari Excel
+			if c, ok := headerColumns(record); ok {
+				cols = c
+				continue
+			}
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(lines) >= MaxStatementLines {
+			return nil, nil, fmt.Errorf("maksimal %d baris per file", MaxStatementLines)
+		}
+
+		line, err := parseStatementRecord(record, cols)
+		if err != nil {
+			lineErrs = append(lineErrs, LineError{LineNo: lineNo, Error: err.Error()})
+			continue
+		}
+		line.LineNo = lineNo
+		lines = append(lines, line)
+	}
+	return lines, lineErrs, nil
+}
+
+// sniffDelimiter tebak pemisah kolom dari baris pertama (Excel versi Indonesia pakai ";")
+func sniffDelimiter(head []byte) rune {
+	firstLine, _, _ := strings.Cut(string(head), "\n")
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		return ';'
+	}
+	return ','
+}
+
+func headerColumns(record []string) ([3]int, bool) {
+	cols := [3]int{-1, -1, -1}
+	for i, name := range []string{"date", "description", "amount"} {
+		for j, field := range record {
+			field = strings.ToLower(strings.TrimSpace(field))
+			for _, alias := range statementHeaders[name] {
+				if field == alias {
+					cols[i] = j
+				}
+			}
+		}
+	}
+	return cols, cols[0] >= 0 && cols[1] >= 0 && cols[2] >= 0
+}
+
+func isBlankRecord(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseStatementRecord(record []string, cols [3]int) (StatementLine, error) {
+	for _, c := range cols {
+		if c >= len(record) {
+			return StatementLine{}, errors.New("kolom kurang (butuh tanggal, keterangan, nominal)")
+		}
+	}
+
+	date, err := parseStatementDate(record[cols[0]])
+	if err != nil {
+		return StatementLine{}, err
+	}
+	amount, err := ParseAmount(record[cols[2]])
+	if err != nil {
+		return StatementLine{}, err
+	}
+	desc := strings.Join(strings.Fields(record[cols[1]]), " ")
+	for utf8.RuneCountInString(desc) > maxDescriptionLength {
+		_, size := utf8.DecodeLastRuneInString(desc)
+		desc = desc[:len(desc)-size]
+	}
+	return StatementLine{Date: date, Description: desc, Amount: amount}, nil
+}
+
+func parseStatementDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	// Sebagian bank nyertain jam ("02/01/2026 10:15", "02 Jan 2026 10:15"), cukup tanggalnya
+	dateOnly := s
+	if i := strings.LastIndex(s, " "); i > 0 && strings.Contains(s[i:], ":") {
+		dateOnly = strings.TrimSpace(s[:i])
+	}
+	for _, v := range []string{s, dateOnly} {
+		for _, layout := range statementDateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("tanggal %q tidak dikenal", s)
+}
+
+// ParseAmount baca nominal format Indonesia maupun internasional:
+// "150.123", "150.123,00", "150,123.00", "Rp 150.123", "-150123", "150.123 DB".
+// Akhiran CR = masuk, DB = keluar (negatif).
+func ParseAmount(s string) (float64, error) {
+	orig := s
+	s = strings.ToUpper(strings.TrimSpace(s))
+	negative := false
+	for _, suffix := range []string{"CR", "DB"} {
+		if rest, ok := strings.CutSuffix(s, suffix); ok {
+			s = strings.TrimSpace(rest)
+			negative = suffix == "DB"
+			break
+		}
+	}
+	s = strings.TrimSpace(strings.TrimPrefix(s, "RP"))
+	s = strings.ReplaceAll(s, " ", "")
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		s = rest
+		negative = true
+	} else if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = s[1 : len(s)-1]
+		negative = true
+	}
+
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0:
+		// Dua-duanya ada: yang paling belakang = pemisah desimal
+		if comma > dot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case dot >= 0:
+		s = normalizeSingleSeparator(s, ".")
+	case comma >= 0:
+		s = normalizeSingleSeparator(s, ",")
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("nominal %q tidak valid", strings.TrimSpace(orig))
+	}
+	v = math.Round(v*100) / 100
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// Cuma satu jenis pemisah: muncul berkali-kali atau diikuti pas 3 digit =
+// pemisah ribuan (rupiah praktis gak pernah pakai sen), selain itu desimal.
+func normalizeSingleSeparator(s, sep string) string {
+	last := strings.LastIndex(s, sep)
+	if strings.Count(s, sep) > 1 || len(s)-last-1 == 3 {
+		return strings.ReplaceAll(s, sep, "")
+	}
+	return strings.Replace(s, sep, ".", 1)
+}
+
+// fingerprints bikin sidik tiap baris (tanggal + keterangan + nominal + urutan
+// kemunculan baris kembar) biar import ulang file yang sama gak dobel, tapi
+// dua transfer beneran yang kebetulan identik di hari yang sama tetap masuk.
+func fingerprints(lines []StatementLine) []string {
+	seen := map[string]int{}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		base := fmt.Sprintf("%s|%s|%.2f", l.Date.Format(DateLayout), strings.ToLower(l.Description), l.Amount)
+		seen[base]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", base, seen[base])))
+		out[i] = hex.EncodeToString(sum[:])
+	}
+	return out
+}
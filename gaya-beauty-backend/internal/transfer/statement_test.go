@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "150.123", want: 150123},      // titik + 3 digit = ribuan
+		{in: "150.12", want: 150.12},       // titik + 2 digit = desimal
+		{in: "1.500", want: 1500},          // ribuan, bukan 1,5
+		{in: "(1.500,00)", want: -1500},    // kurung = debit
+		{in: "150.123,00", want: 150123},   // format Indonesia
+		{in: "150,123.00", want: 150123},   // format internasional
+		{in: "1.234.567", want: 1234567},   // pemisah ribuan berkali-kali
+		{in: "12,5", want: 12.5},           // koma desimal
+		{in: "Rp 150.123", want: 150123},   // prefix rupiah
+		{in: "rp150.123", want: 150123},    // huruf kecil, tanpa spasi
+		{in: "-150123", want: -150123},     // minus
+		{in: "150.123 DB", want: -150123},  // debit
+		{in: " 150.123 CR ", want: 150123}, // kredit
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.2.3,4,5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAmount(%q) = %v, mau error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAmount(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q) = %v, mau %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func day(s string) time.Time {
+	t, _ := time.Parse(DateLayout, s)
+	return t
+}
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		csv       string
+		want      []StatementLine
+		wantErrAt []int // nomor baris yang masuk LineError
+	}{
+		{
+			name: "koma tanpa header",
+			csv:  "2026-03-01,TRF DARI ANI,150.123\n2026-03-02,BIAYA ADM,2.500 DB\n",
+			want: []StatementLine{
+				{LineNo: 1, Date: day("2026-03-01"), Description: "TRF DARI ANI", Amount: 150123},
+				{LineNo: 2, Date: day("2026-03-02"), Description: "BIAYA ADM", Amount: -2500},
+			},
+		},
+		{
+			name: "titik koma, BOM, header urutan beda",
+			csv:  "\xef\xbb\xbfNominal;Tanggal;Keterangan\n150.123,00;01/03/2026;TRF  DARI   ANI\n\n(1.500,00);02/03/2026;BIAYA\n",
+			want: []StatementLine{
+				{LineNo: 2, Date: day("2026-03-01"), Description: "TRF DARI ANI", Amount: 150123},
+				{LineNo: 4, Date: day("2026-03-02"), Description: "BIAYA", Amount: -1500},
+			},
+		},
+		{
+			name: "baris rusak dilaporkan, sisanya jalan",
+			csv:  "tanggal,keterangan,nominal\n2026-03-01,OK,99.001\nkemarin,TANGGAL SALAH,1.000\n2026-03-01,NOMINAL SALAH,abc\n2026-03-01,KURANG KOLOM\n02 Mar 2026 10:15,JAM,1.000\n",
+			want: []StatementLine{
+				{LineNo: 2, Date: day("2026-03-01"), Description: "OK", Amount: 99001},
+				{LineNo: 6, Date: day("2026-03-02"), Description: "JAM", Amount: 1000},
+			},
+			wantErrAt: []int{3, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, lineErrs, err := ParseStatement(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("dapat %d baris %+v, mau %d", len(lines), lines, len(tt.want))
+			}
+			for i := range lines {
+				if lines[i] != tt.want[i] {
+					t.Errorf("baris %d = %+v, mau %+v", i, lines[i], tt.want[i])
+				}
+			}
+			if len(lineErrs) != len(tt.wantErrAt) {
+				t.Fatalf("dapat error %+v, mau di baris %v", lineErrs, tt.wantErrAt)
+			}
+			for i, le := range lineErrs {
+				if le.LineNo != tt.wantErrAt[i] {
+					t.Errorf("error %d di baris %d, mau %d", i, le.LineNo, tt.wantErrAt[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseStatementEmpty(t *testing.T) {
+	for _, in := range []string{"", " \n\n"} {
+		if _, _, err := ParseStatement(strings.NewReader(in)); err != ErrEmptyStatement {
+			t.Errorf("ParseStatement(%q) error = %v, mau ErrEmptyStatement", in, err)
+		}
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	same := StatementLine{Date: day("2026-03-01"), Description: "TRF DARI ANI", Amount: 150123}
+	other := StatementLine{Date: day("2026-03-01"), Description: "TRF DARI BUDI", Amount: 150123}
+	file := []StatementLine{same, other, same}
+
+	first := fingerprints(file)
+	if first[0] == first[2] {
+		t.Error("dua transfer kembar di hari yang sama dapat sidik sama, yang kedua bakal dianggap dobel")
+	}
+	if first[0] == first[1] {
+		t.Error("keterangan beda dapat sidik sama")
+	}
+
+	again := fingerprints(file)
+	for i := range first {
+		if first[i] != again[i] {
+			t.Errorf("import ulang baris %d dapat sidik beda", i)
+		}
+	}
+
+	// Huruf besar/kecil keterangan gak bikin beda
+	lower := same
+	lower.Description = "trf dari ani"
+	if fingerprints([]StatementLine{lower})[0] != first[0] {
+		t.Error("keterangan beda kapital dapat sidik beda")
+	}
+}
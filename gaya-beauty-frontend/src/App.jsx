@@ -11,7 +11,7 @@ import MyOrders from './pages/MyOrders'
 
 // --- PAGES: ADMIN ---
 import Login from './pages/Login'
-import Register from './pages/Register'
+import AcceptInvite from './pages/AcceptInvite'
 import AdminDashboard from './pages/AdminDashboard'
 import AddProduct from './pages/AddProduct'
 
@@ -29,7 +29,7 @@ function App() {
 
       {/* === ADMIN ROUTES === */}
       <Route path="/login" element={<Login />} />
-      <Route path="/register" element={<Register />} />
+      <Route path="/accept-invite" element={<AcceptInvite />} />
       <Route path="/admin" element={<AdminDashboard />} />
       <Route path="/products/create" element={<AddProduct />} />
     </Routes>
@@ -0,0 +1,129 @@
+import { useState } from 'react'
+import { useNavigate, useSearchParams, Link } from 'react-router-dom'
+import axios from 'axios'
+
+// Akun staff gak bisa daftar sendiri: owner/admin bikin undangan, token-nya
+// dikirim ke calon staff (bisa lewat link /accept-invite?token=...)
+export default function AcceptInvite() {
+  const navigate = useNavigate()
+  const [searchParams] = useSearchParams()
+
+  const [token, setToken] = useState(searchParams.get('token') || '')
+  const [fullName, setFullName] = useState('')
+  const [password, setPassword] = useState('')
+  const [confirmPassword, setConfirmPassword] = useState('')
+  const [isLoading, setIsLoading] = useState(false)
+
+  const handleSubmit = async (e) => {
+    e.preventDefault()
+    if (password.length < 8) return alert('Password minimal 8 karakter ya.')
+    if (password !== confirmPassword)
+      return alert('Konfirmasi password belum sama.')
+
+    setIsLoading(true)
+    try {
+      const res = await axios.post(
+        `${import.meta.env.VITE_API_URL}/invites/accept`,
+        { token: token.trim(), full_name: fullName, password }
+      )
+      alert(res.data.message)
+      navigate('/login')
+    } catch (err) {
+      console.error(err)
+      alert(err.response?.data || 'Gagal aktifkan akun, coba cek token-nya.')
+    } finally {
+      setIsLoading(false)
+    }
+  }
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-pink-50 font-sans p-4">
+      <div className="bg-white p-8 rounded-3xl shadow-xl w-full max-w-md border border-pink-100">
+        {/* Header */}
+        <div className="text-center mb-8">
+          <h2 className="text-3xl font-extrabold text-gray-800">
+            Aktifkan Akun Staff
+          </h2>
+          <p className="text-pink-500 font-bold text-sm tracking-wider mt-1">
+            GAYA BEAUTY DASHBOARD
+          </p>
+        </div>
+
+        <form onSubmit={handleSubmit} className="space-y-6">
+          <div>
+            <label className="block text-sm font-bold text-gray-700 mb-2">
+              Token Undangan
+            </label>
+            <input
+              type="text"
+              value={token}
+              onChange={(e) => setToken(e.target.value)}
+              placeholder="Tempel token dari admin"
+              className="w-full p-3 rounded-xl border border-gray-300 focus:border-pink-500 focus:ring-2 focus:ring-pink-200 outline-none transition bg-white font-mono text-sm"
+              required
+            />
+          </div>
+
+          <div>
+            <label className="block text-sm font-bold text-gray-700 mb-2">
+              Nama Lengkap
+            </label>
+            <input
+              type="text"
+              value={fullName}
+              onChange={(e) => setFullName(e.target.value)}
+              placeholder="Nama kamu"
+              className="w-full p-3 rounded-xl border border-gray-300 focus:border-pink-500 focus:ring-2 focus:ring-pink-200 outline-none transition bg-white"
+              required
+            />
+          </div>
+
+          <div>
+            <label className="block text-sm font-bold text-gray-700 mb-2">
+              Password Baru
+            </label>
+            <input
+              type="password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              placeholder="Minimal 8 karakter"
+              className="w-full p-3 rounded-xl border border-gray-300 focus:border-pink-500 focus:ring-2 focus:ring-pink-200 outline-none transition bg-white"
+              required
+            />
+          </div>
+
+          <div>
+            <label className="block text-sm font-bold text-gray-700 mb-2">
+              Ulangi Password
+            </label>
+            <input
+              type="password"
+              value={confirmPassword}
+              onChange={(e) => setConfirmPassword(e.target.value)}
+              placeholder="••••••••"
+              className="w-full p-3 rounded-xl border border-gray-300 focus:border-pink-500 focus:ring-2 focus:ring-pink-200 outline-none transition bg-white"
+              required
+            />
+          </div>
+
+          <button
+            type="submit"
+            disabled={isLoading}
+            className="w-full bg-pink-600 text-white py-3 rounded-xl font-bold shadow-lg hover:bg-pink-700 hover:shadow-pink-300 transition transform hover:-translate-y-1 disabled:bg-gray-400 disabled:cursor-not-allowed"
+          >
+            {isLoading ? 'Sedang Memproses...' : 'Aktifkan Akun'}
+          </button>
+        </form>
+
+        <div className="mt-6 text-center">
+          <p className="text-sm text-gray-500">
+            Sudah punya akun?{' '}
+            <Link to="/login" className="text-pink-600 font-bold hover:underline">
+              Masuk di sini
+            </Link>
+          </p>
+        </div>
+      </div>
+    </div>
+  )
+}
@@ -8,59 +8,59 @@ function AddProduct() {
   const [price, setPrice] = useState('')
   const [stock, setStock] = useState('')
   const [category, setCategory] = useState('Skincare')
-  const [imageUrl, setImageUrl] = useState('')
+  const [images, setImages] = useState([])
+  const [previews, setPreviews] = useState([])
   const [description, setDescription] = useState('')
-  const [isUploading, setIsUploading] = useState(false)
   const [isSubmitting, setIsSubmitting] = useState(false)
 
-  // --- 1. MAGIC UPLOAD KE IMGUR ---
-  const handleImageUpload = async (e) => {
-    const file = e.target.files[0]
-    if (!file) return
-
-    setIsUploading(true)
-    const formData = new FormData()
-    formData.append('image', file)
-
-    try {
-      // Upload ke Imgur (Public Demo ID)
-      const res = await axios.post('https://api.imgur.com/3/image', formData, {
-        headers: { Authorization: 'Client-ID 87c679a957242df' },
-      })
-      setImageUrl(res.data.data.link)
-      alert('Gambar berhasil di-upload!')
-    } catch (err) {
-      console.error(err)
-      alert('Gagal upload. Coba file JPG/PNG yg lebih kecil.')
-    } finally {
-      setIsUploading(false)
-    }
+  // --- 1. PILIH FOTO (di-upload ke server kita setelah produk dibuat) ---
+  const handleImageSelect = (e) => {
+    const files = Array.from(e.target.files).slice(0, 10)
+    previews.forEach((url) => URL.revokeObjectURL(url))
+    setImages(files)
+    setPreviews(files.map((file) => URL.createObjectURL(file)))
   }
 
   // --- 2. SIMPAN PRODUK ---
   const handleSubmit = async (e) => {
     e.preventDefault()
-    if (!name || !price || !stock || !imageUrl)
+    if (!name || !price || !stock || images.length === 0)
       return alert('Lengkapi data dulu Bos!')
 
     setIsSubmitting(true)
     const token = localStorage.getItem('admin_token')
 
     try {
-      await axios.post(
-        `${import.meta.env.VITE_API_URL}/products`,
+      const res = await axios.post(
+        `${import.meta.env.VITE_API_URL}/products/create`,
         {
           name,
           price: parseInt(price),
           stock: parseInt(stock),
           category,
-          image_url: imageUrl,
           description,
         },
         {
           headers: { Authorization: `Bearer ${token}` },
         }
       )
+
+      // Upload foto ke /products/{id}/images (foto pertama jadi cover)
+      const formData = new FormData()
+      images.forEach((file) => formData.append('images', file))
+      try {
+        await axios.post(
+          `${import.meta.env.VITE_API_URL}/products/${res.data.id}/images`,
+          formData,
+          { headers: { Authorization: `Bearer ${token}` } }
+        )
+      } catch (uploadErr) {
+        console.error(uploadErr)
+        alert(
+          uploadErr.response?.data ||
+            'Produk tersimpan, tapi foto gagal di-upload. Coba upload ulang fotonya.'
+        )
+      }
       alert('Produk Berhasil Ditambahkan! ')
       navigate('/admin')
     } catch (err) {
@@ -138,28 +138,26 @@ function AddProduct() {
           {/* INPUT UPLOAD GAMBAR */}
           <div>
             <label className="block text-sm font-bold text-gray-700 mb-2">
-              Upload Gambar
+              Foto Produk (maks. 10, JPG/PNG/WebP)
             </label>
-            <div className="flex items-center gap-4">
-              <input
-                type="file"
-                accept="image/*"
-                onChange={handleImageUpload}
-                disabled={isUploading}
-                className="block w-full text-sm text-gray-500 file:mr-4 file:py-2 file:px-4 file:rounded-full file:border-0 file:text-sm file:font-semibold file:bg-pink-50 file:text-pink-700 hover:file:bg-pink-100"
-              />
-              {isUploading && (
-                <span className="text-sm text-pink-500 animate-pulse font-bold">
-                  Uploading...
-                </span>
-              )}
-            </div>
-            {imageUrl && (
-              <img
-                src={imageUrl}
-                alt="Preview"
-                className="mt-3 h-32 rounded-lg object-cover border"
-              />
+            <input
+              type="file"
+              accept="image/jpeg,image/png,image/webp"
+              multiple
+              onChange={handleImageSelect}
+              className="block w-full text-sm text-gray-500 file:mr-4 file:py-2 file:px-4 file:rounded-full file:border-0 file:text-sm file:font-semibold file:bg-pink-50 file:text-pink-700 hover:file:bg-pink-100"
+            />
+            {previews.length > 0 && (
+              <div className="mt-3 flex gap-3 flex-wrap">
+                {previews.map((url, i) => (
+                  <img
+                    key={url}
+                    src={url}
+                    alt={`Preview ${i + 1}`}
+                    className="h-32 rounded-lg object-cover border"
+                  />
+                ))}
+              </div>
             )}
           </div>
           <div>
@@ -184,7 +182,7 @@ function AddProduct() {
             </button>
             <button
               type="submit"
-              disabled={isSubmitting || !imageUrl}
+              disabled={isSubmitting || images.length === 0}
               className="flex-1 py-3 rounded-xl font-bold text-white bg-pink-600 hover:bg-pink-700 shadow-lg disabled:bg-gray-400"
             >
               Simpan Produk
@@ -7,6 +7,7 @@ function AdminDashboard() {
   const [orders, setOrders] = useState([])
   const [loading, setLoading] = useState(true)
   const [token, setToken] = useState('')
+  const [statusFlow, setStatusFlow] = useState({})
 
   useEffect(() => {
     const savedToken = localStorage.getItem('admin_token')
@@ -17,8 +18,25 @@ function AdminDashboard() {
     }
     setToken(savedToken)
     fetchOrders(savedToken)
+    fetchStatuses()
   }, [])
 
+  // Daftar status & perpindahan yang sah diambil dari backend
+  const fetchStatuses = async () => {
+    try {
+      const res = await axios.get(
+        `${import.meta.env.VITE_API_URL}/order-statuses`
+      )
+      const flow = {}
+      res.data.forEach((s) => {
+        flow[s.status] = s.next
+      })
+      setStatusFlow(flow)
+    } catch (err) {
+      console.error(err)
+    }
+  }
+
   const fetchOrders = async (authToken) => {
     try {
       const res = await axios.get(`${import.meta.env.VITE_API_URL}/orders`, {
@@ -51,7 +69,7 @@ function AdminDashboard() {
       fetchOrders(token) // Refresh tabel
     } catch (err) {
       console.error(err)
-      alert('Gagal update status. Cek backend.')
+      alert(err.response?.data?.error || 'Gagal update status. Cek backend.')
     }
   }
 
@@ -67,27 +85,24 @@ function AdminDashboard() {
       minimumFractionDigits: 0,
     }).format(num)
 
-  // Opsi Status yang tersedia
-  const statusOptions = [
-    'Pending',
-    'Diproses',
-    'Dikirim',
-    'Selesai',
-    'Dibatalkan',
-  ]
+  // Opsi status = status sekarang + tujuan yang diizinkan backend
+  const statusOptions = (current) => [current, ...(statusFlow[current] || [])]
 
   // Helper warna status
   const getStatusColor = (status) => {
     switch (status) {
       case 'Pending':
         return 'bg-yellow-100 text-yellow-800'
-      case 'Diproses':
+      case 'Lunas':
+        return 'bg-teal-100 text-teal-800'
+      case 'Dikemas':
         return 'bg-blue-100 text-blue-800'
       case 'Dikirim':
         return 'bg-purple-100 text-purple-800'
       case 'Selesai':
         return 'bg-green-100 text-green-800'
       case 'Dibatalkan':
+      case 'Refund':
         return 'bg-red-100 text-red-800'
       default:
         return 'bg-gray-100 text-gray-800'
@@ -227,7 +242,7 @@ function AdminDashboard() {
                             handleUpdateStatus(order.id, e.target.value)
                           }
                         >
-                          {statusOptions.map((option) => (
+                          {statusOptions(order.status).map((option) => (
                             <option key={option} value={option}>
                               {option}
                             </option>
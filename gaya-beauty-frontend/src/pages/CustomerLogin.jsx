@@ -16,6 +16,7 @@ const CustomerLogin = () => {
       });
 
       localStorage.setItem("customer_user", JSON.stringify(response.data.user));
+      localStorage.setItem("customer_token", response.data.token);
       
       alert("Login Berhasil! Selamat Belanja.");
       navigate("/");
@@ -7,7 +7,9 @@ function Home() {
 
   // --- STATE DATA ---
   const [products, setProducts] = useState([])
-  const [cart, setCart] = useState([])
+  // Keranjang disimpan di server (GET/POST/PATCH/DELETE /cart)
+  const emptyCart = { items: [], total_price: 0 }
+  const [cart, setCart] = useState(emptyCart)
   const [user, setUser] = useState(null)
 
   // --- STATE UI ---
@@ -27,6 +29,7 @@ function Home() {
     const storedUser = localStorage.getItem('customer_user')
     if (storedUser) {
       setUser(JSON.parse(storedUser))
+      fetchCart()
     }
     fetchProducts()
   }, [])
@@ -41,8 +44,11 @@ function Home() {
 
   const fetchProducts = async () => {
     try {
-      const res = await axios.get(`${import.meta.env.VITE_API_URL}/products`)
-      setProducts(res.data || [])
+      // Katalog sekarang dipaging; ambil halaman pertama yang besar dulu
+      const res = await axios.get(`${import.meta.env.VITE_API_URL}/products`, {
+        params: { limit: 100 },
+      })
+      setProducts(res.data.items || [])
     } catch (err) {
       console.error('Gagal ambil produk', err)
     } finally {
@@ -50,6 +56,30 @@ function Home() {
     }
   }
 
+  const authHeader = () => ({
+    headers: {
+      Authorization: `Bearer ${localStorage.getItem('customer_token')}`,
+    },
+  })
+
+  // Pesan error dari backend (http.Error = teks biasa, sebagian JSON { error })
+  const errorMessage = (err, fallback) =>
+    err.response?.data?.error ||
+    (typeof err.response?.data === 'string' && err.response.data.trim()) ||
+    fallback
+
+  const fetchCart = async () => {
+    try {
+      const res = await axios.get(
+        `${import.meta.env.VITE_API_URL}/cart`,
+        authHeader()
+      )
+      setCart(res.data)
+    } catch (err) {
+      console.error('Gagal ambil keranjang', err)
+    }
+  }
+
   // 2. HELPER URL GAMBAR
   const getImageUrl = (url) => {
     if (!url || url === '') return 'https://placehold.co/150?text=No+Image'
@@ -68,46 +98,66 @@ function Home() {
   )
 
   // 4. LOGIKA ADD TO CART (DENGAN NOTIFIKASI ALA TIKTOK)
-  const addToCart = (product) => {
+  const addToCart = async (product) => {
     if (!user) {
       alert('Eits, Login dulu dong cantik biar bisa belanja! 😉')
       navigate('/login-member')
       return
     }
 
-    const existing = cart.find((item) => item.id === product.id)
-    if (existing) {
-      setCart(
-        cart.map((item) =>
-          item.id === product.id ? { ...item, qty: item.qty + 1 } : item
-        )
-      )
-    } else {
-      setCart([...cart, { ...product, qty: 1 }])
+    // Produk bervarian (shade/ukuran) harus pilih varian dulu di halaman detail
+    if (product.variants?.length > 0) {
+      navigate(`/product/${product.id}`)
+      return
     }
 
-    // 🔥 Munculin Notif, Gak Langsung Buka Sidebar
-    setToast(`✅ ${product.name} berhasil masuk keranjang!`)
+    try {
+      const res = await axios.post(
+        `${import.meta.env.VITE_API_URL}/cart`,
+        { product_id: product.id, quantity: 1 },
+        authHeader()
+      )
+      setCart(res.data)
+      // 🔥 Munculin Notif, Gak Langsung Buka Sidebar
+      setToast(`✅ ${product.name} berhasil masuk keranjang!`)
+    } catch (err) {
+      setToast(`⚠️ ${errorMessage(err, 'Gagal masuk keranjang')}`)
+    }
   }
 
   // 🔥 FITUR BARU: TOMBOL TAMBAH & KURANG (+ / -)
-  const updateQty = (id, amount) => {
-    setCart(
-      cart.map((item) => {
-        if (item.id === id) {
-          const newQty = item.qty + amount
-          return newQty > 0 ? { ...item, qty: newQty } : item
-        }
-        return item
-      })
-    )
+  const updateQty = async (item, amount) => {
+    const newQty = item.quantity + amount
+    if (newQty <= 0) return
+    try {
+      const res = await axios.patch(
+        `${import.meta.env.VITE_API_URL}/cart`,
+        {
+          product_id: item.product_id,
+          variant_id: item.variant_id,
+          quantity: newQty,
+        },
+        authHeader()
+      )
+      setCart(res.data)
+    } catch (err) {
+      setToast(`⚠️ ${errorMessage(err, 'Gagal ubah jumlah')}`)
+    }
   }
 
-  const removeFromCart = (id) => {
-    setCart(cart.filter((item) => item.id !== id))
+  const removeFromCart = async (item) => {
+    try {
+      const res = await axios.delete(`${import.meta.env.VITE_API_URL}/cart`, {
+        ...authHeader(),
+        params: { product_id: item.product_id, variant_id: item.variant_id },
+      })
+      setCart(res.data)
+    } catch (err) {
+      setToast(`⚠️ ${errorMessage(err, 'Gagal hapus item')}`)
+    }
   }
 
-  const totalPrice = cart.reduce((acc, curr) => acc + curr.price * curr.qty, 0)
+  const totalPrice = cart.total_price
 
   // 5. LOGIKA CHECKOUT
   const handleCheckout = async () => {
@@ -118,32 +168,30 @@ function Home() {
     }
 
     try {
-      await axios.post(`${import.meta.env.VITE_API_URL}/checkout`, {
-        customer_name: user.full_name,
-        customer_id: user.id,
-        payment_method: paymentMethod,
-        cart_items: cart.map((item) => ({
-          product_id: item.id,
-          quantity: item.qty,
-          price: item.price,
-        })),
-        total_price: totalPrice,
-      })
+      // Tanpa cart_items: server checkout isi keranjang tersimpan, lalu dikosongin
+      await axios.post(
+        `${import.meta.env.VITE_API_URL}/checkout`,
+        { payment_method: paymentMethod, total_price: totalPrice },
+        authHeader()
+      )
       alert(`Berhasil! Pesanan Kak ${user.full_name} sedang diproses.`)
-      setCart([])
+      setCart(emptyCart)
       setShowCart(false)
       fetchProducts()
       navigate('/my-orders')
     } catch (err) {
       console.error(err)
-      alert('Checkout Gagal. Periksa alamat API di Vercel lo Bro!')
+      alert(`Checkout Gagal. ${errorMessage(err, 'Silakan coba lagi.')}`)
+      // Harga/stok bisa sudah berubah, tampilkan keranjang versi server
+      fetchCart()
     }
   }
 
   const handleLogout = () => {
     localStorage.removeItem('customer_user')
+    localStorage.removeItem('customer_token')
     setUser(null)
-    setCart([])
+    setCart(emptyCart)
     window.location.reload()
   }
 
@@ -232,9 +280,9 @@ function Home() {
                 className="relative p-2 bg-pink-100 rounded-full text-pink-600 hover:bg-pink-200 transition"
               >
                 🛒
-                {cart.length > 0 && (
+                {cart.items.length > 0 && (
                   <span className="absolute -top-1 -right-1 bg-red-500 text-white text-[10px] w-5 h-5 flex items-center justify-center rounded-full font-bold shadow-sm">
-                    {cart.reduce((a, c) => a + c.qty, 0)}
+                    {cart.items.reduce((a, c) => a + c.quantity, 0)}
                   </span>
                 )}
               </button>
@@ -273,11 +321,20 @@ function Home() {
                   className="relative aspect-square overflow-hidden cursor-pointer"
                   onClick={() => navigate(`/product/${p.id}`)}
                 >
-                  <img
-                    src={getImageUrl(p.image_url)}
-                    alt={p.name}
-                    className="w-full h-full object-cover group-hover:scale-110 transition duration-500"
-                  />
+                  <picture className="block w-full h-full">
+                    {p.srcset?.card?.webp && (
+                      <source
+                        type="image/webp"
+                        srcSet={getImageUrl(p.srcset.card.webp)}
+                      />
+                    )}
+                    <img
+                      src={getImageUrl(p.srcset?.card?.src)}
+                      alt={p.name}
+                      loading="lazy"
+                      className="w-full h-full object-cover group-hover:scale-110 transition duration-500"
+                    />
+                  </picture>
                   {p.stock <= 0 && (
                     <div className="absolute inset-0 bg-black/50 flex items-center justify-center text-white font-bold text-xs">
                       STOK HABIS
@@ -325,37 +382,45 @@ function Home() {
             </div>
 
             <div className="flex-1 overflow-y-auto p-5 space-y-4">
-              {cart.length === 0 ? (
+              {cart.items.length === 0 ? (
                 <div className="text-center py-20 text-gray-400 italic text-sm">
                   Keranjang kosong...
                 </div>
               ) : (
-                cart.map((item) => (
+                cart.items.map((item) => (
                   <div
-                    key={item.id}
+                    key={`${item.product_id}-${item.variant_id}`}
                     className="flex justify-between items-center bg-white p-3 rounded-xl border border-pink-100 shadow-sm"
                   >
                     <div className="flex-1">
                       <p className="font-bold text-sm text-gray-800 truncate">
                         {item.name}
                       </p>
+                      {item.variant && (
+                        <p className="text-[10px] text-pink-400">{item.variant}</p>
+                      )}
                       <p className="text-xs text-gray-400 mb-2">
                         {formatRupiah(item.price)}
                       </p>
+                      {!item.available && (
+                        <p className="text-[10px] text-red-500 mb-2">
+                          Stok tinggal {item.stock}, kurangi jumlahnya
+                        </p>
+                      )}
 
                       {/* 🔥 TOMBOL TAMBAH KURANG JUMLAH 🔥 */}
                       <div className="flex items-center gap-3">
                         <button
-                          onClick={() => updateQty(item.id, -1)}
+                          onClick={() => updateQty(item, -1)}
                           className="w-6 h-6 rounded bg-gray-100 hover:bg-gray-200 text-gray-600 font-bold"
                         >
                           -
                         </button>
                         <span className="text-sm font-bold text-gray-800">
-                          {item.qty}
+                          {item.quantity}
                         </span>
                         <button
-                          onClick={() => updateQty(item.id, 1)}
+                          onClick={() => updateQty(item, 1)}
                           className="w-6 h-6 rounded bg-pink-100 hover:bg-pink-200 text-pink-600 font-bold"
                         >
                           +
@@ -363,7 +428,7 @@ function Home() {
                       </div>
                     </div>
                     <button
-                      onClick={() => removeFromCart(item.id)}
+                      onClick={() => removeFromCart(item)}
                       className="text-red-400 text-xs hover:bg-red-50 px-2 py-1 rounded transition ml-2"
                     >
                       🗑️
@@ -382,7 +447,7 @@ function Home() {
               </div>
               <button
                 onClick={handleCheckout}
-                disabled={cart.length === 0}
+                disabled={cart.items.length === 0}
                 className="w-full bg-pink-600 text-white py-3 rounded-xl font-bold hover:bg-pink-700 shadow-lg shadow-pink-200"
               >
                 Bayar Sekarang
@@ -85,15 +85,15 @@ export default function Login() {
           </button>
         </form>
 
-        {/* Link ke Register (Opsional) */}
+        {/* Akun staff baru cuma lewat undangan dari owner/admin */}
         <div className="mt-6 text-center">
           <p className="text-sm text-gray-500">
-            Belum punya akun?{' '}
+            Dapat undangan staff?{' '}
             <Link
-              to="/register"
+              to="/accept-invite"
               className="text-pink-600 font-bold hover:underline"
             >
-              Daftar Admin Baru
+              Aktifkan Akun
             </Link>
           </p>
         </div>
@@ -4,7 +4,8 @@ import { useNavigate } from 'react-router-dom'
 
 const MyOrders = () => {
   const [orders, setOrders] = useState([])
-  const [user, setUser] = useState(null)
+  const [proofForm, setProofForm] = useState(null) // { orderId, senderName, senderBank, file }
+  const [isUploading, setIsUploading] = useState(false)
   const navigate = useNavigate()
 
   useEffect(() => {
@@ -15,19 +16,26 @@ const MyOrders = () => {
       navigate('/login-member')
       return
     }
-    const parsedUser = JSON.parse(storedUser)
-    setUser(parsedUser)
-    fetchMyOrders(parsedUser.id)
+    fetchMyOrders()
   }, [])
 
-  const fetchMyOrders = async (userId) => {
+  const authHeaders = () => ({
+    Authorization: `Bearer ${localStorage.getItem('customer_token')}`,
+  })
+
+  const fetchMyOrders = async () => {
     try {
-      const res = await axios.get(
-        `${import.meta.env.VITE_API_URL}/my-orders?user_id=${userId}`
-      )
+      const res = await axios.get(`${import.meta.env.VITE_API_URL}/my-orders`, {
+        headers: authHeaders(),
+      })
       setOrders(res.data || [])
     } catch (error) {
       console.error(error)
+      if (error.response?.status === 401) {
+        localStorage.removeItem('customer_user')
+        localStorage.removeItem('customer_token')
+        navigate('/login-member')
+      }
     }
   }
 
@@ -36,16 +44,65 @@ const MyOrders = () => {
     if (!confirm) return
 
     try {
-      await axios.post(`${import.meta.env.VITE_API_URL}/complete-order`, {
-        order_id: orderId,
-      })
+      await axios.post(
+        `${import.meta.env.VITE_API_URL}/complete-order`,
+        { order_id: orderId },
+        { headers: authHeaders() }
+      )
       alert('Terima kasih! Transaksi selesai.')
-      fetchMyOrders(user.id) // Refresh
+      fetchMyOrders() // Refresh
     } catch (error) {
       alert('Gagal konfirmasi.')
     }
   }
 
+  const handleCancelOrder = async (orderId) => {
+    const reason = window.prompt('Alasan batalin pesanan? (boleh kosong)')
+    if (reason === null) return
+
+    try {
+      await axios.post(
+        `${import.meta.env.VITE_API_URL}/my-orders/cancel`,
+        { order_id: orderId, reason },
+        { headers: authHeaders() }
+      )
+      alert('Pesanan dibatalkan.')
+      fetchMyOrders()
+    } catch (error) {
+      alert(error.response?.data?.error || 'Gagal batalin pesanan.')
+    }
+  }
+
+  const handleUploadProof = async (e) => {
+    e.preventDefault()
+    if (!proofForm.file || !proofForm.senderName.trim()) {
+      alert('Isi nama pemilik rekening dan pilih foto bukti transfer dulu ya!')
+      return
+    }
+
+    const data = new FormData()
+    data.append('order_id', proofForm.orderId)
+    data.append('sender_name', proofForm.senderName)
+    data.append('sender_bank', proofForm.senderBank)
+    data.append('receipt', proofForm.file)
+
+    setIsUploading(true)
+    try {
+      await axios.post(
+        `${import.meta.env.VITE_API_URL}/my-orders/transfer-proof`,
+        data,
+        { headers: authHeaders() }
+      )
+      alert('Bukti transfer terkirim! Tunggu verifikasi admin ya.')
+      setProofForm(null)
+      fetchMyOrders()
+    } catch (error) {
+      alert(error.response?.data?.error || 'Gagal upload bukti transfer.')
+    } finally {
+      setIsUploading(false)
+    }
+  }
+
   const formatRupiah = (num) =>
     new Intl.NumberFormat('id-ID', {
       style: 'currency',
@@ -122,9 +179,17 @@ const MyOrders = () => {
                     </button>
                   )}
                   {order.status === 'Pending' && (
-                    <span className="text-xs text-gray-400 italic">
-                      Menunggu dikirim penjual...
-                    </span>
+                    <div className="flex items-center gap-3">
+                      <span className="text-xs text-gray-400 italic">
+                        Menunggu dikirim penjual...
+                      </span>
+                      <button
+                        onClick={() => handleCancelOrder(order.id)}
+                        className="text-xs text-red-500 font-bold hover:underline"
+                      >
+                        Batalkan
+                      </button>
+                    </div>
                   )}
                   {order.status === 'Selesai' && (
                     <span className="text-xs text-green-500 font-bold">
@@ -132,6 +197,90 @@ const MyOrders = () => {
                     </span>
                   )}
                 </div>
+
+                {/* Transfer manual: nominal unik + upload bukti */}
+                {order.status === 'Pending' && order.transfer_amount && (
+                  <div className="mt-4 p-3 rounded-xl bg-pink-50 text-sm space-y-2">
+                    <p className="text-gray-600">
+                      Transfer tepat{' '}
+                      <span className="font-bold text-pink-600">
+                        {formatRupiah(order.transfer_amount)}
+                      </span>{' '}
+                      (termasuk kode unik)
+                    </p>
+                    {order.proof_status === 'pending' && (
+                      <p className="text-xs text-yellow-600 font-bold">
+                        Bukti transfer lagi dicek admin...
+                      </p>
+                    )}
+                    {order.proof_status === 'rejected' && (
+                      <p className="text-xs text-red-500">
+                        Bukti transfer ditolak: {order.proof_reject_reason}
+                      </p>
+                    )}
+                    {order.proof_status !== 'pending' &&
+                      (proofForm?.orderId === order.id ? (
+                        <form onSubmit={handleUploadProof} className="space-y-2">
+                          <input
+                            type="text"
+                            placeholder="Nama pemilik rekening pengirim"
+                            value={proofForm.senderName}
+                            onChange={(e) =>
+                              setProofForm({ ...proofForm, senderName: e.target.value })
+                            }
+                            className="w-full border border-gray-200 rounded-lg px-3 py-2"
+                          />
+                          <input
+                            type="text"
+                            placeholder="Bank pengirim (opsional)"
+                            value={proofForm.senderBank}
+                            onChange={(e) =>
+                              setProofForm({ ...proofForm, senderBank: e.target.value })
+                            }
+                            className="w-full border border-gray-200 rounded-lg px-3 py-2"
+                          />
+                          <input
+                            type="file"
+                            accept="image/jpeg,image/png,image/webp"
+                            onChange={(e) =>
+                              setProofForm({ ...proofForm, file: e.target.files[0] })
+                            }
+                            className="w-full text-xs"
+                          />
+                          <div className="flex gap-3">
+                            <button
+                              type="submit"
+                              disabled={isUploading}
+                              className="bg-pink-500 text-white px-4 py-2 rounded-lg text-xs font-bold hover:bg-pink-600 transition disabled:opacity-50"
+                            >
+                              {isUploading ? 'Mengirim...' : 'Kirim Bukti'}
+                            </button>
+                            <button
+                              type="button"
+                              onClick={() => setProofForm(null)}
+                              className="text-xs text-gray-500 hover:underline"
+                            >
+                              Batal
+                            </button>
+                          </div>
+                        </form>
+                      ) : (
+                        <button
+                          onClick={() =>
+                            setProofForm({
+                              orderId: order.id,
+                              senderName: '',
+                              senderBank: '',
+                              file: null,
+                            })
+                          }
+                          className="text-xs text-pink-600 font-bold hover:underline"
+                        >
+                          Upload Bukti Transfer
+                        </button>
+                      ))}
+                  </div>
+                )}
               </div>
             ))
           )}
@@ -10,6 +10,7 @@ function ProductDetail() {
   const [product, setProduct] = useState(null)
   const [loading, setLoading] = useState(true)
   const [user, setUser] = useState(null)
+  const [selectedVariant, setSelectedVariant] = useState(null)
 
   // --- CHECKOUT STATE ---
   const [showModal, setShowModal] = useState(false)
@@ -27,11 +28,16 @@ function ProductDetail() {
     const fetchProduct = async () => {
       try {
         setLoading(true)
-        const res = await axios.get(`${import.meta.env.VITE_API_URL}/products`)
-        // Cari produk berdasarkan ID (Client-side filtering sementara)
-        const found = res.data.find((p) => p.id === parseInt(id))
-        setProduct(found || null)
+        const res = await axios.get(
+          `${import.meta.env.VITE_API_URL}/products/${id}`
+        )
+        setProduct(res.data)
+        // Default pilih varian pertama yang masih ada stoknya
+        const variants = res.data.variants || []
+        setSelectedVariant(variants.find((v) => v.stock > 0) || variants[0] || null)
       } catch (err) {
+        // 404 = produk gak ada, tampilkan halaman "tidak ditemukan"
+        setProduct(null)
         console.error('Error fetching product:', err)
       } finally {
         setLoading(false)
@@ -59,6 +65,11 @@ function ProductDetail() {
       : `${import.meta.env.VITE_API_URL}/${cleanUrl}`
   }
 
+  // Harga & stok ikut varian yang dipilih (kalau produknya punya varian)
+  const price = selectedVariant ? selectedVariant.price : product?.price
+  const stock = selectedVariant ? selectedVariant.stock : product?.stock
+  const variantLabel = (v) => Object.values(v.options || {}).join(' / ')
+
   // --- HANDLERS ---
   const handleProcessOrder = async () => {
     // Validasi Dasar
@@ -76,35 +87,66 @@ function ProductDetail() {
     const finalMethod =
       paymentMethod === 'cod'
         ? 'COD (Bayar di Tempat)'
-        : `Transfer Bank - ${selectedBank}`
-
-    // Payload sesuai struktur database baru
-    const payload = {
-      customer_id: user.id,
-      customer_name: user.full_name,
-      payment_method: finalMethod,
-      total_price: product.price,
-      cart_items: [
-        { product_id: product.id, quantity: 1, price: product.price },
-      ],
+        : paymentMethod === 'online'
+          ? 'Online'
+          : `Transfer Bank - ${selectedBank}`
+
+    const auth = {
+      headers: {
+        Authorization: `Bearer ${localStorage.getItem('customer_token')}`,
+      },
     }
 
     try {
+      // Checkout selalu dari keranjang tersimpan: masukin produk ini dulu,
+      // lalu checkout sesuai total keranjang versi server
+      const cartRes = await axios.post(
+        `${import.meta.env.VITE_API_URL}/cart`,
+        {
+          product_id: product.id,
+          variant_id: selectedVariant ? selectedVariant.id : 0,
+          quantity: 1,
+        },
+        auth
+      )
       const res = await axios.post(
         `${import.meta.env.VITE_API_URL}/checkout`,
-        payload
+        {
+          payment_method: finalMethod,
+          total_price: cartRes.data.total_price,
+        },
+        auth
       )
 
+      // Bayar online: langsung ke halaman pembayaran gateway, gak perlu konfirmasi WA
+      if (paymentMethod === 'online') {
+        if (res.data.payment?.redirect_url) {
+          window.location.href = res.data.payment.redirect_url
+          return
+        }
+        alert(
+          res.data.payment_error ||
+            'Pesanan dibuat, tapi halaman pembayaran belum siap. Coba bayar lagi dari Pesanan Saya.'
+        )
+        setShowModal(false)
+        navigate('/my-orders')
+        return
+      }
+
       // Redirect ke WhatsApp Admin
       const nomorAdmin = '6285741802183'
-      const pesan = `Halo Admin Gaya Beauty! 🌸\nSaya mau konfirmasi pesanan:\n\n🛍️ *Produk:* ${product.name}\n💰 *Harga:* ${formatRupiah(product.price)}\n👤 *Nama:* ${user.full_name}\n💳 *Bayar:* ${finalMethod}\n🆔 *Order ID:* ${res.data.order_id || 'Baru'}\n\nMohon diproses ya! ✨`
+      const pesan = `Halo Admin Gaya Beauty! 🌸\nSaya mau konfirmasi pesanan:\n\n🛍️ *Produk:* ${product.name}${selectedVariant ? ` (${variantLabel(selectedVariant)})` : ''}\n💰 *Total:* ${formatRupiah(res.data.total_price)}\n👤 *Nama:* ${user.full_name}\n💳 *Bayar:* ${finalMethod}\n${res.data.transfer ? `🏦 *Nominal Transfer:* ${formatRupiah(res.data.transfer.amount)} (pas sampai 3 digit terakhir)\n` : ''}🆔 *Order ID:* ${res.data.order_id || 'Baru'}\n\nMohon diproses ya! ✨`
 
       window.open(
         `https://wa.me/${nomorAdmin}?text=${encodeURIComponent(pesan)}`,
         '_blank'
       )
 
-      alert('✅ Pesanan Berhasil dibuat!')
+      alert(
+        res.data.transfer
+          ? `✅ Pesanan Berhasil dibuat!\nTransfer tepat ${formatRupiah(res.data.transfer.amount)} (termasuk kode unik), lalu upload bukti transfer di Pesanan Saya.`
+          : '✅ Pesanan Berhasil dibuat!'
+      )
       setShowModal(false)
       navigate('/my-orders')
     } catch (err) {
@@ -156,14 +198,22 @@ function ProductDetail() {
         <div className="bg-white rounded-3xl shadow-xl overflow-hidden flex flex-col md:flex-row border border-pink-100">
           {/* Bagian Gambar */}
           <div className="w-full md:w-1/2 bg-gray-50 relative group">
-            <img
-              src={getImageUrl(product.image_url)}
-              alt={product.name}
-              className="w-full h-full object-cover aspect-square md:aspect-auto transition duration-500 group-hover:scale-105"
-              onError={(e) => {
-                e.target.src = 'https://placehold.co/400?text=No+Image'
-              }}
-            />
+            <picture className="block w-full h-full">
+              {product.srcset?.zoom?.webp && (
+                <source
+                  type="image/webp"
+                  srcSet={getImageUrl(product.srcset.zoom.webp)}
+                />
+              )}
+              <img
+                src={getImageUrl(product.srcset?.zoom?.src)}
+                alt={product.name}
+                className="w-full h-full object-cover aspect-square md:aspect-auto transition duration-500 group-hover:scale-105"
+                onError={(e) => {
+                  e.target.src = 'https://placehold.co/400?text=No+Image'
+                }}
+              />
+            </picture>
           </div>
 
           {/* Bagian Detail */}
@@ -177,9 +227,35 @@ function ProductDetail() {
             </h1>
 
             <p className="text-2xl font-black text-pink-500 mb-6">
-              {formatRupiah(product.price)}
+              {formatRupiah(price)}
             </p>
 
+            {/* PILIHAN VARIAN (shade / ukuran) */}
+            {product.variants?.length > 0 && (
+              <div className="flex flex-wrap gap-2 mb-6">
+                {product.variants.map((v) => (
+                  <button
+                    key={v.id}
+                    onClick={() => setSelectedVariant(v)}
+                    className={`flex items-center gap-2 px-3 py-2 rounded-xl border text-xs font-bold transition ${
+                      selectedVariant?.id === v.id
+                        ? 'border-pink-500 bg-pink-50 text-pink-600'
+                        : 'border-gray-200 text-gray-500 hover:border-pink-300'
+                    } ${v.stock <= 0 ? 'opacity-50' : ''}`}
+                  >
+                    {v.swatch_url && (
+                      <img
+                        src={getImageUrl(v.swatch_url)}
+                        alt=""
+                        className="w-5 h-5 rounded-full object-cover"
+                      />
+                    )}
+                    {variantLabel(v)}
+                  </button>
+                ))}
+              </div>
+            )}
+
             <div className="prose prose-pink text-gray-500 mb-8 text-sm leading-relaxed flex-grow">
               {product.description || 'Deskripsi produk belum tersedia.'}
             </div>
@@ -188,24 +264,22 @@ function ProductDetail() {
               <div className="flex justify-between items-center mb-4 text-sm font-bold text-gray-400">
                 <span>Stok Tersedia</span>
                 <span
-                  className={
-                    product.stock > 0 ? 'text-green-500' : 'text-red-500'
-                  }
+                  className={stock > 0 ? 'text-green-500' : 'text-red-500'}
                 >
-                  {product.stock} pcs
+                  {stock} pcs
                 </span>
               </div>
 
               <button
-                onClick={() => product.stock > 0 && setShowModal(true)}
-                disabled={product.stock <= 0}
+                onClick={() => stock > 0 && setShowModal(true)}
+                disabled={stock <= 0}
                 className={`w-full py-4 rounded-xl font-bold text-white shadow-lg transition transform hover:scale-[1.02] active:scale-95 ${
-                  product.stock > 0
+                  stock > 0
                     ? 'bg-gradient-to-r from-pink-500 to-purple-600 hover:shadow-pink-300'
                     : 'bg-gray-300 cursor-not-allowed'
                 }`}
               >
-                {product.stock > 0 ? 'Beli Sekarang ✨' : 'Stok Habis 😭'}
+                {stock > 0 ? 'Beli Sekarang ✨' : 'Stok Habis 😭'}
               </button>
             </div>
           </div>
@@ -263,6 +337,33 @@ function ProductDetail() {
                 </div>
               </div>
 
+              {/* Option: Online (payment gateway) */}
+              <div
+                onClick={() => {
+                  setPaymentMethod('online')
+                  setSelectedBank('')
+                }}
+                className={`p-4 rounded-xl border-2 cursor-pointer flex items-center gap-4 transition-all ${
+                  paymentMethod === 'online'
+                    ? 'border-pink-500 bg-pink-50'
+                    : 'border-gray-100 hover:border-pink-200'
+                }`}
+              >
+                <div
+                  className={`w-5 h-5 rounded-full border-2 flex items-center justify-center ${paymentMethod === 'online' ? 'border-pink-500' : 'border-gray-300'}`}
+                >
+                  {paymentMethod === 'online' && (
+                    <div className="w-2.5 h-2.5 bg-pink-500 rounded-full" />
+                  )}
+                </div>
+                <div>
+                  <p className="font-bold text-gray-800">Bayar Online</p>
+                  <p className="text-xs text-gray-500">
+                    QRIS, e-wallet, VA, kartu kredit
+                  </p>
+                </div>
+              </div>
+
               {/* Option: Transfer */}
               <div
                 onClick={() => setPaymentMethod('transfer')}
@@ -315,7 +416,7 @@ function ProductDetail() {
               <div className="flex justify-between text-sm font-bold text-gray-500 mb-4">
                 <span>Total Tagihan</span>
                 <span className="text-pink-600 text-lg">
-                  {formatRupiah(product.price)}
+                  {formatRupiah(price)}
                 </span>
               </div>
               <button